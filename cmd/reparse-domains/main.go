@@ -0,0 +1,39 @@
+package main
+
+import (
+	"api-app/main/src/cache"
+	"api-app/main/src/database"
+	"api-app/main/src/services"
+	"api-app/main/src/utils"
+	"fmt"
+	"os"
+)
+
+// The reparse-domains command parses the names of all existing domains again with the Public Suffix List,
+// and stores their canonical name, subdomain, second-level domain and top-level domain.
+func main() {
+	// Open database connection.
+	if err := database.OpenDBConnection(); err != nil {
+		panic(fmt.Sprintf("Could not connect to the database: %v", err))
+	}
+
+	// Open Valkey connection, so the cache of renamed domains can be cleared.
+	if err := cache.OpenValkeyConnection(); err != nil {
+		panic(fmt.Sprintf("Could not connect to the cache: %v", err))
+	}
+	defer cache.CloseValkeyConnection()
+
+	updated, failures, err := services.ReparseDomains(utils.SystemActor)
+	for _, failure := range failures {
+		fmt.Printf("Could not re-parse domain %d (%s): %v\n", failure.ID, failure.Name, failure.Error)
+	}
+	fmt.Printf("Re-parsed domains, %d updated and %d failed.\n", updated, len(failures))
+
+	if err != nil {
+		fmt.Printf("Could not re-parse domains: %v\n", err)
+		os.Exit(1)
+	}
+	if len(failures) > 0 {
+		os.Exit(1)
+	}
+}
@@ -6,10 +6,13 @@ import (
 	"api-app/main/src/database"
 	"api-app/main/src/middleware"
 	"api-app/main/src/routes"
+	"api-app/main/src/services"
+	"context"
 	"fmt"
 	routeutil "github.com/ArnoldPMolenaar/api-utils/routes"
 	"github.com/ArnoldPMolenaar/api-utils/utils"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
 	"os"
 )
 
@@ -28,11 +31,34 @@ func main() {
 		panic(fmt.Sprintf("Could not connect to the database: %v", err))
 	}
 
-	// Open Valkey connection.
+	// Open Valkey connection. Without it the settings are served from the database,
+	// until the connection is opened in the background.
 	if err := cache.OpenValkeyConnection(); err != nil {
-		panic(fmt.Sprintf("Could not connect to the cache: %v", err))
+		log.Errorf("Could not connect to the cache: %v", err)
 	}
-	defer cache.Valkey.Close()
+	defer cache.CloseValkeyConnection()
+
+	// Reconnect to Valkey, and close the circuit breaker when it is available again.
+	go cache.Monitor(context.Background())
+
+	// Remove the keys that are deleted on other instances from the local cache.
+	go cache.ListenInvalidations()
+
+	// Warm the cache, so the first requests after a deploy or a flush do not reach the database.
+	if os.Getenv("CACHE_WARM_ON_START") != "false" && cache.Available() {
+		go func() {
+			if _, _, err := services.WarmCache(); err != nil {
+				log.Errorf("Could not warm the cache: %v", err)
+			}
+		}()
+	}
+
+	// Relay the outbox events to the event stream.
+	go services.StartOutboxRelay(context.Background())
+	// Send the webhook deliveries.
+	go services.StartWebhookDispatcher(context.Background())
+	// Purge the apps and domains that are deleted longer than the retention period.
+	go services.StartTrashPurger(context.Background())
 
 	// Register a private routes_util for app.
 	routes.PrivateRoutes(app)
@@ -0,0 +1,193 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/valkey-io/valkey-go"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrUnavailable is returned when Valkey is not connected, or when the circuit breaker is open.
+var ErrUnavailable = errors.New("cache is unavailable")
+
+// circuitBreaker stops the calls to Valkey after a number of consecutive failures,
+// so requests fall back to the database without waiting for Valkey to time out.
+type circuitBreaker struct {
+	sync.Mutex
+	failures  int
+	threshold int
+	open      bool
+}
+
+// breaker is the circuit breaker around all Valkey calls.
+var breaker = &circuitBreaker{threshold: breakerThreshold()}
+
+// pending holds the keys and tags that could not be deleted while Valkey was unavailable.
+// They are deleted when Valkey is available again, so no stale values are served after an outage.
+var pending = struct {
+	sync.Mutex
+	keys []string
+	tags []string
+}{}
+
+// Available returns whether Valkey is connected and the circuit breaker is closed.
+func Available() bool {
+	breaker.Lock()
+	defer breaker.Unlock()
+
+	return !breaker.open && getClient() != nil
+}
+
+// Do runs the command that is built by the build function, when Valkey is available.
+// The result is reported to the circuit breaker. A nil reply is not an error.
+func Do(ctx context.Context, build func(b valkey.Builder) valkey.Completed) (valkey.ValkeyResult, error) {
+	client := getClient()
+	if client == nil || !Available() {
+		return valkey.ValkeyResult{}, ErrUnavailable
+	}
+
+	result := client.Do(ctx, build(client.B()))
+	err := result.Error()
+	if valkey.IsValkeyNil(err) {
+		err = nil
+	}
+	report(err)
+
+	return result, err
+}
+
+// DoMulti runs the commands that are built by the build function in a single round trip, when Valkey is available.
+// The first error of the results is returned and reported to the circuit breaker. A nil reply is not an error.
+func DoMulti(ctx context.Context, build func(b valkey.Builder) []valkey.Completed) ([]valkey.ValkeyResult, error) {
+	client := getClient()
+	if client == nil || !Available() {
+		return nil, ErrUnavailable
+	}
+
+	results := client.DoMulti(ctx, build(client.B())...)
+	var err error
+	for _, result := range results {
+		if resultErr := result.Error(); resultErr != nil && !valkey.IsValkeyNil(resultErr) {
+			err = resultErr
+			break
+		}
+	}
+	report(err)
+
+	return results, err
+}
+
+// Receive subscribes with the command that is built by the build function, when Valkey is available,
+// and blocks until the subscription is lost.
+func Receive(ctx context.Context, build func(b valkey.Builder) valkey.Completed, fn func(message valkey.PubSubMessage)) error {
+	client := getClient()
+	if client == nil || !Available() {
+		return ErrUnavailable
+	}
+
+	err := client.Receive(ctx, build(client.B()), fn)
+	report(err)
+
+	return err
+}
+
+// Monitor connects to Valkey when it was not reachable at startup, and closes the circuit breaker
+// when Valkey responds again. It checks every CACHE_BREAKER_COOLDOWN until the context is done.
+func Monitor(ctx context.Context) {
+	ticker := time.NewTicker(breakerCooldown())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if Available() {
+				continue
+			}
+
+			if getClient() == nil {
+				if err := OpenValkeyConnection(); err != nil {
+					continue
+				}
+			} else if err := getClient().Do(ctx, getClient().B().Ping().Build()).Error(); err != nil {
+				continue
+			}
+
+			breaker.Lock()
+			breaker.open = false
+			breaker.failures = 0
+			breaker.Unlock()
+			log.Info("Cache is available again.")
+
+			replayPending()
+		}
+	}
+}
+
+// report counts the consecutive failures of the Valkey calls, and opens the circuit breaker at the threshold.
+// An error reply of Valkey itself means that it is reachable, so it is not counted.
+func report(err error) {
+	var valkeyErr *valkey.ValkeyError
+	failed := err != nil && !errors.As(err, &valkeyErr) && !errors.Is(err, context.Canceled)
+
+	breaker.Lock()
+	defer breaker.Unlock()
+
+	if !failed {
+		breaker.failures = 0
+		return
+	}
+
+	breaker.failures++
+	if !breaker.open && breaker.failures >= breaker.threshold {
+		breaker.open = true
+		log.Errorf("Cache is unavailable, falling back to the database: %v", err)
+	}
+}
+
+// addPending stores the keys and tags that could not be deleted.
+func addPending(keys, tags []string) {
+	pending.Lock()
+	defer pending.Unlock()
+
+	pending.keys = append(pending.keys, keys...)
+	pending.tags = append(pending.tags, tags...)
+}
+
+// replayPending deletes the keys and tags that could not be deleted while Valkey was unavailable.
+func replayPending() {
+	pending.Lock()
+	keys, tags := pending.keys, pending.tags
+	pending.keys, pending.tags = nil, nil
+	pending.Unlock()
+
+	if err := Invalidate(tags...); err != nil {
+		log.Errorf("Could not invalidate the cache tags of the outage: %v", err)
+	}
+	if err := Delete(keys...); err != nil {
+		log.Errorf("Could not delete the cache keys of the outage: %v", err)
+	}
+}
+
+// breakerThreshold returns the number of consecutive failures after which the circuit breaker opens.
+func breakerThreshold() int {
+	if threshold, err := strconv.Atoi(os.Getenv("CACHE_BREAKER_THRESHOLD")); err == nil && threshold > 0 {
+		return threshold
+	}
+
+	return 5
+}
+
+// breakerCooldown returns the interval at which an unavailable Valkey is checked again.
+func breakerCooldown() time.Duration {
+	if cooldown, err := time.ParseDuration(os.Getenv("CACHE_BREAKER_COOLDOWN")); err == nil && cooldown > 0 {
+		return cooldown
+	}
+
+	return 10 * time.Second
+}
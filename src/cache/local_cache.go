@@ -0,0 +1,139 @@
+package cache
+
+import (
+	"container/list"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// localEntry struct holds a value of the local cache.
+type localEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// localCache is a bounded in-process LRU cache in front of Valkey.
+type localCache struct {
+	sync.Mutex
+	size    int
+	ttl     time.Duration
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+// Local is the in-process cache of this instance.
+// Its entries are removed when a key is deleted on any instance, and expire after CACHE_LOCAL_TTL
+// in case an invalidation was missed.
+var Local = newLocalCache(localCacheSize(), localCacheTTL())
+
+// newLocalCache returns an empty local cache that holds at most size entries.
+func newLocalCache(size int, ttl time.Duration) *localCache {
+	return &localCache{
+		size:    size,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// Get gets the value of the key, and marks it as recently used.
+func (lc *localCache) Get(key string) ([]byte, bool) {
+	lc.Lock()
+	defer lc.Unlock()
+
+	element, exists := lc.entries[key]
+	if !exists {
+		return nil, false
+	}
+
+	entry := element.Value.(*localEntry)
+	if time.Now().After(entry.expiresAt) {
+		lc.order.Remove(element)
+		delete(lc.entries, key)
+		return nil, false
+	}
+	lc.order.MoveToFront(element)
+
+	return entry.value, true
+}
+
+// Set sets the value of the key, and removes the least recently used entry when the cache is full.
+// The entry expires after the ttl when it is shorter than CACHE_LOCAL_TTL.
+func (lc *localCache) Set(key string, value []byte, ttl time.Duration) {
+	if lc.size <= 0 {
+		return
+	}
+	if ttl <= 0 || ttl > lc.ttl {
+		ttl = lc.ttl
+	}
+
+	lc.Lock()
+	defer lc.Unlock()
+
+	if element, exists := lc.entries[key]; exists {
+		entry := element.Value.(*localEntry)
+		entry.value = value
+		entry.expiresAt = time.Now().Add(ttl)
+		lc.order.MoveToFront(element)
+		return
+	}
+
+	lc.entries[key] = lc.order.PushFront(&localEntry{key: key, value: value, expiresAt: time.Now().Add(ttl)})
+	if lc.order.Len() > lc.size {
+		oldest := lc.order.Back()
+		lc.order.Remove(oldest)
+		delete(lc.entries, oldest.Value.(*localEntry).key)
+	}
+}
+
+// Delete removes the keys.
+func (lc *localCache) Delete(keys ...string) {
+	lc.Lock()
+	defer lc.Unlock()
+
+	for _, key := range keys {
+		if element, exists := lc.entries[key]; exists {
+			lc.order.Remove(element)
+			delete(lc.entries, key)
+		}
+	}
+}
+
+// Clear removes all keys.
+func (lc *localCache) Clear() {
+	lc.Lock()
+	defer lc.Unlock()
+
+	lc.order.Init()
+	lc.entries = make(map[string]*list.Element)
+}
+
+// Len returns the number of entries.
+func (lc *localCache) Len() int {
+	lc.Lock()
+	defer lc.Unlock()
+
+	return lc.order.Len()
+}
+
+// localCacheSize returns the maximum number of entries in the local cache.
+// A size of zero disables the local cache.
+func localCacheSize() int {
+	if size, err := strconv.Atoi(os.Getenv("CACHE_LOCAL_SIZE")); err == nil && size >= 0 {
+		return size
+	}
+
+	return 10000
+}
+
+// localCacheTTL returns the duration an entry is kept in the local cache.
+func localCacheTTL() time.Duration {
+	if ttl, err := time.ParseDuration(os.Getenv("CACHE_LOCAL_TTL")); err == nil && ttl > 0 {
+		return ttl
+	}
+
+	return time.Minute
+}
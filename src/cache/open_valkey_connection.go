@@ -1,22 +1,52 @@
 package cache
 
 import (
+	"fmt"
 	"github.com/ArnoldPMolenaar/api-utils/cache"
 	"github.com/valkey-io/valkey-go"
+	"sync"
 )
 
-var Valkey valkey.Client
+// valkeyClient is the Valkey client, which is nil until a connection has been opened.
+var (
+	valkeyClient valkey.Client
+	clientLock   sync.RWMutex
+)
 
 // OpenValkeyConnection Start a new valkey connection.
-func OpenValkeyConnection() error {
+func OpenValkeyConnection() (err error) {
+	// The connection helper panics when Valkey can not be reached.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
 	// Open connection to valkey.
 	client, err := cache.ValkeyConnection()
 	if err != nil {
 		return err
 	}
 
-	// Set the global Valkey variable.
-	Valkey = client
+	// Set the global Valkey client.
+	clientLock.Lock()
+	valkeyClient = client
+	clientLock.Unlock()
 
 	return nil
 }
+
+// CloseValkeyConnection closes the Valkey connection, when it was opened.
+func CloseValkeyConnection() {
+	if client := getClient(); client != nil {
+		client.Close()
+	}
+}
+
+// getClient returns the Valkey client, or nil when no connection has been opened.
+func getClient() valkey.Client {
+	clientLock.RLock()
+	defer clientLock.RUnlock()
+
+	return valkeyClient
+}
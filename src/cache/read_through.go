@@ -0,0 +1,199 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/valkey-io/valkey-go"
+	"golang.org/x/sync/singleflight"
+	"os"
+	"time"
+)
+
+// InvalidationChannel is the Valkey channel on which deleted keys are published,
+// so every instance can remove them from its local cache.
+const InvalidationChannel = "cache:invalidate"
+
+// loads collapses the concurrent loads of the same key.
+var loads singleflight.Group
+
+// entry struct is the format in which a loaded value is cached.
+// It tells a value that does not exist apart from an empty value.
+type entry[T any] struct {
+	Found bool `json:"found"`
+	Value T    `json:"value"`
+}
+
+// Load gets the value of the key from the local cache or Valkey, and loads and stores it with the load function on a miss.
+// The load function reports whether the value exists, and adds the tags under which the key is registered.
+// A value that does not exist is cached for the CACHE_NEGATIVE_TTL, so repeated lookups of it do not reach the database.
+// Concurrent misses of the same key on this instance share a single load, which is not cached
+// when the key or one of its tags is invalidated while it runs.
+// When Valkey is unavailable, the value is loaded from the database and kept in the local cache only.
+func Load[T any](key string, load func(tags *Tags) (T, bool, error)) (T, bool, error) {
+	var cached entry[T]
+
+	if data, found, err := Get(key); err == nil && found {
+		err := json.Unmarshal(data, &cached)
+		return cached.Value, cached.Found, err
+	}
+
+	data, err, _ := loads.Do(key, func() (interface{}, error) {
+		var tags Tags
+		generation := startLoad()
+		defer finishLoad()
+
+		value, found, err := load(&tags)
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := json.Marshal(entry[T]{Found: found, Value: value})
+		if err != nil {
+			return nil, err
+		}
+
+		expiration := NegativeExpiration()
+		if found {
+			if expiration, err = Expiration(); err != nil {
+				return nil, err
+			}
+		}
+		// A key that can not be tagged is not cached in Valkey, because it could not be invalidated.
+		tagErr := Tag(key, expiration, tags...)
+		// A value that has been invalidated while it was loaded is returned, but not cached, because it may be stale.
+		// The key is tagged first, so an invalidation that starts after this check finds it.
+		if deletedSince(generation, key, tags) {
+			return data, nil
+		}
+		if tagErr != nil || Set(key, data, expiration) != nil {
+			Local.Set(key, data, expiration)
+		}
+
+		return data, nil
+	})
+	if err != nil {
+		return cached.Value, false, err
+	}
+
+	// Every caller decodes its own copy, so the shared result can not be modified.
+	err = json.Unmarshal(data.([]byte), &cached)
+
+	return cached.Value, cached.Found, err
+}
+
+// Get gets the value of the key from the local cache, or from Valkey when it is not cached locally.
+// A value from Valkey is kept in the local cache no longer than it is kept in Valkey.
+func Get(key string) ([]byte, bool, error) {
+	if data, found := Local.Get(key); found {
+		localHits.Add(1)
+		return data, true, nil
+	}
+
+	results, err := DoMulti(context.Background(), func(b valkey.Builder) []valkey.Completed {
+		return []valkey.Completed{b.Get().Key(key).Build(), b.Pttl().Key(key).Build()}
+	})
+	if err != nil {
+		misses.Add(1)
+		return nil, false, err
+	}
+	data, err := results[0].AsBytes()
+	if valkey.IsValkeyNil(err) {
+		misses.Add(1)
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+	valkeyHits.Add(1)
+	if ttl, err := results[1].AsInt64(); err == nil {
+		Local.Set(key, data, time.Duration(ttl)*time.Millisecond)
+	}
+
+	return data, true, nil
+}
+
+// Set sets the value of the key in Valkey and in the local cache, which expires after the expiration.
+func Set(key string, data []byte, expiration time.Duration) error {
+	if _, err := Do(context.Background(), func(b valkey.Builder) valkey.Completed {
+		return b.Set().Key(key).Value(valkey.BinaryString(data)).Ex(expiration).Build()
+	}); err != nil {
+		return err
+	}
+	Local.Set(key, data, expiration)
+
+	return nil
+}
+
+// Expiration returns the VALKEY_EXPIRATION, the duration a value is cached.
+func Expiration() (time.Duration, error) {
+	return time.ParseDuration(os.Getenv("VALKEY_EXPIRATION"))
+}
+
+// NegativeExpiration returns the CACHE_NEGATIVE_TTL, the duration a value that does not exist is cached.
+func NegativeExpiration() time.Duration {
+	if ttl, err := time.ParseDuration(os.Getenv("CACHE_NEGATIVE_TTL")); err == nil && ttl > 0 {
+		return ttl
+	}
+
+	return 30 * time.Second
+}
+
+// Delete deletes the keys from Valkey and from the local cache of every instance.
+// Keys that can not be deleted from Valkey are deleted when it is available again.
+func Delete(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	bumpGeneration(keys...)
+	Local.Delete(keys...)
+	for _, key := range keys {
+		loads.Forget(key)
+	}
+
+	message, err := json.Marshal(keys)
+	if err != nil {
+		return err
+	}
+
+	if _, err := DoMulti(context.Background(), func(b valkey.Builder) []valkey.Completed {
+		return []valkey.Completed{
+			b.Del().Key(keys...).Build(),
+			b.Publish().Channel(InvalidationChannel).Message(valkey.BinaryString(message)).Build(),
+		}
+	}); err != nil {
+		addPending(keys, nil)
+		return err
+	}
+
+	return nil
+}
+
+// ListenInvalidations removes the keys that are deleted on any instance from the local cache.
+// When the subscription is lost, the local cache is cleared once it is restored, because invalidations may have been missed.
+// While Valkey is unavailable, the local cache keeps serving its entries until they expire.
+func ListenInvalidations() {
+	for {
+		if !Available() {
+			time.Sleep(time.Second)
+			continue
+		}
+
+		Local.Clear()
+		err := Receive(context.Background(), func(b valkey.Builder) valkey.Completed {
+			return b.Subscribe().Channel(InvalidationChannel).Build()
+		}, func(message valkey.PubSubMessage) {
+			var keys []string
+			if err := json.Unmarshal([]byte(message.Message), &keys); err != nil {
+				return
+			}
+			bumpGeneration(keys...)
+			Local.Delete(keys...)
+		})
+		if err != nil {
+			log.Errorf("Cache invalidation subscription lost: %v", err)
+		}
+
+		time.Sleep(time.Second)
+	}
+}
@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"context"
+	"github.com/valkey-io/valkey-go"
+	"sync/atomic"
+)
+
+// scanCount is the number of keys that is requested per SCAN iteration.
+const scanCount = 1000
+
+// Stats struct holds the counters of the cache lookups on this instance since it started.
+type Stats struct {
+	LocalHits    uint64
+	ValkeyHits   uint64
+	Misses       uint64
+	LocalEntries int
+}
+
+// Counters of the cache lookups on this instance.
+var (
+	localHits  atomic.Uint64
+	valkeyHits atomic.Uint64
+	misses     atomic.Uint64
+)
+
+// GetStats returns the counters of the cache lookups on this instance.
+func GetStats() Stats {
+	return Stats{
+		LocalHits:    localHits.Load(),
+		ValkeyHits:   valkeyHits.Load(),
+		Misses:       misses.Load(),
+		LocalEntries: Local.Len(),
+	}
+}
+
+// ScanKeys calls the function with every key in Valkey, in batches.
+func ScanKeys(fn func(keys []string) error) error {
+	var cursor uint64
+	for {
+		result, err := Do(context.Background(), func(b valkey.Builder) valkey.Completed {
+			return b.Scan().Cursor(cursor).Count(scanCount).Build()
+		})
+		if err != nil {
+			return err
+		}
+		entry, err := result.AsScanEntry()
+		if err != nil {
+			return err
+		}
+		if len(entry.Elements) > 0 {
+			if err := fn(entry.Elements); err != nil {
+				return err
+			}
+		}
+
+		cursor = entry.Cursor
+		if cursor == 0 {
+			return nil
+		}
+	}
+}
+
+// CountKeys returns the number of keys in Valkey per group, which is given by the classify function.
+// Keys for which the function returns an empty group are not counted.
+func CountKeys(classify func(key string) string) (map[string]int64, error) {
+	counts := make(map[string]int64)
+	err := ScanKeys(func(keys []string) error {
+		for _, key := range keys {
+			if group := classify(key); group != "" {
+				counts[group]++
+			}
+		}
+
+		return nil
+	})
+
+	return counts, err
+}
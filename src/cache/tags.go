@@ -0,0 +1,149 @@
+package cache
+
+import (
+	"context"
+	"github.com/valkey-io/valkey-go"
+	"sync"
+	"time"
+)
+
+// generations holds the generation of every key and tag set that has been deleted, as seen by this instance.
+// A load captures the generation before it reads the database, and does not store its value when one of its keys
+// has been deleted since, because the value may have been read before the change that deleted it.
+var generations = struct {
+	sync.Mutex
+	current uint64
+	loading int
+	keys    map[string]uint64
+}{keys: make(map[string]uint64)}
+
+// Tags holds the tags of a value while it is loaded.
+// Every key is registered under its tags, so a mutation can delete every key that depends on it
+// by invalidating a tag, without knowing the keys.
+type Tags []string
+
+// Add adds the tags.
+func (t *Tags) Add(tags ...string) {
+	*t = append(*t, tags...)
+}
+
+// TagKey returns the key of the Valkey set that holds the keys of a tag.
+func TagKey(tag string) string {
+	return "tags:" + tag
+}
+
+// Tag registers the key under the tags.
+// A tag set expires when the last key that was added to it has expired.
+func Tag(key string, expiration time.Duration, tags ...string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	seconds := int64(expiration.Seconds()) + 1
+	_, err := DoMulti(context.Background(), func(b valkey.Builder) []valkey.Completed {
+		commands := make([]valkey.Completed, 0, len(tags)*3)
+		for _, tag := range tags {
+			commands = append(commands,
+				b.Sadd().Key(TagKey(tag)).Member(key).Build(),
+				b.Expire().Key(TagKey(tag)).Seconds(seconds).Nx().Build(),
+				b.Expire().Key(TagKey(tag)).Seconds(seconds).Gt().Build(),
+			)
+		}
+
+		return commands
+	})
+
+	return err
+}
+
+// Invalidate deletes every key that is registered under one of the tags, and the tag sets themselves.
+// Tags that can not be invalidated are invalidated when Valkey is available again.
+func Invalidate(tags ...string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	keys := make([]string, len(tags))
+	for i, tag := range tags {
+		keys[i] = TagKey(tag)
+	}
+	// The generation is increased before the keys are looked up, so a load that has not tagged its key yet is skipped too.
+	bumpGeneration(keys...)
+
+	results, err := DoMulti(context.Background(), func(b valkey.Builder) []valkey.Completed {
+		commands := make([]valkey.Completed, len(keys))
+		for i := range keys {
+			commands[i] = b.Smembers().Key(keys[i]).Build()
+		}
+
+		return commands
+	})
+	if err != nil {
+		addPending(nil, tags)
+		return err
+	}
+
+	for _, result := range results {
+		members, err := result.AsStrSlice()
+		if err != nil {
+			addPending(nil, tags)
+			return err
+		}
+		keys = append(keys, members...)
+	}
+
+	return Delete(keys...)
+}
+
+// startLoad registers a load and returns the current generation.
+func startLoad() uint64 {
+	generations.Lock()
+	defer generations.Unlock()
+
+	generations.loading++
+
+	return generations.current
+}
+
+// finishLoad unregisters a load. The generations are only needed by the running loads,
+// so they are cleared when no load is running.
+func finishLoad() {
+	generations.Lock()
+	defer generations.Unlock()
+
+	generations.loading--
+	if generations.loading == 0 {
+		clear(generations.keys)
+	}
+}
+
+// deletedSince reports whether the key or one of the tags has been deleted after the generation.
+func deletedSince(generation uint64, key string, tags Tags) bool {
+	generations.Lock()
+	defer generations.Unlock()
+
+	if generations.keys[key] > generation {
+		return true
+	}
+	for _, tag := range tags {
+		if generations.keys[TagKey(tag)] > generation {
+			return true
+		}
+	}
+
+	return false
+}
+
+// bumpGeneration gives the keys the next generation, which is only kept while a load is running.
+func bumpGeneration(keys ...string) {
+	generations.Lock()
+	defer generations.Unlock()
+
+	generations.current++
+	if generations.loading == 0 {
+		return
+	}
+	for _, key := range keys {
+		generations.keys[key] = generations.current
+	}
+}
@@ -4,15 +4,12 @@ import (
 	"api-app/main/src/database"
 	"api-app/main/src/dto/requests"
 	"api-app/main/src/dto/responses"
-	"api-app/main/src/enums"
 	"api-app/main/src/errors"
 	"api-app/main/src/models"
 	"api-app/main/src/services"
-	"encoding/json"
+	apputils "api-app/main/src/utils"
 	"fmt"
-	"strconv"
 	"strings"
-	"time"
 
 	errorutil "github.com/ArnoldPMolenaar/api-utils/errors"
 	"github.com/ArnoldPMolenaar/api-utils/pagination"
@@ -37,6 +34,12 @@ func AreAppsAvailable(c *fiber.Ctx) error {
 	return c.JSON(responses.Exists{Exists: exist})
 }
 
+// appIncludes are the relations of an app that can be included in a read.
+var appIncludes = []string{"settings", "domains", "domains.settings"}
+
+// appFields are the fields of an app that can be selected in a read.
+var appFields = []string{"id", "name", "version", "createdAt", "updatedAt"}
+
 // GetApps function fetches all apps from the database.
 func GetApps(c *fiber.Ctx) error {
 	apps := make([]models.App, 0)
@@ -60,7 +63,13 @@ func GetApps(c *fiber.Ctx) error {
 	}
 	offset := pagination.Offset(page, limit)
 
-	db := database.Pg.Scopes(queryFunc, sortFunc).Limit(limit).Offset(offset).Find(&apps)
+	// Get the included relations and fields from the query string.
+	expansion, err := apputils.ParseExpansion(c, nil, append(appIncludes, "settingsCount"), appFields)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, err.Error())
+	}
+
+	db := database.Pg.Scopes(queryFunc, sortFunc, services.PreloadAppIncludes(expansion.Includes)).Limit(limit).Offset(offset).Find(&apps)
 	if db.Error != nil {
 		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, db.Error.Error())
 	}
@@ -69,11 +78,25 @@ func GetApps(c *fiber.Ctx) error {
 	database.Pg.Scopes(queryFunc).Model(&models.App{}).Count(&total)
 	pageCount := pagination.Count(int(total), limit)
 
-	paginatedApps := make([]responses.PaginatedApp, len(apps))
+	// Count the settings of the apps on the page.
+	var settingsCounts map[uint]int64
+	if expansion.Include("settingsCount") && len(apps) > 0 {
+		appIDs := make([]uint, len(apps))
+		for i := range apps {
+			appIDs[i] = apps[i].ID
+		}
+		if settingsCounts, err = services.CountAppSettings(appIDs); err != nil {
+			return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+		}
+	}
+
+	paginatedApps := make([]interface{}, len(apps))
 	for i := range apps {
 		paginatedApp := responses.PaginatedApp{}
-		paginatedApp.SetPaginatedApp(&apps[i])
-		paginatedApps[i] = paginatedApp
+		paginatedApp.SetExpandedPaginatedApp(&apps[i], expansion.Includes, settingsCounts)
+		if paginatedApps[i], err = expansion.SelectFields(paginatedApp); err != nil {
+			return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.InternalServerError, err.Error())
+		}
 	}
 
 	paginationModel := pagination.CreatePaginationModel(limit, page, pageCount, int(total), paginatedApps)
@@ -93,8 +116,14 @@ func GetApp(c *fiber.Ctx) error {
 		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, "Invalid App ID.")
 	}
 
+	// Get the included relations and fields from the query string.
+	expansion, err := apputils.ParseExpansion(c, []string{"settings", "domains"}, appIncludes, appFields)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, err.Error())
+	}
+
 	// Get the app.
-	app, err := services.GetAppById(appID)
+	app, err := services.GetExpandedAppById(appID, expansion.Includes)
 	if err != nil {
 		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
 	} else if app.ID == 0 {
@@ -103,9 +132,14 @@ func GetApp(c *fiber.Ctx) error {
 
 	// Return the app.
 	response := responses.App{}
-	response.SetApp(app)
+	response.SetExpandedApp(app, expansion.Includes)
+	result, err := expansion.SelectFields(response)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.InternalServerError, err.Error())
+	}
+	c.Set(fiber.HeaderETag, apputils.VersionETag(app.Version))
 
-	return c.JSON(response)
+	return c.JSON(result)
 }
 
 // CreateApp func to create a app.
@@ -121,9 +155,25 @@ func CreateApp(c *fiber.Ctx) error {
 	if err := validate.Struct(request); err != nil {
 		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.Validator, utils.ValidatorErrors(err))
 	}
-	if validationErrors := validateAppSettings(&request.Settings); validationErrors != "" {
+	if validationErrors := validateAppSettings(&request.Settings, nil); validationErrors != "" {
 		return errorutil.Response(c, fiber.StatusBadRequest, errors.AppSettings, validationErrors)
 	}
+	environments := make([]string, len(request.Settings))
+	for i := range request.Settings {
+		environments[i] = request.Settings[i].Environment
+	}
+	if validationErrors, err := validateSettingEnvironments(0, environments); err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if validationErrors != "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errors.AppSettings, validationErrors)
+	}
+	names := make([]*string, len(request.Domains))
+	for i := range request.Domains {
+		names[i] = &request.Domains[i].Name
+	}
+	if validationErrors := normalizeDomainNames(names...); validationErrors != "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errors.DomainName, validationErrors)
+	}
 
 	// Check if app exists.
 	if available, err := services.IsAppAvailable(request.Name); err != nil {
@@ -133,7 +183,7 @@ func CreateApp(c *fiber.Ctx) error {
 	}
 
 	// Create the app.
-	app, err := services.CreateApp(&request)
+	app, err := services.CreateApp(&request, apputils.GetActor(c))
 	if err != nil {
 		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
 	}
@@ -168,9 +218,32 @@ func UpdateApp(c *fiber.Ctx) error {
 	if err := validate.Struct(request); err != nil {
 		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.Validator, utils.ValidatorErrors(err))
 	}
-	if validationErrors := validateAppSettings(&request.Settings); validationErrors != "" {
+	definitions, err := services.GetSettingDefinitionsByAppID(appID)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+	if validationErrors := validateAppSettings(&request.Settings, definitions); validationErrors != "" {
 		return errorutil.Response(c, fiber.StatusBadRequest, errors.AppSettings, validationErrors)
 	}
+	if validationErrors := validateRequiredAppSettings(&request.Settings, definitions); validationErrors != "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errors.AppSettings, validationErrors)
+	}
+	environments := make([]string, len(request.Settings))
+	for i := range request.Settings {
+		environments[i] = request.Settings[i].Environment
+	}
+	if validationErrors, err := validateSettingEnvironments(appID, environments); err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if validationErrors != "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errors.AppSettings, validationErrors)
+	}
+	names := make([]*string, len(request.Domains))
+	for i := range request.Domains {
+		names[i] = &request.Domains[i].Name
+	}
+	if validationErrors := normalizeDomainNames(names...); validationErrors != "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errors.DomainName, validationErrors)
+	}
 
 	// Check if app exists.
 	app, err := services.GetAppById(appID, true)
@@ -189,36 +262,31 @@ func UpdateApp(c *fiber.Ctx) error {
 		}
 	}
 
-	// Check if the app data has been modified since it was last fetched.
-	if request.UpdatedAt.Unix() < app.UpdatedAt.Unix() {
-		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.OutOfSync, "Data is out of sync.")
-	}
-	for i := range app.Domains {
-		if app.Domains[i].DeletedAt.Valid {
-			continue
-		}
-
-		var newDomain *requests.UpdateAppDomain = nil
-		for j := range request.Domains {
-			if app.Domains[i].ID == request.Domains[j].ID {
-				newDomain = &request.Domains[j]
-				break
-			}
-		}
-		if newDomain != nil && newDomain.UpdatedAt.Unix() < app.Domains[i].UpdatedAt.Unix() {
-			return errorutil.Response(c, fiber.StatusBadRequest, errorutil.OutOfSync, "Data is out of sync.")
+	// Get the version the change is based on, from the If-Match header or else from the request.
+	version, ifMatch, err := apputils.GetIfMatchVersion(c)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, err.Error())
+	} else if !ifMatch {
+		if request.Version == 0 {
+			return errorutil.Response(c, fiber.StatusPreconditionRequired, errorutil.OutOfSync, "The If-Match header or the version is required.")
 		}
+		version = request.Version
+	} else if version == 0 {
+		version = app.Version
 	}
 
 	// Update the app.
-	updatedApp, err := services.UpdateApp(app, &request)
-	if err != nil {
+	updatedApp, err := services.UpdateApp(app, version, &request, apputils.GetActor(c))
+	if err == services.ErrVersionConflict {
+		return sendAppPreconditionFailed(c, appID)
+	} else if err != nil {
 		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
 	}
 
 	// Return the app.
 	response := responses.App{}
 	response.SetApp(updatedApp)
+	c.Set(fiber.HeaderETag, apputils.VersionETag(updatedApp.Version))
 
 	return c.JSON(response)
 }
@@ -235,16 +303,36 @@ func DeleteApp(c *fiber.Ctx) error {
 		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, err.Error())
 	}
 
-	// Find the app.
-	app, err := services.GetAppById(appID)
+	// Get the permanent parameter from the query string.
+	permanent := c.QueryBool("permanent")
+
+	// Find the app, a deleted app can only be deleted permanently.
+	app, err := services.GetAppById(appID, permanent)
 	if err != nil {
 		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
 	} else if app.ID == 0 {
 		return errorutil.Response(c, fiber.StatusNotFound, errors.AppExists, "App does not exist.")
 	}
 
+	// Delete the app permanently.
+	if permanent {
+		if err := services.PurgeApp(app, apputils.GetActor(c)); err != nil {
+			return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+		}
+
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+
+	// Get the version the delete is based on from the If-Match header, without it the app is deleted regardless.
+	version, _, err := apputils.GetIfMatchVersion(c)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, err.Error())
+	}
+
 	// Delete the app.
-	if err := services.DeleteApp(app); err != nil {
+	if err := services.DeleteApp(app, version, apputils.GetActor(c)); err == services.ErrVersionConflict {
+		return sendAppPreconditionFailed(c, appID)
+	} else if err != nil {
 		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
 	}
 
@@ -270,53 +358,94 @@ func RestoreApp(c *fiber.Ctx) error {
 		return errorutil.Response(c, fiber.StatusNotFound, errors.AppExists, "App does not exist.")
 	}
 
+	// Check if the name or a wildcard domain of the app has been taken since it was deleted.
+	app, err := services.GetAppById(appID, true)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+	if available, err := services.IsAppAvailable(app.Name); err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if available {
+		return errorutil.Response(c, fiber.StatusConflict, errors.AppAvailable, "App name is taken by another app.")
+	}
+	if app.DeletionBatch.Valid {
+		if taken, err := services.IsDeletionBatchTaken(app.ID, app.DeletionBatch.String); err != nil {
+			return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+		} else if taken {
+			return errorutil.Response(c, fiber.StatusConflict, errors.DomainAvailable, "A wildcard domain of the app is taken by another app.")
+		}
+	}
+
 	// Restore the app.
-	if err := services.RestoreApp(appID); err != nil {
+	if err := services.RestoreApp(appID, apputils.GetActor(c)); err != nil {
 		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
 	}
 
 	return c.SendStatus(fiber.StatusNoContent)
 }
 
-// validateAppSettings validates an array of DomainSetting structs.
-// It checks if the Value field of each DomainSetting is valid based on its ValueType.
+// sendAppPreconditionFailed responds with 412 Precondition Failed and the current representation of the app,
+// after a change that was based on an outdated version.
+func sendAppPreconditionFailed(c *fiber.Ctx, appID uint) error {
+	app, err := services.GetAppById(appID)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if app.ID == 0 {
+		return errorutil.Response(c, fiber.StatusNotFound, errorutil.NotFound, "App not found.")
+	}
+
+	response := responses.App{}
+	response.SetApp(app)
+	c.Set(fiber.HeaderETag, apputils.VersionETag(app.Version))
+
+	return c.Status(fiber.StatusPreconditionFailed).JSON(response)
+}
+
+// validateAppSettings validates an array of AppSetting structs.
+// It checks if the Value field of each AppSetting is valid based on its ValueType,
+// and if it satisfies the SettingDefinition of the app.
 // If any validation errors occur, it returns a comma-separated string of error messages.
 // If the string is empty, it means all validations passed.
-func validateAppSettings(settings *[]requests.AppSetting) string {
+func validateAppSettings(settings *[]requests.AppSetting, definitions *[]models.SettingDefinition) string {
 	var validateErrors []string
 
 	for i := range *settings {
 		setting := &(*settings)[i]
-		switch enums.ValueType(setting.ValueType) {
-		case enums.Int:
-			if _, err := strconv.Atoi(setting.Value); err != nil {
-				validateErrors = append(validateErrors, fmt.Sprintf("Invalid int value for setting %s", setting.Name))
-			}
-		case enums.Float:
-			if _, err := strconv.ParseFloat(setting.Value, 64); err != nil {
-				validateErrors = append(validateErrors, fmt.Sprintf("Invalid float value for setting %s", setting.Name))
-			}
-		case enums.String:
-			// No validation needed for string type.
-		case enums.Bool:
-			if _, err := strconv.ParseBool(setting.Value); err != nil {
-				validateErrors = append(validateErrors, fmt.Sprintf("Invalid bool value for setting %s", setting.Name))
-			}
-		case enums.Date:
-			if _, err := time.Parse(time.DateOnly, setting.Value); err != nil {
-				validateErrors = append(validateErrors, fmt.Sprintf("Invalid date value for setting %s", setting.Name))
-			}
-		case enums.DateTime:
-			if _, err := time.Parse(time.DateTime, setting.Value); err != nil {
-				validateErrors = append(validateErrors, fmt.Sprintf("Invalid datetime value for setting %s", setting.Name))
-			}
-		case enums.JSON:
-			var js json.RawMessage
-			if err := json.Unmarshal([]byte(setting.Value), &js); err != nil {
-				validateErrors = append(validateErrors, fmt.Sprintf("Invalid JSON value for setting %s", setting.Name))
+		if validationError := validateSettingValue(setting.Name, setting.ValueType, setting.Value); validationError != "" {
+			validateErrors = append(validateErrors, validationError)
+			continue
+		}
+		if validationError := validateSecretLevel(setting.Name, setting.ValueType, setting.Level); validationError != "" {
+			validateErrors = append(validateErrors, validationError)
+		}
+		validateErrors = append(validateErrors, validateSettingAgainstDefinitions(setting.Name, setting.Level, setting.Value, setting.ValueType, definitions)...)
+	}
+
+	return strings.Join(validateErrors, ", ")
+}
+
+// validateRequiredAppSettings checks if every required SettingDefinition without a default has a base setting,
+// the environments fall back to it.
+// If any validation errors occur, it returns a comma-separated string of error messages.
+// If the string is empty, it means all validations passed.
+func validateRequiredAppSettings(settings *[]requests.AppSetting, definitions *[]models.SettingDefinition) string {
+	var validateErrors []string
+
+	for i := range *definitions {
+		definition := &(*definitions)[i]
+		if !definition.Required || definition.Default.Valid {
+			continue
+		}
+
+		exists := false
+		for j := range *settings {
+			if (*settings)[j].Name == definition.Name && (*settings)[j].Environment == "" {
+				exists = true
+				break
 			}
-		default:
-			validateErrors = append(validateErrors, fmt.Sprintf("Unknown ValueType for setting %s", setting.Name))
+		}
+		if !exists {
+			validateErrors = append(validateErrors, fmt.Sprintf("Missing required setting %s", definition.Name))
 		}
 	}
 
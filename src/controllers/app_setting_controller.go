@@ -1,9 +1,13 @@
 package controllers
 
 import (
+	"api-app/main/src/dto/requests"
+	"api-app/main/src/dto/responses"
 	"api-app/main/src/enums"
 	"api-app/main/src/errors"
 	"api-app/main/src/services"
+	apputils "api-app/main/src/utils"
+	"fmt"
 	errorutil "github.com/ArnoldPMolenaar/api-utils/errors"
 	"github.com/ArnoldPMolenaar/api-utils/utils"
 	"github.com/gofiber/fiber/v2"
@@ -17,19 +21,31 @@ func GetSettingsByAppName(c *fiber.Ctx, level enums.Level) error {
 		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "App Name is required.")
 	}
 
-	// Get the app settings.
-	appSettings, err := services.GetAppSettingsByName(appName, level)
+	// Get the app settings, in the environment when it is given.
+	environment := c.Query("env")
+	appSettings, err := services.GetAppSettingsByName(appName, level, environment)
 	if err != nil {
 		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if appSettings == nil && environment != "" {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.EnvironmentNotFound, "App or environment does not exist.")
+	} else if appSettings == nil {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.AppNotFound, "App does not exist.")
 	}
 
-	// Return the settings.
-	response, err := toSettingsResponse(appSettings, nil)
+	// Get the global settings.
+	globalSettings, err := services.GetGlobalSettings(level)
 	if err != nil {
-		return errorutil.Response(c, fiber.StatusInternalServerError, errors.DomainSettings, err.Error())
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
 	}
 
-	return c.JSON(response)
+	// Get the setting definitions.
+	definitions, err := services.GetSettingDefinitionsByAppName(appName)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	// Return the settings, or their explanation.
+	return sendSettings(c, globalSettings, appSettings, nil, definitions, level)
 }
 
 // GetSettingsByAppID function to get settings by app ID.
@@ -44,17 +60,161 @@ func GetSettingsByAppID(c *fiber.Ctx, level enums.Level) error {
 		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, "Invalid App ID.")
 	}
 
-	// Get the app settings.
-	appSettings, err := services.GetAppSettingsByAppID(appID, level)
+	// Get the app settings, in the environment when it is given.
+	environment := c.Query("env")
+	appSettings, err := services.GetAppSettingsByAppID(appID, level, environment)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if appSettings == nil && environment != "" {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.EnvironmentNotFound, "App or environment does not exist.")
+	} else if appSettings == nil {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.AppNotFound, "App does not exist.")
+	}
+
+	// Get the global settings.
+	globalSettings, err := services.GetGlobalSettings(level)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	// Get the setting definitions.
+	definitions, err := services.GetSettingDefinitionsByAppID(appID)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	// Return the settings, or their explanation.
+	return sendSettings(c, globalSettings, appSettings, nil, definitions, level)
+}
+
+// UpsertAppSetting func to create or update a single setting of an app.
+func UpsertAppSetting(c *fiber.Ctx) error {
+	// Get the ID and name from the URL.
+	appIDParam := c.Params("id")
+	if appIDParam == "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "App ID is required.")
+	}
+	appID, err := utils.StringToUint(appIDParam)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, "Invalid App ID.")
+	}
+	name := c.Params("name")
+	if name == "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "Setting Name is required.")
+	}
+
+	// Parse the request.
+	request := requests.UpsertSetting{}
+	if err := c.BodyParser(&request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.BodyParse, err.Error())
+	}
+
+	// Validate setting fields.
+	validate := utils.NewValidator()
+	if err := validate.Struct(request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.Validator, utils.ValidatorErrors(err))
+	}
+	setting := requests.AppSetting{
+		Name:        name,
+		Level:       request.Level,
+		Value:       request.Value,
+		ValueType:   request.ValueType,
+		Environment: c.Query("env"),
+		Locked:      request.Locked,
+	}
+	definitions, err := services.GetSettingDefinitionsByAppID(appID)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+	if validationErrors := validateAppSettings(&[]requests.AppSetting{setting}, definitions); validationErrors != "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errors.AppSettings, validationErrors)
+	}
+
+	// Find the app.
+	app, err := services.GetAppById(appID)
 	if err != nil {
 		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if app.ID == 0 {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.AppExists, "App does not exist.")
 	}
 
-	// Return the settings.
-	response, err := toSettingsResponse(appSettings, nil)
+	// Check if the environment exists.
+	if setting.Environment != "" {
+		if exists, err := services.IsEnvironmentAvailable(app.ID, setting.Environment); err != nil {
+			return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+		} else if !exists {
+			return errorutil.Response(c, fiber.StatusNotFound, errors.EnvironmentNotFound, "Environment does not exist.")
+		}
+	}
+
+	// Create or update the setting.
+	appSetting, err := services.UpsertAppSetting(app, &setting, apputils.GetActor(c))
 	if err != nil {
-		return errorutil.Response(c, fiber.StatusInternalServerError, errors.DomainSettings, err.Error())
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
 	}
 
+	// Return the setting.
+	response := responses.AppSetting{}
+	response.SetAppSetting(appSetting)
+
 	return c.JSON(response)
 }
+
+// DeleteAppSetting func to delete a single setting of an app.
+func DeleteAppSetting(c *fiber.Ctx) error {
+	// Get the ID and name from the URL.
+	appIDParam := c.Params("id")
+	if appIDParam == "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "App ID is required.")
+	}
+	appID, err := utils.StringToUint(appIDParam)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, "Invalid App ID.")
+	}
+	name := c.Params("name")
+	if name == "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "Setting Name is required.")
+	}
+	level := enums.Level(c.Query("level"))
+	if level == "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "Level is required.")
+	} else if level != enums.Public && level != enums.Private && level != enums.Both {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, "Invalid Level.")
+	}
+	environment := c.Query("env")
+
+	// Find the app.
+	app, err := services.GetAppById(appID)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if app.ID == 0 {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.AppExists, "App does not exist.")
+	}
+
+	// Find the setting.
+	exists := false
+	for i := range app.Settings {
+		if app.Settings[i].Name == name && app.Settings[i].Level == level && app.Settings[i].Environment == environment {
+			exists = true
+			break
+		}
+	}
+	if !exists {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.AppSettingExists, "Setting does not exist.")
+	}
+
+	// Check if the setting is required by its definition, an environment falls back to the base setting.
+	definition, err := services.GetSettingDefinitionByName(appID, name)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if environment == "" && definition.ID != 0 && definition.Required && !definition.Default.Valid {
+		return errorutil.Response(c, fiber.StatusBadRequest, errors.AppSettings, fmt.Sprintf("Missing required setting %s", name))
+	}
+
+	// Delete the setting.
+	if err := services.DeleteAppSetting(app, name, level, environment, apputils.GetActor(c)); err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
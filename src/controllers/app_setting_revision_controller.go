@@ -0,0 +1,172 @@
+package controllers
+
+import (
+	"api-app/main/src/database"
+	"api-app/main/src/dto/responses"
+	"api-app/main/src/errors"
+	"api-app/main/src/models"
+	"api-app/main/src/services"
+	apputils "api-app/main/src/utils"
+	errorutil "github.com/ArnoldPMolenaar/api-utils/errors"
+	"github.com/ArnoldPMolenaar/api-utils/pagination"
+	"github.com/ArnoldPMolenaar/api-utils/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetAppSettingRevisions function fetches the paginated setting revisions of an app.
+func GetAppSettingRevisions(c *fiber.Ctx) error {
+	// Get the appID parameter from the URL.
+	appIDParam := c.Params("id")
+	if appIDParam == "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "App ID is required.")
+	}
+	appID, err := utils.StringToUint(appIDParam)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, "Invalid App ID.")
+	}
+
+	revisions := make([]models.AppSettingRevision, 0)
+	values := c.Request().URI().QueryArgs()
+	allowedColumns := map[string]bool{
+		"revision":   true,
+		"actor":      true,
+		"created_at": true,
+	}
+
+	queryFunc := pagination.Query(values, allowedColumns)
+	sortFunc := pagination.Sort(values, allowedColumns)
+	page := c.QueryInt("page", 1)
+	if page < 1 {
+		page = 1
+	}
+	limit := c.QueryInt("limit", 10)
+	if limit < 1 {
+		limit = 10
+	}
+	offset := pagination.Offset(page, limit)
+
+	query := database.Pg.Scopes(queryFunc, sortFunc).Where("app_id = ?", appID)
+	if c.Query("sortBy") == "" {
+		query = query.Order("revision DESC")
+	}
+	if db := query.Limit(limit).Offset(offset).Find(&revisions); db.Error != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, db.Error.Error())
+	}
+
+	total := int64(0)
+	database.Pg.Scopes(queryFunc).Model(&models.AppSettingRevision{}).Where("app_id = ?", appID).Count(&total)
+	pageCount := pagination.Count(int(total), limit)
+
+	// Get the names of the secret settings.
+	secretNames, err := services.GetSecretSettingNamesByAppID(appID)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	settingRevisions := make([]responses.SettingRevision, len(revisions))
+	for i := range revisions {
+		settingRevisions[i].SetAppSettingRevision(&revisions[i], secretNames)
+	}
+
+	paginationModel := pagination.CreatePaginationModel(limit, page, pageCount, int(total), settingRevisions)
+
+	return c.Status(fiber.StatusOK).JSON(paginationModel)
+}
+
+// GetAppSettingRevision function fetches a single setting revision of an app.
+func GetAppSettingRevision(c *fiber.Ctx) error {
+	// Get the appID and revision parameters from the URL.
+	appIDParam := c.Params("id")
+	if appIDParam == "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "App ID is required.")
+	}
+	appID, err := utils.StringToUint(appIDParam)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, "Invalid App ID.")
+	}
+	revisionParam := c.Params("rev")
+	if revisionParam == "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "Revision is required.")
+	}
+	revision, err := utils.StringToUint(revisionParam)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, "Invalid Revision.")
+	}
+
+	// Get the revision.
+	appSettingRevision, err := services.GetAppSettingRevisionById(appID, revision)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if appSettingRevision.ID == 0 {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.AppRevisionExists, "Revision does not exist.")
+	}
+
+	// Get the names of the secret settings.
+	secretNames, err := services.GetSecretSettingNamesByAppID(appID)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	// Return the revision.
+	response := responses.SettingRevision{}
+	response.SetAppSettingRevision(appSettingRevision, secretNames)
+
+	return c.JSON(response)
+}
+
+// RollbackAppSettings func to restore the settings of an app to a revision.
+func RollbackAppSettings(c *fiber.Ctx) error {
+	// Get the appID and revision parameters from the URL.
+	appIDParam := c.Params("id")
+	if appIDParam == "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "App ID is required.")
+	}
+	appID, err := utils.StringToUint(appIDParam)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, "Invalid App ID.")
+	}
+	revisionParam := c.Params("rev")
+	if revisionParam == "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "Revision is required.")
+	}
+	revision, err := utils.StringToUint(revisionParam)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, "Invalid Revision.")
+	}
+
+	// Find the app.
+	app, err := services.GetAppById(appID)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if app.ID == 0 {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.AppExists, "App does not exist.")
+	}
+
+	// Find the revision.
+	appSettingRevision, err := services.GetAppSettingRevisionById(appID, revision)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if appSettingRevision.ID == 0 {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.AppRevisionExists, "Revision does not exist.")
+	}
+
+	// Rollback the settings.
+	newRevision, err := services.RollbackAppSettings(app, appSettingRevision, apputils.GetActor(c))
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if newRevision == nil {
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+
+	// Get the names of the secret settings.
+	secretNames, err := services.GetSecretSettingNamesByAppID(appID)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	// Return the new revision.
+	response := responses.SettingRevision{}
+	response.SetAppSettingRevision(newRevision, secretNames)
+
+	return c.JSON(response)
+}
@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"api-app/main/src/dto/requests"
+	"api-app/main/src/dto/responses"
+	"api-app/main/src/enums"
+	"api-app/main/src/models"
+	"api-app/main/src/services"
+	"bufio"
+	"encoding/json"
+	errorutil "github.com/ArnoldPMolenaar/api-utils/errors"
+	"github.com/ArnoldPMolenaar/api-utils/pagination"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"slices"
+	"time"
+)
+
+// GetAuditEvents function fetches the audit events from the database, filtered by the query string.
+func GetAuditEvents(c *fiber.Ctx) error {
+	events := make([]models.AuditEvent, 0)
+	values := c.Request().URI().QueryArgs()
+	allowedColumns := map[string]bool{
+		"id":            true,
+		"actor":         true,
+		"claimed_actor": true,
+		"request_id":    true,
+		"action":        true,
+		"target":        true,
+		"app_id":        true,
+		"domain_id":     true,
+		"created_at":    true,
+	}
+
+	// Get the filters from the query string.
+	filter := &requests.GetAuditEvents{}
+	if err := c.QueryParser(filter); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.BodyParse, err.Error())
+	}
+	if validationError := validateAuditFilter(filter); validationError != "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, validationError)
+	}
+
+	queryFunc := pagination.Query(values, allowedColumns)
+	sortFunc := pagination.Sort(values, allowedColumns)
+	page := c.QueryInt("page", 1)
+	if page < 1 {
+		page = 1
+	}
+	limit := c.QueryInt("limit", 10)
+	if limit < 1 {
+		limit = 10
+	}
+	offset := pagination.Offset(page, limit)
+
+	query := services.AuditListQuery(filter).Scopes(queryFunc, sortFunc)
+	if c.Query("sortBy") == "" {
+		query = query.Order("id DESC")
+	}
+
+	db := query.Limit(limit).Offset(offset).Find(&events)
+	if db.Error != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, db.Error.Error())
+	}
+
+	total := int64(0)
+	services.AuditListQuery(filter).Scopes(queryFunc).Count(&total)
+	pageCount := pagination.Count(int(total), limit)
+
+	auditEvents := make([]responses.AuditEvent, len(events))
+	for i := range events {
+		auditEvents[i].SetAuditEvent(&events[i])
+	}
+
+	paginationModel := pagination.CreatePaginationModel(limit, page, pageCount, int(total), auditEvents)
+
+	return c.Status(fiber.StatusOK).JSON(paginationModel)
+}
+
+// ExportAuditEvents function streams the audit events that match the query string as NDJSON, oldest first.
+func ExportAuditEvents(c *fiber.Ctx) error {
+	// Get the filters from the query string.
+	filter := &requests.GetAuditEvents{}
+	if err := c.QueryParser(filter); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.BodyParse, err.Error())
+	}
+	if validationError := validateAuditFilter(filter); validationError != "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, validationError)
+	}
+
+	c.Set(fiber.HeaderContentType, "application/x-ndjson")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="audit.ndjson"`)
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		encoder := json.NewEncoder(w)
+
+		if err := services.ExportAuditEvents(filter, func(events []models.AuditEvent) error {
+			for i := range events {
+				auditEvent := responses.AuditEvent{}
+				auditEvent.SetAuditEvent(&events[i])
+				if err := encoder.Encode(auditEvent); err != nil {
+					return err
+				}
+			}
+
+			return w.Flush()
+		}); err != nil {
+			log.Errorf("Could not export the audit events: %v", err)
+		}
+	})
+
+	return nil
+}
+
+// validateAuditFilter validates the filters of the audit listing and export.
+func validateAuditFilter(filter *requests.GetAuditEvents) string {
+	if filter.Action != "" && !slices.Contains(enums.AuditActions, enums.AuditAction(filter.Action)) {
+		return "Invalid action."
+	}
+	if filter.From != "" {
+		if _, err := time.Parse(time.RFC3339, filter.From); err != nil {
+			return "Invalid from, it must be an RFC 3339 timestamp."
+		}
+	}
+	if filter.To != "" {
+		if _, err := time.Parse(time.RFC3339, filter.To); err != nil {
+			return "Invalid to, it must be an RFC 3339 timestamp."
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"api-app/main/src/cache"
+	"api-app/main/src/dto/responses"
+	"api-app/main/src/services"
+	errorutil "github.com/ArnoldPMolenaar/api-utils/errors"
+	"github.com/gofiber/fiber/v2"
+)
+
+// WarmCache function to load the settings of every app and domain into the cache.
+func WarmCache(c *fiber.Ctx) error {
+	if !cache.Available() {
+		return errorutil.Response(c, fiber.StatusServiceUnavailable, errorutil.CacheError, cache.ErrUnavailable.Error())
+	}
+
+	// Warm the cache.
+	apps, domains, err := services.WarmCache()
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.CacheError, err.Error())
+	}
+
+	// Return the number of warmed apps and domains.
+	return c.JSON(responses.CacheWarm{Apps: apps, Domains: domains})
+}
+
+// GetCacheStats function to get the hit and miss counters of this instance and the number of keys per group.
+func GetCacheStats(c *fiber.Ctx) error {
+	// Count the keys per group.
+	keys, err := cache.CountKeys(services.CacheKeyGroup)
+	if err == cache.ErrUnavailable {
+		return errorutil.Response(c, fiber.StatusServiceUnavailable, errorutil.CacheError, err.Error())
+	} else if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.CacheError, err.Error())
+	}
+
+	// Return the statistics.
+	response := responses.CacheStats{}
+	response.SetCacheStats(cache.GetStats(), keys)
+
+	return c.JSON(response)
+}
+
+// ClearCache function to delete the keys of an app from the cache, or every key when no app is given.
+func ClearCache(c *fiber.Ctx) error {
+	// Get the app parameter from the query string.
+	appName := c.Query("app")
+
+	// Clear the cache.
+	var err error
+	if appName != "" {
+		err = services.ClearAppCache(appName)
+	} else {
+		err = services.ClearCache()
+	}
+	if err == cache.ErrUnavailable {
+		return errorutil.Response(c, fiber.StatusServiceUnavailable, errorutil.CacheError, err.Error())
+	} else if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.CacheError, err.Error())
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
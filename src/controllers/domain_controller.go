@@ -5,17 +5,107 @@ import (
 	"api-app/main/src/dto/responses"
 	"api-app/main/src/enums"
 	"api-app/main/src/errors"
+	"api-app/main/src/models"
 	"api-app/main/src/services"
-	"encoding/json"
+	apputils "api-app/main/src/utils"
 	"fmt"
 	errorutil "github.com/ArnoldPMolenaar/api-utils/errors"
+	"github.com/ArnoldPMolenaar/api-utils/pagination"
 	"github.com/ArnoldPMolenaar/api-utils/utils"
 	"github.com/gofiber/fiber/v2"
-	"strconv"
 	"strings"
-	"time"
 )
 
+// domainIncludes are the relations of a domain that can be included in a read.
+var domainIncludes = []string{"settings"}
+
+// domainFields are the fields of a domain that can be selected in a read.
+var domainFields = []string{
+	"id", "appId", "ssl", "name", "sub", "secondLevel", "topLevel", "ipAddress", "aliasOfId", "version", "verification", "createdAt", "updatedAt",
+}
+
+// GetDomains function fetches the domains from the database, filtered by the query string.
+func GetDomains(c *fiber.Ctx) error {
+	domains := make([]models.Domain, 0)
+	values := c.Request().URI().QueryArgs()
+	allowedColumns := map[string]bool{
+		"id":                  true,
+		"app_id":              true,
+		"name":                true,
+		"ssl":                 true,
+		"sub":                 true,
+		"second_level":        true,
+		"top_level":           true,
+		"ip_address":          true,
+		"verification_status": true,
+		"created_at":          true,
+		"updated_at":          true,
+		"deleted_at":          true,
+	}
+
+	// Get the filters from the query string.
+	filter := &requests.GetDomains{}
+	if err := c.QueryParser(filter); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.BodyParse, err.Error())
+	}
+	switch filter.Deleted {
+	case "", "false", "true", "all":
+	default:
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, "Invalid deleted filter.")
+	}
+	switch enums.VerificationStatus(filter.VerificationStatus) {
+	case "", enums.Pending, enums.Verified, enums.Failed:
+	default:
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, "Invalid verification status.")
+	}
+
+	// Get the included relations and fields from the query string.
+	expansion, err := apputils.ParseExpansion(c, nil, domainIncludes, append(domainFields, "deletedAt"))
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, err.Error())
+	}
+	includeSettings := expansion.Include("settings")
+
+	queryFunc := pagination.Query(values, allowedColumns)
+	sortFunc := pagination.Sort(values, allowedColumns)
+	page := c.QueryInt("page", 1)
+	if page < 1 {
+		page = 1
+	}
+	limit := c.QueryInt("limit", 10)
+	if limit < 1 {
+		limit = 10
+	}
+	offset := pagination.Offset(page, limit)
+
+	query := services.DomainListQuery(filter).Scopes(queryFunc, sortFunc)
+	if includeSettings {
+		query = query.Preload("Settings")
+	}
+
+	db := query.Limit(limit).Offset(offset).Find(&domains)
+	if db.Error != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, db.Error.Error())
+	}
+
+	total := int64(0)
+	services.DomainListQuery(filter).Scopes(queryFunc).Count(&total)
+	pageCount := pagination.Count(int(total), limit)
+
+	paginatedDomains := make([]interface{}, len(domains))
+	for i := range domains {
+		paginatedDomain := responses.PaginatedDomain{}
+		paginatedDomain.SetPaginatedDomain(&domains[i], includeSettings)
+		if paginatedDomains[i], err = expansion.SelectFields(paginatedDomain); err != nil {
+			return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.InternalServerError, err.Error())
+		}
+	}
+
+	paginationModel := pagination.CreatePaginationModel(limit, page, pageCount, int(total), paginatedDomains)
+
+	return c.Status(fiber.StatusOK).JSON(paginationModel)
+}
+
 // GetDomain function fetches a domain from the database by its ID.
 func GetDomain(c *fiber.Ctx) error {
 	// Get the domainID parameter from the URL.
@@ -28,8 +118,14 @@ func GetDomain(c *fiber.Ctx) error {
 		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, "Invalid Domain ID.")
 	}
 
+	// Get the included relations and fields from the query string.
+	expansion, err := apputils.ParseExpansion(c, domainIncludes, domainIncludes, domainFields)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, err.Error())
+	}
+
 	// Get the domain.
-	domain, err := services.GetDomainById(domainID)
+	domain, err := services.GetExpandedDomainById(domainID, expansion.Includes)
 	if err != nil {
 		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
 	} else if domain.ID == 0 {
@@ -38,9 +134,14 @@ func GetDomain(c *fiber.Ctx) error {
 
 	// Return the domain.
 	response := responses.Domain{}
-	response.SetDomain(domain)
+	response.SetExpandedDomain(domain, expansion.Includes)
+	result, err := expansion.SelectFields(response)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.InternalServerError, err.Error())
+	}
+	c.Set(fiber.HeaderETag, apputils.VersionETag(domain.Version))
 
-	return c.JSON(response)
+	return c.JSON(result)
 }
 
 // CreateDomain func to create a domain.
@@ -56,7 +157,23 @@ func CreateDomain(c *fiber.Ctx) error {
 	if err := validate.Struct(request); err != nil {
 		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.Validator, utils.ValidatorErrors(err))
 	}
-	if validationErrors := validateDomainSettings(&request.Settings); validationErrors != "" {
+	if validationErrors := normalizeDomainNames(&request.Name); validationErrors != "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errors.DomainName, validationErrors)
+	}
+	definitions, err := services.GetSettingDefinitionsByAppID(request.AppID)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+	if validationErrors := validateDomainSettings(&request.Settings, definitions); validationErrors != "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errors.DomainSettings, validationErrors)
+	}
+	environments := make([]string, len(request.Settings))
+	for i := range request.Settings {
+		environments[i] = request.Settings[i].Environment
+	}
+	if validationErrors, err := validateSettingEnvironments(request.AppID, environments); err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if validationErrors != "" {
 		return errorutil.Response(c, fiber.StatusBadRequest, errors.DomainSettings, validationErrors)
 	}
 
@@ -67,8 +184,15 @@ func CreateDomain(c *fiber.Ctx) error {
 		return errorutil.Response(c, fiber.StatusBadRequest, errors.DomainAvailable, "DomainName already available.")
 	}
 
+	// Check if the alias points to a canonical domain of the app.
+	if validationErrors, err := validateAliasOf(request.AppID, 0, request.AliasOfID); err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if validationErrors != "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errors.DomainAlias, validationErrors)
+	}
+
 	// Create the domain.
-	domain, err := services.CreateDomain(request.AppID, request.SSL, request.Name, request.IpAddress, &request.Settings)
+	domain, err := services.CreateDomain(request.AppID, request.SSL, request.Name, request.IpAddress, request.AliasOfID, &request.Settings, apputils.GetActor(c))
 	if err != nil {
 		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
 	}
@@ -103,8 +227,8 @@ func UpdateDomain(c *fiber.Ctx) error {
 	if err := validate.Struct(request); err != nil {
 		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.Validator, utils.ValidatorErrors(err))
 	}
-	if validationErrors := validateDomainSettings(&request.Settings); validationErrors != "" {
-		return errorutil.Response(c, fiber.StatusBadRequest, errors.DomainSettings, validationErrors)
+	if validationErrors := normalizeDomainNames(&request.Name); validationErrors != "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errors.DomainName, validationErrors)
 	}
 
 	// Get the domain.
@@ -115,6 +239,24 @@ func UpdateDomain(c *fiber.Ctx) error {
 		return errorutil.Response(c, fiber.StatusNotFound, errors.DomainExists, "Domain not found.")
 	}
 
+	// Validate the settings with the definitions of the app.
+	definitions, err := services.GetSettingDefinitionsByAppID(domain.AppID)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+	if validationErrors := validateDomainSettings(&request.Settings, definitions); validationErrors != "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errors.DomainSettings, validationErrors)
+	}
+	environments := make([]string, len(request.Settings))
+	for i := range request.Settings {
+		environments[i] = request.Settings[i].Environment
+	}
+	if validationErrors, err := validateSettingEnvironments(domain.AppID, environments); err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if validationErrors != "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errors.DomainSettings, validationErrors)
+	}
+
 	// Check if domain exists.
 	if request.Name != domain.Name {
 		if available, err := services.IsDomainNameAvailable(domain.AppID, request.Name); err != nil {
@@ -124,20 +266,38 @@ func UpdateDomain(c *fiber.Ctx) error {
 		}
 	}
 
-	// Check if the domain data has been modified since it was last fetched.
-	if request.UpdatedAt.Unix() < domain.UpdatedAt.Unix() {
-		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.OutOfSync, "Data is out of sync.")
+	// Get the version the change is based on, from the If-Match header or else from the request.
+	version, ifMatch, err := apputils.GetIfMatchVersion(c)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, err.Error())
+	} else if !ifMatch {
+		if request.Version == 0 {
+			return errorutil.Response(c, fiber.StatusPreconditionRequired, errorutil.OutOfSync, "The If-Match header or the version is required.")
+		}
+		version = request.Version
+	} else if version == 0 {
+		version = domain.Version
+	}
+
+	// Check if the alias points to a canonical domain of the app.
+	if validationErrors, err := validateAliasOf(domain.AppID, domain.ID, request.AliasOfID); err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if validationErrors != "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errors.DomainAlias, validationErrors)
 	}
 
 	// Update the domain.
-	domain, err = services.UpdateDomain(domain, request.SSL, request.Name, request.IpAddress, &request.Settings)
-	if err != nil {
+	domain, err = services.UpdateDomain(domain, version, request.SSL, request.Name, request.IpAddress, request.AliasOfID, &request.Settings, apputils.GetActor(c))
+	if err == services.ErrVersionConflict {
+		return sendDomainPreconditionFailed(c, domainID)
+	} else if err != nil {
 		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
 	}
 
 	// Return the domain.
 	response := responses.Domain{}
 	response.SetDomain(domain)
+	c.Set(fiber.HeaderETag, apputils.VersionETag(domain.Version))
 
 	return c.JSON(response)
 }
@@ -154,16 +314,36 @@ func DeleteDomain(c *fiber.Ctx) error {
 		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, err.Error())
 	}
 
-	// Get the domain.
-	domain, err := services.GetDomainById(domainID)
+	// Get the permanent parameter from the query string.
+	permanent := c.QueryBool("permanent")
+
+	// Get the domain, a deleted domain can only be deleted permanently.
+	domain, err := services.GetDomainById(domainID, permanent)
 	if err != nil {
 		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
 	} else if domain.ID == 0 {
 		return errorutil.Response(c, fiber.StatusNotFound, errors.DomainExists, "Domain not found.")
 	}
 
+	// Delete the domain permanently.
+	if permanent {
+		if err := services.PurgeDomain(domain, apputils.GetActor(c)); err != nil {
+			return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+		}
+
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+
+	// Get the version the delete is based on from the If-Match header, without it the domain is deleted regardless.
+	version, _, err := apputils.GetIfMatchVersion(c)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, err.Error())
+	}
+
 	// Delete the domain.
-	if err := services.DeleteDomain(domain); err != nil {
+	if err := services.DeleteDomain(domain, version, apputils.GetActor(c)); err == services.ErrVersionConflict {
+		return sendDomainPreconditionFailed(c, domainID)
+	} else if err != nil {
 		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
 	}
 
@@ -189,55 +369,168 @@ func RestoreDomain(c *fiber.Ctx) error {
 		return errorutil.Response(c, fiber.StatusNotFound, errors.DomainExists, "Domain does not exist.")
 	}
 
+	// Check if the app of the domain is deleted, because it has to be restored first.
+	if deleted, err := services.IsDomainAppDeleted(domainID); err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if deleted {
+		return errorutil.Response(c, fiber.StatusConflict, errors.AppDeleted, "The app of the domain is deleted.")
+	}
+
+	// Check if the name of the domain has been taken since it was deleted.
+	domain, err := services.GetDomainById(domainID, true)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+	if available, err := services.IsDomainNameAvailable(domain.AppID, domain.Name); err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if available {
+		return errorutil.Response(c, fiber.StatusConflict, errors.DomainAvailable, "Domain name is taken by another domain.")
+	}
+
 	// Restore the domain.
-	if err := services.RestoreDomain(domainID); err != nil {
+	if err := services.RestoreDomain(domainID, apputils.GetActor(c)); err != nil {
 		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
 	}
 
 	return c.SendStatus(fiber.StatusNoContent)
 }
 
+// VerifyDomain func to verify the ownership of a domain.
+// The verification token is checked with a DNS TXT record, or with an HTTP file when the method is "http".
+func VerifyDomain(c *fiber.Ctx) error {
+	// Get the ID from the URL.
+	domainIDParam := c.Params("id")
+	if domainIDParam == "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "Domain ID is required.")
+	}
+	domainID, err := utils.StringToUint(domainIDParam)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, err.Error())
+	}
+
+	// Get the verification method from the query string.
+	method := enums.VerificationMethod(c.Query("method", enums.DNS.String()))
+	if method != enums.DNS && method != enums.HTTP {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, "Invalid verification method.")
+	}
+
+	// Find the domain.
+	domain, err := services.GetDomainById(domainID)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if domain.ID == 0 {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.DomainExists, "Domain does not exist.")
+	}
+
+	// Verify the domain.
+	domain, reason, err := services.VerifyDomain(domain, method, apputils.GetActor(c))
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if reason != "" {
+		return errorutil.Response(c, fiber.StatusUnprocessableEntity, errors.DomainVerification, reason)
+	}
+
+	// Return the domain.
+	response := responses.Domain{}
+	response.SetDomain(domain)
+
+	return c.JSON(response)
+}
+
+// validateAliasOf validates the canonical domain of an alias domain.
+// The canonical domain must belong to the same app and can not be an alias itself,
+// and a domain that has aliases can not become an alias.
+// The domainID is zero for a new domain.
+// If any validation errors occur, it returns a comma-separated string of error messages.
+func validateAliasOf(appID, domainID uint, aliasOfID *uint) (string, error) {
+	if aliasOfID == nil {
+		return "", nil
+	}
+
+	var validateErrors []string
+	if *aliasOfID == domainID {
+		validateErrors = append(validateErrors, "Domain can not be an alias of itself")
+	}
+
+	canonical, err := services.GetDomainById(*aliasOfID)
+	if err != nil {
+		return "", err
+	}
+	if canonical.ID == 0 || canonical.AppID != appID {
+		validateErrors = append(validateErrors, fmt.Sprintf("Domain %d does not exist for the app", *aliasOfID))
+	} else if canonical.AliasOfID != nil {
+		validateErrors = append(validateErrors, fmt.Sprintf("Domain %d is an alias itself", *aliasOfID))
+	}
+
+	if domainID != 0 {
+		if hasAliases, err := services.HasDomainAliases(domainID); err != nil {
+			return "", err
+		} else if hasAliases {
+			validateErrors = append(validateErrors, "Domain with aliases can not be an alias")
+		}
+	}
+
+	return strings.Join(validateErrors, ", "), nil
+}
+
+// normalizeDomainNames replaces the domain names with their canonical form.
+// If any domain name is invalid, it returns a comma-separated string of error messages.
+// If the string is empty, it means all domain names are valid.
+func normalizeDomainNames(names ...*string) string {
+	var validateErrors []string
+
+	for _, name := range names {
+		normalized, err := apputils.NormalizeDomain(*name)
+		if err != nil {
+			validateErrors = append(validateErrors, err.Error())
+			continue
+		}
+		if _, _, _, err := apputils.ExtractDomain(normalized); err != nil {
+			validateErrors = append(validateErrors, err.Error())
+			continue
+		}
+		*name = normalized
+	}
+
+	return strings.Join(validateErrors, ", ")
+}
+
 // validateDomainSettings validates an array of DomainSetting structs.
-// It checks if the Value field of each DomainSetting is valid based on its ValueType.
+// It checks if the Value field of each DomainSetting is valid based on its ValueType,
+// and if it satisfies the SettingDefinition of the app of the domain.
 // If any validation errors occur, it returns a comma-separated string of error messages.
 // If the string is empty, it means all validations passed.
-func validateDomainSettings(settings *[]requests.DomainSetting) string {
+func validateDomainSettings(settings *[]requests.DomainSetting, definitions *[]models.SettingDefinition) string {
 	var validateErrors []string
 
 	for i := range *settings {
 		setting := &(*settings)[i]
-		switch enums.ValueType(setting.ValueType) {
-		case enums.Int:
-			if _, err := strconv.Atoi(setting.Value); err != nil {
-				validateErrors = append(validateErrors, fmt.Sprintf("Invalid int value for setting %s", setting.Name))
-			}
-		case enums.Float:
-			if _, err := strconv.ParseFloat(setting.Value, 64); err != nil {
-				validateErrors = append(validateErrors, fmt.Sprintf("Invalid float value for setting %s", setting.Name))
-			}
-		case enums.String:
-			// No validation needed for string type.
-		case enums.Bool:
-			if _, err := strconv.ParseBool(setting.Value); err != nil {
-				validateErrors = append(validateErrors, fmt.Sprintf("Invalid bool value for setting %s", setting.Name))
-			}
-		case enums.Date:
-			if _, err := time.Parse(time.DateOnly, setting.Value); err != nil {
-				validateErrors = append(validateErrors, fmt.Sprintf("Invalid date value for setting %s", setting.Name))
-			}
-		case enums.DateTime:
-			if _, err := time.Parse(time.DateTime, setting.Value); err != nil {
-				validateErrors = append(validateErrors, fmt.Sprintf("Invalid datetime value for setting %s", setting.Name))
-			}
-		case enums.JSON:
-			var js json.RawMessage
-			if err := json.Unmarshal([]byte(setting.Value), &js); err != nil {
-				validateErrors = append(validateErrors, fmt.Sprintf("Invalid JSON value for setting %s", setting.Name))
-			}
-		default:
-			validateErrors = append(validateErrors, fmt.Sprintf("Unknown ValueType for setting %s", setting.Name))
+		if validationError := validateSettingValue(setting.Name, setting.ValueType, setting.Value); validationError != "" {
+			validateErrors = append(validateErrors, validationError)
+			continue
+		}
+		if validationError := validateSecretLevel(setting.Name, setting.ValueType, setting.Level); validationError != "" {
+			validateErrors = append(validateErrors, validationError)
 		}
+		validateErrors = append(validateErrors, validateSettingAgainstDefinitions(setting.Name, setting.Level, setting.Value, setting.ValueType, definitions)...)
 	}
 
 	return strings.Join(validateErrors, ", ")
 }
+
+// sendDomainPreconditionFailed responds with 412 Precondition Failed and the current representation of the domain,
+// after a change that was based on an outdated version.
+func sendDomainPreconditionFailed(c *fiber.Ctx, domainID uint) error {
+	domain, err := services.GetDomainById(domainID)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if domain.ID == 0 {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.DomainExists, "Domain not found.")
+	}
+
+	response := responses.Domain{}
+	response.SetDomain(domain)
+	c.Set(fiber.HeaderETag, apputils.VersionETag(domain.Version))
+
+	return c.Status(fiber.StatusPreconditionFailed).JSON(response)
+}
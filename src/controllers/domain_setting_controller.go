@@ -1,10 +1,14 @@
 package controllers
 
 import (
+	"api-app/main/src/dto/requests"
+	"api-app/main/src/dto/responses"
 	"api-app/main/src/enums"
 	"api-app/main/src/errors"
 	"api-app/main/src/models"
+	"api-app/main/src/secrets"
 	"api-app/main/src/services"
+	apputils "api-app/main/src/utils"
 	"encoding/json"
 	"fmt"
 	errorutil "github.com/ArnoldPMolenaar/api-utils/errors"
@@ -25,26 +29,50 @@ func GetSettingsByDomainName(c *fiber.Ctx, level enums.Level) error {
 	if domainName == "" {
 		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "Domain Name is required.")
 	}
+	if normalized, err := apputils.NormalizeDomain(domainName); err == nil {
+		domainName = normalized
+	}
+
+	// Check if the domain is hidden.
+	if hidden, err := services.IsDomainNameHidden(appName, domainName, level); err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if hidden {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.DomainNotFound, "Domain does not exist.")
+	}
 
-	// Get the app settings.
-	appSettings, err := services.GetAppSettingsByName(appName, level)
+	// Get the app settings, in the environment when it is given.
+	environment := c.Query("env")
+	appSettings, err := services.GetAppSettingsByName(appName, level, environment)
 	if err != nil {
 		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if appSettings == nil && environment != "" {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.EnvironmentNotFound, "App or environment does not exist.")
+	} else if appSettings == nil {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.AppNotFound, "App does not exist.")
 	}
 
 	// Get the domain settings.
-	domainSettings, err := services.GetDomainSettingsByName(appName, domainName, level)
+	domainSettings, err := services.GetDomainSettingsByName(appName, domainName, level, environment)
 	if err != nil {
 		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if domainSettings == nil {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.DomainNotFound, "Domain does not exist.")
 	}
 
-	// Return the settings.
-	response, err := toSettingsResponse(appSettings, domainSettings)
+	// Get the global settings.
+	globalSettings, err := services.GetGlobalSettings(level)
 	if err != nil {
-		return errorutil.Response(c, fiber.StatusInternalServerError, errors.DomainSettings, err.Error())
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
 	}
 
-	return c.JSON(response)
+	// Get the setting definitions.
+	definitions, err := services.GetSettingDefinitionsByAppName(appName)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	// Return the settings, or their explanation.
+	return sendSettings(c, globalSettings, appSettings, domainSettings, definitions, level)
 }
 
 // GetSettingsByDomainID function to get settings by domain ID.
@@ -63,22 +91,192 @@ func GetSettingsByDomainID(c *fiber.Ctx, level enums.Level) error {
 	appID, err := services.GetAppIDByDomainID(domainID)
 	if err != nil {
 		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if appID == 0 {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.DomainNotFound, "Domain does not exist.")
 	}
 
-	// Get the app settings.
-	appSettings, err := services.GetAppSettingsByAppID(appID, level)
+	// Check if the domain is hidden.
+	if hidden, err := services.IsDomainHidden(domainID, level); err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if hidden {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.DomainNotFound, "Domain does not exist.")
+	}
+
+	// Get the app settings, in the environment when it is given.
+	environment := c.Query("env")
+	appSettings, err := services.GetAppSettingsByAppID(appID, level, environment)
 	if err != nil {
 		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if appSettings == nil && environment != "" {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.EnvironmentNotFound, "App or environment does not exist.")
+	} else if appSettings == nil {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.AppNotFound, "App does not exist.")
 	}
 
 	// Get the domain settings.
-	domainSettings, err := services.GetDomainSettingsByDomainID(domainID, level)
+	domainSettings, err := services.GetDomainSettingsByDomainID(domainID, level, environment)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if domainSettings == nil {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.DomainNotFound, "Domain does not exist.")
+	}
+
+	// Get the global settings.
+	globalSettings, err := services.GetGlobalSettings(level)
 	if err != nil {
 		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
 	}
 
-	// Return the settings.
-	response, err := toSettingsResponse(appSettings, domainSettings)
+	// Get the setting definitions.
+	definitions, err := services.GetSettingDefinitionsByAppID(appID)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	// Return the settings, or their explanation.
+	return sendSettings(c, globalSettings, appSettings, domainSettings, definitions, level)
+}
+
+// UpsertDomainSetting func to create or update a single setting of a domain.
+func UpsertDomainSetting(c *fiber.Ctx) error {
+	// Get the ID and name from the URL.
+	domainIDParam := c.Params("id")
+	if domainIDParam == "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "Domain ID is required.")
+	}
+	domainID, err := utils.StringToUint(domainIDParam)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, "Invalid Domain ID.")
+	}
+	name := c.Params("name")
+	if name == "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "Setting Name is required.")
+	}
+
+	// Parse the request.
+	request := requests.UpsertSetting{}
+	if err := c.BodyParser(&request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.BodyParse, err.Error())
+	}
+
+	// Validate setting fields.
+	validate := utils.NewValidator()
+	if err := validate.Struct(request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.Validator, utils.ValidatorErrors(err))
+	}
+	setting := requests.DomainSetting{
+		DomainID:    domainID,
+		Name:        name,
+		Level:       request.Level,
+		Value:       request.Value,
+		ValueType:   request.ValueType,
+		Environment: c.Query("env"),
+		Locked:      request.Locked,
+	}
+
+	// Find the domain.
+	domain, err := services.GetDomainById(domainID)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if domain.ID == 0 {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.DomainExists, "Domain does not exist.")
+	}
+
+	// Check if the environment exists.
+	if setting.Environment != "" {
+		if exists, err := services.IsEnvironmentAvailable(domain.AppID, setting.Environment); err != nil {
+			return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+		} else if !exists {
+			return errorutil.Response(c, fiber.StatusNotFound, errors.EnvironmentNotFound, "Environment does not exist.")
+		}
+	}
+
+	// Validate the setting with the definitions of the app.
+	definitions, err := services.GetSettingDefinitionsByAppID(domain.AppID)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+	if validationErrors := validateDomainSettings(&[]requests.DomainSetting{setting}, definitions); validationErrors != "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errors.DomainSettings, validationErrors)
+	}
+
+	// Create or update the setting.
+	domainSetting, err := services.UpsertDomainSetting(domain, &setting, apputils.GetActor(c))
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	// Return the setting.
+	response := responses.DomainSetting{}
+	response.SetDomainSetting(domainSetting)
+
+	return c.JSON(response)
+}
+
+// DeleteDomainSetting func to delete a single setting of a domain.
+func DeleteDomainSetting(c *fiber.Ctx) error {
+	// Get the ID and name from the URL.
+	domainIDParam := c.Params("id")
+	if domainIDParam == "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "Domain ID is required.")
+	}
+	domainID, err := utils.StringToUint(domainIDParam)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, "Invalid Domain ID.")
+	}
+	name := c.Params("name")
+	if name == "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "Setting Name is required.")
+	}
+	level := enums.Level(c.Query("level"))
+	if level == "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "Level is required.")
+	} else if level != enums.Public && level != enums.Private && level != enums.Both {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, "Invalid Level.")
+	}
+	environment := c.Query("env")
+
+	// Find the domain.
+	domain, err := services.GetDomainById(domainID)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if domain.ID == 0 {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.DomainExists, "Domain does not exist.")
+	}
+
+	// Find the setting.
+	exists := false
+	for i := range domain.Settings {
+		if domain.Settings[i].Name == name && domain.Settings[i].Level == level && domain.Settings[i].Environment == environment {
+			exists = true
+			break
+		}
+	}
+	if !exists {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.DomainSettingExists, "Setting does not exist.")
+	}
+
+	// Delete the setting.
+	if err := services.DeleteDomainSetting(domain, name, level, environment, apputils.GetActor(c)); err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// sendSettings responds with the resolved settings, or with the explanation of each setting
+// when the explain query parameter is true.
+func sendSettings(c *fiber.Ctx, globalSettings *[]models.GlobalSetting, appSettings *[]models.AppSetting, domainSettings *[]models.DomainSetting, definitions *[]models.SettingDefinition, level enums.Level) error {
+	if c.QueryBool("explain") {
+		response, err := toSettingsExplanation(globalSettings, appSettings, domainSettings, definitions, level)
+		if err != nil {
+			return errorutil.Response(c, fiber.StatusInternalServerError, errors.DomainSettings, err.Error())
+		}
+
+		return c.JSON(response)
+	}
+
+	response, err := toSettingsResponse(globalSettings, appSettings, domainSettings, definitions, level)
 	if err != nil {
 		return errorutil.Response(c, fiber.StatusInternalServerError, errors.DomainSettings, err.Error())
 	}
@@ -86,9 +284,37 @@ func GetSettingsByDomainID(c *fiber.Ctx, level enums.Level) error {
 	return c.JSON(response)
 }
 
-// toSettingsResponse converts an array of DomainSetting structs to a dynamic JSON object.
-func toSettingsResponse(appSettings *[]models.AppSetting, domainSettings *[]models.DomainSetting) (map[string]interface{}, error) {
-	response := make(map[string]interface{})
+// Layers of a resolved setting, from the lowest to the highest precedence.
+const (
+	defaultLayer = "default"
+	globalLayer  = "global"
+	appLayer     = "app"
+	domainLayer  = "domain"
+)
+
+// toSettingsResponse converts the global, app and domain settings to a dynamic JSON object.
+// Definitions without a stored setting are added with their default value, when it is visible on the level.
+func toSettingsResponse(globalSettings *[]models.GlobalSetting, appSettings *[]models.AppSetting, domainSettings *[]models.DomainSetting, definitions *[]models.SettingDefinition, level enums.Level) (map[string]interface{}, error) {
+	explanations, err := toSettingsExplanation(globalSettings, appSettings, domainSettings, definitions, level)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make(map[string]interface{}, len(explanations))
+	for name := range explanations {
+		response[name] = explanations[name].Value
+	}
+
+	return response, nil
+}
+
+// toSettingsExplanation resolves the global, app and domain settings, and explains for each setting
+// which layer won and which values it shadowed. The layers are applied in the order global, app and domain,
+// where the domain settings follow the settings of its canonical domain and the settings of an environment
+// follow the base settings. A later value overrides an earlier one, unless the earlier value is locked.
+// Definitions without a stored setting are added with their default value, when it is visible on the level.
+func toSettingsExplanation(globalSettings *[]models.GlobalSetting, appSettings *[]models.AppSetting, domainSettings *[]models.DomainSetting, definitions *[]models.SettingDefinition, level enums.Level) (map[string]*responses.SettingExplanation, error) {
+	response := make(map[string]*responses.SettingExplanation)
 
 	convertSetting := func(name, valueType, value string) (interface{}, error) {
 		switch enums.ValueType(valueType) {
@@ -98,6 +324,8 @@ func toSettingsResponse(appSettings *[]models.AppSetting, domainSettings *[]mode
 			return strconv.ParseFloat(value, 64)
 		case enums.String:
 			return value, nil
+		case enums.Secret:
+			return secrets.Mask, nil
 		case enums.Bool:
 			return strconv.ParseBool(value)
 		case enums.Date:
@@ -113,23 +341,95 @@ func toSettingsResponse(appSettings *[]models.AppSetting, domainSettings *[]mode
 		}
 	}
 
-	for i := range *appSettings {
-		setting := (*appSettings)[i]
-		value, err := convertSetting(setting.Name, string(setting.ValueType), setting.Value)
+	applySetting := func(name string, layer responses.SettingLayer, value string) error {
+		converted, err := convertSetting(name, layer.ValueType, value)
 		if err != nil {
-			return nil, fmt.Errorf("error converting setting %s: %v", setting.Name, err)
+			return fmt.Errorf("error converting setting %s: %v", name, err)
+		}
+		layer.Value = converted
+
+		explanation, exists := response[name]
+		if !exists {
+			response[name] = &responses.SettingExplanation{
+				SettingLayer: layer,
+				Shadowed:     make([]responses.SettingLayer, 0),
+				Ignored:      make([]responses.SettingLayer, 0),
+			}
+		} else if explanation.Locked {
+			explanation.Ignored = append(explanation.Ignored, layer)
+		} else {
+			explanation.Shadowed = append(explanation.Shadowed, explanation.SettingLayer)
+			explanation.SettingLayer = layer
+		}
+
+		return nil
+	}
+
+	if globalSettings != nil {
+		for i := range *globalSettings {
+			setting := (*globalSettings)[i]
+			if err := applySetting(setting.Name, responses.SettingLayer{
+				Layer:     globalLayer,
+				ValueType: string(setting.ValueType),
+				Locked:    setting.Locked,
+			}, setting.Value); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if appSettings != nil {
+		for i := range *appSettings {
+			setting := (*appSettings)[i]
+			if err := applySetting(setting.Name, responses.SettingLayer{
+				Layer:       appLayer,
+				Environment: setting.Environment,
+				ValueType:   string(setting.ValueType),
+				Locked:      setting.Locked,
+			}, setting.Value); err != nil {
+				return nil, err
+			}
 		}
-		response[setting.Name] = value
 	}
 
 	if domainSettings != nil {
 		for i := range *domainSettings {
 			setting := (*domainSettings)[i]
-			value, err := convertSetting(setting.Name, string(setting.ValueType), setting.Value)
+			domainID := setting.DomainID
+			if err := applySetting(setting.Name, responses.SettingLayer{
+				Layer:       domainLayer,
+				DomainID:    &domainID,
+				Environment: setting.Environment,
+				ValueType:   string(setting.ValueType),
+				Locked:      setting.Locked,
+			}, setting.Value); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if definitions != nil {
+		for i := range *definitions {
+			definition := (*definitions)[i]
+			if _, exists := response[definition.Name]; exists || !definition.Default.Valid {
+				continue
+			}
+			if definition.Level != enums.Both && definition.Level != level {
+				continue
+			}
+			value, err := convertSetting(definition.Name, string(definition.ValueType), definition.Default.String)
 			if err != nil {
-				return nil, fmt.Errorf("error converting setting %s: %v", setting.Name, err)
+				return nil, fmt.Errorf("error converting default of setting %s: %v", definition.Name, err)
+			}
+			response[definition.Name] = &responses.SettingExplanation{
+				SettingLayer: responses.SettingLayer{
+					Layer:     defaultLayer,
+					Value:     value,
+					ValueType: string(definition.ValueType),
+				},
+				Shadowed: make([]responses.SettingLayer, 0),
+				Ignored:  make([]responses.SettingLayer, 0),
 			}
-			response[setting.Name] = value
 		}
 	}
 
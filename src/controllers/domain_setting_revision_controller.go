@@ -0,0 +1,172 @@
+package controllers
+
+import (
+	"api-app/main/src/database"
+	"api-app/main/src/dto/responses"
+	"api-app/main/src/errors"
+	"api-app/main/src/models"
+	"api-app/main/src/services"
+	apputils "api-app/main/src/utils"
+	errorutil "github.com/ArnoldPMolenaar/api-utils/errors"
+	"github.com/ArnoldPMolenaar/api-utils/pagination"
+	"github.com/ArnoldPMolenaar/api-utils/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetDomainSettingRevisions function fetches the paginated setting revisions of a domain.
+func GetDomainSettingRevisions(c *fiber.Ctx) error {
+	// Get the domainID parameter from the URL.
+	domainIDParam := c.Params("id")
+	if domainIDParam == "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "Domain ID is required.")
+	}
+	domainID, err := utils.StringToUint(domainIDParam)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, "Invalid Domain ID.")
+	}
+
+	revisions := make([]models.DomainSettingRevision, 0)
+	values := c.Request().URI().QueryArgs()
+	allowedColumns := map[string]bool{
+		"revision":   true,
+		"actor":      true,
+		"created_at": true,
+	}
+
+	queryFunc := pagination.Query(values, allowedColumns)
+	sortFunc := pagination.Sort(values, allowedColumns)
+	page := c.QueryInt("page", 1)
+	if page < 1 {
+		page = 1
+	}
+	limit := c.QueryInt("limit", 10)
+	if limit < 1 {
+		limit = 10
+	}
+	offset := pagination.Offset(page, limit)
+
+	query := database.Pg.Scopes(queryFunc, sortFunc).Where("domain_id = ?", domainID)
+	if c.Query("sortBy") == "" {
+		query = query.Order("revision DESC")
+	}
+	if db := query.Limit(limit).Offset(offset).Find(&revisions); db.Error != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, db.Error.Error())
+	}
+
+	total := int64(0)
+	database.Pg.Scopes(queryFunc).Model(&models.DomainSettingRevision{}).Where("domain_id = ?", domainID).Count(&total)
+	pageCount := pagination.Count(int(total), limit)
+
+	// Get the names of the secret settings.
+	secretNames, err := services.GetSecretSettingNamesByDomainID(domainID)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	settingRevisions := make([]responses.SettingRevision, len(revisions))
+	for i := range revisions {
+		settingRevisions[i].SetDomainSettingRevision(&revisions[i], secretNames)
+	}
+
+	paginationModel := pagination.CreatePaginationModel(limit, page, pageCount, int(total), settingRevisions)
+
+	return c.Status(fiber.StatusOK).JSON(paginationModel)
+}
+
+// GetDomainSettingRevision function fetches a single setting revision of a domain.
+func GetDomainSettingRevision(c *fiber.Ctx) error {
+	// Get the domainID and revision parameters from the URL.
+	domainIDParam := c.Params("id")
+	if domainIDParam == "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "Domain ID is required.")
+	}
+	domainID, err := utils.StringToUint(domainIDParam)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, "Invalid Domain ID.")
+	}
+	revisionParam := c.Params("rev")
+	if revisionParam == "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "Revision is required.")
+	}
+	revision, err := utils.StringToUint(revisionParam)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, "Invalid Revision.")
+	}
+
+	// Get the revision.
+	domainSettingRevision, err := services.GetDomainSettingRevisionById(domainID, revision)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if domainSettingRevision.ID == 0 {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.DomainRevisionExists, "Revision does not exist.")
+	}
+
+	// Get the names of the secret settings.
+	secretNames, err := services.GetSecretSettingNamesByDomainID(domainID)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	// Return the revision.
+	response := responses.SettingRevision{}
+	response.SetDomainSettingRevision(domainSettingRevision, secretNames)
+
+	return c.JSON(response)
+}
+
+// RollbackDomainSettings func to restore the settings of a domain to a revision.
+func RollbackDomainSettings(c *fiber.Ctx) error {
+	// Get the domainID and revision parameters from the URL.
+	domainIDParam := c.Params("id")
+	if domainIDParam == "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "Domain ID is required.")
+	}
+	domainID, err := utils.StringToUint(domainIDParam)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, "Invalid Domain ID.")
+	}
+	revisionParam := c.Params("rev")
+	if revisionParam == "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "Revision is required.")
+	}
+	revision, err := utils.StringToUint(revisionParam)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, "Invalid Revision.")
+	}
+
+	// Find the domain.
+	domain, err := services.GetDomainById(domainID)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if domain.ID == 0 {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.DomainExists, "Domain does not exist.")
+	}
+
+	// Find the revision.
+	domainSettingRevision, err := services.GetDomainSettingRevisionById(domainID, revision)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if domainSettingRevision.ID == 0 {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.DomainRevisionExists, "Revision does not exist.")
+	}
+
+	// Rollback the settings.
+	newRevision, err := services.RollbackDomainSettings(domain, domainSettingRevision, apputils.GetActor(c))
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if newRevision == nil {
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+
+	// Get the names of the secret settings.
+	secretNames, err := services.GetSecretSettingNamesByDomainID(domainID)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	// Return the new revision.
+	response := responses.SettingRevision{}
+	response.SetDomainSettingRevision(newRevision, secretNames)
+
+	return c.JSON(response)
+}
@@ -0,0 +1,230 @@
+package controllers
+
+import (
+	"api-app/main/src/dto/requests"
+	"api-app/main/src/dto/responses"
+	"api-app/main/src/errors"
+	"api-app/main/src/models"
+	"api-app/main/src/services"
+	apputils "api-app/main/src/utils"
+	"fmt"
+	errorutil "github.com/ArnoldPMolenaar/api-utils/errors"
+	"github.com/ArnoldPMolenaar/api-utils/utils"
+	"github.com/gofiber/fiber/v2"
+	"regexp"
+	"strings"
+)
+
+// environmentNamePattern is the pattern an environment name has to match, so it is safe in URLs and cache keys.
+var environmentNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// GetEnvironments function fetches all environments of an app.
+func GetEnvironments(c *fiber.Ctx) error {
+	// Find the app.
+	app, err := findEnvironmentApp(c)
+	if err != nil || app == nil {
+		return err
+	}
+
+	// Get the environments.
+	environments, err := services.GetEnvironmentsByAppID(app.ID)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	// Return the environments.
+	response := make([]responses.Environment, len(*environments))
+	for i := range *environments {
+		response[i].SetEnvironment(&(*environments)[i])
+	}
+
+	return c.JSON(response)
+}
+
+// CreateEnvironment func to create an environment for an app.
+func CreateEnvironment(c *fiber.Ctx) error {
+	// Parse the request.
+	request := requests.CreateEnvironment{}
+	if err := c.BodyParser(&request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.BodyParse, err.Error())
+	}
+
+	// Validate environment fields.
+	validate := utils.NewValidator()
+	if err := validate.Struct(request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.Validator, utils.ValidatorErrors(err))
+	}
+	if !environmentNamePattern.MatchString(request.Name) {
+		return errorutil.Response(c, fiber.StatusBadRequest, errors.Environment, "Name can only contain lowercase letters, digits and dashes, and can not start with a dash.")
+	}
+
+	// Find the app.
+	app, err := findEnvironmentApp(c)
+	if err != nil || app == nil {
+		return err
+	}
+
+	// Check if the environment exists.
+	if available, err := services.IsEnvironmentAvailable(app.ID, request.Name); err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if available {
+		return errorutil.Response(c, fiber.StatusBadRequest, errors.EnvironmentAvailable, "Environment already available.")
+	}
+
+	// Create the environment.
+	environment, err := services.CreateEnvironment(app.ID, &request, apputils.GetActor(c))
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	// Return the environment.
+	response := responses.Environment{}
+	response.SetEnvironment(environment)
+
+	return c.JSON(response)
+}
+
+// DeleteEnvironment func to delete an environment of an app, with the settings of the app and its domains in it.
+func DeleteEnvironment(c *fiber.Ctx) error {
+	// Find the app and the environment.
+	app, err := findEnvironmentApp(c)
+	if err != nil || app == nil {
+		return err
+	}
+	environment, err := findEnvironment(c, app.ID, c.Params("env"))
+	if err != nil || environment == nil {
+		return err
+	}
+
+	// Delete the environment.
+	if err := services.DeleteEnvironment(app, environment, apputils.GetActor(c)); err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetPromotion function to get the changes a promotion of an environment to another would make,
+// so they can be reviewed before the promotion is applied.
+func GetPromotion(c *fiber.Ctx) error {
+	// Find the app and the environments.
+	app, from, to, err := findPromotion(c)
+	if err != nil || app == nil {
+		return err
+	}
+
+	// Get the changes.
+	promotion, err := services.GetPromotion(app, from.Name, to.Name)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	return c.JSON(promotion)
+}
+
+// PromoteEnvironment func to replace the settings of an environment with the settings of another,
+// for the app and each of its domains. The applied changes are returned.
+func PromoteEnvironment(c *fiber.Ctx) error {
+	// Find the app and the environments.
+	app, from, to, err := findPromotion(c)
+	if err != nil || app == nil {
+		return err
+	}
+
+	// Apply the promotion.
+	promotion, err := services.PromoteEnvironment(app, from.Name, to.Name, apputils.GetActor(c))
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	return c.JSON(promotion)
+}
+
+// findEnvironmentApp gets the app with the ID parameter from the URL.
+// When the app can not be found, the error response is written and a nil app is returned.
+func findEnvironmentApp(c *fiber.Ctx) (*models.App, error) {
+	// Get the appID parameter from the URL.
+	appIDParam := c.Params("id")
+	if appIDParam == "" {
+		return nil, errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "App ID is required.")
+	}
+	appID, err := utils.StringToUint(appIDParam)
+	if err != nil {
+		return nil, errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, "Invalid App ID.")
+	}
+
+	app, err := services.GetAppById(appID)
+	if err != nil {
+		return nil, errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if app.ID == 0 {
+		return nil, errorutil.Response(c, fiber.StatusNotFound, errors.AppExists, "App does not exist.")
+	}
+
+	return app, nil
+}
+
+// findEnvironment gets the environment of an app by its name.
+// When the environment can not be found, the error response is written and a nil environment is returned.
+func findEnvironment(c *fiber.Ctx, appID uint, name string) (*models.Environment, error) {
+	if name == "" {
+		return nil, errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "Environment is required.")
+	}
+
+	environment, err := services.GetEnvironmentByName(appID, name)
+	if err != nil {
+		return nil, errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if environment.ID == 0 {
+		return nil, errorutil.Response(c, fiber.StatusNotFound, errors.EnvironmentNotFound, "Environment does not exist.")
+	}
+
+	return environment, nil
+}
+
+// findPromotion gets the app and the source and target environments of a promotion from the URL.
+// When any of them can not be found, the error response is written and a nil app is returned.
+func findPromotion(c *fiber.Ctx) (*models.App, *models.Environment, *models.Environment, error) {
+	app, err := findEnvironmentApp(c)
+	if err != nil || app == nil {
+		return nil, nil, nil, err
+	}
+	from, err := findEnvironment(c, app.ID, c.Params("env"))
+	if err != nil || from == nil {
+		return nil, nil, nil, err
+	}
+	to, err := findEnvironment(c, app.ID, c.Params("target"))
+	if err != nil || to == nil {
+		return nil, nil, nil, err
+	}
+	if from.ID == to.ID {
+		return nil, nil, nil, errorutil.Response(c, fiber.StatusBadRequest, errors.Environment, "An environment can not be promoted to itself.")
+	}
+
+	return app, from, to, nil
+}
+
+// validateSettingEnvironments checks if every environment the settings override exists for the app.
+// If any validation errors occur, it returns a comma-separated string of error messages.
+// If the string is empty, it means all validations passed.
+func validateSettingEnvironments(appID uint, environments []string) (string, error) {
+	var validateErrors []string
+
+	existing := make(map[string]bool)
+	if appID != 0 {
+		found, err := services.GetEnvironmentsByAppID(appID)
+		if err != nil {
+			return "", err
+		}
+		for i := range *found {
+			existing[(*found)[i].Name] = true
+		}
+	}
+
+	for _, environment := range environments {
+		if environment != "" && !existing[environment] {
+			existing[environment] = true
+			validateErrors = append(validateErrors, fmt.Sprintf("Unknown environment %s", environment))
+		}
+	}
+
+	return strings.Join(validateErrors, ", "), nil
+}
@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"api-app/main/src/dto/requests"
+	"api-app/main/src/dto/responses"
+	"api-app/main/src/enums"
+	"api-app/main/src/errors"
+	"api-app/main/src/services"
+	apputils "api-app/main/src/utils"
+	errorutil "github.com/ArnoldPMolenaar/api-utils/errors"
+	"github.com/ArnoldPMolenaar/api-utils/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetGlobalSettings function fetches all global settings.
+func GetGlobalSettings(c *fiber.Ctx) error {
+	// Get the global settings.
+	settings, err := services.GetAllGlobalSettings()
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	// Return the global settings.
+	response := make([]responses.GlobalSetting, len(*settings))
+	for i := range *settings {
+		response[i].SetGlobalSetting(&(*settings)[i])
+	}
+
+	return c.JSON(response)
+}
+
+// UpsertGlobalSetting func to create or update a single global setting.
+func UpsertGlobalSetting(c *fiber.Ctx) error {
+	// Get the name from the URL.
+	name := c.Params("name")
+	if name == "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "Setting Name is required.")
+	}
+
+	// Parse the request.
+	request := requests.UpsertSetting{}
+	if err := c.BodyParser(&request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.BodyParse, err.Error())
+	}
+
+	// Validate setting fields.
+	validate := utils.NewValidator()
+	if err := validate.Struct(request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.Validator, utils.ValidatorErrors(err))
+	}
+	if validationError := validateSettingValue(name, request.ValueType, request.Value); validationError != "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errors.GlobalSettings, validationError)
+	}
+	if validationError := validateSecretLevel(name, request.ValueType, request.Level); validationError != "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errors.GlobalSettings, validationError)
+	}
+
+	// Create or update the setting.
+	globalSetting, err := services.UpsertGlobalSetting(&requests.GlobalSetting{
+		Name:      name,
+		Level:     request.Level,
+		Value:     request.Value,
+		ValueType: request.ValueType,
+		Locked:    request.Locked,
+	}, apputils.GetActor(c))
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	// Return the setting.
+	response := responses.GlobalSetting{}
+	response.SetGlobalSetting(globalSetting)
+
+	return c.JSON(response)
+}
+
+// DeleteGlobalSetting func to delete a single global setting.
+func DeleteGlobalSetting(c *fiber.Ctx) error {
+	// Get the name and level from the URL.
+	name := c.Params("name")
+	if name == "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "Setting Name is required.")
+	}
+	level := enums.Level(c.Query("level"))
+	if level == "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "Level is required.")
+	} else if level != enums.Public && level != enums.Private && level != enums.Both {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, "Invalid Level.")
+	}
+
+	// Find the setting.
+	globalSetting, err := services.GetGlobalSetting(name, level)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if globalSetting.Name == "" {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.GlobalSettingExists, "Setting does not exist.")
+	}
+
+	// Delete the setting.
+	if err := services.DeleteGlobalSetting(globalSetting, apputils.GetActor(c)); err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
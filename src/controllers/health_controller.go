@@ -0,0 +1,30 @@
+package controllers
+
+import (
+	"api-app/main/src/cache"
+	"api-app/main/src/database"
+	"api-app/main/src/dto/responses"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetHealth function to get the health of the database and the cache.
+// An unavailable cache degrades the API, an unavailable database makes it unhealthy.
+func GetHealth(c *fiber.Ctx) error {
+	response := responses.Health{Status: "ok", Cache: cache.Available()}
+
+	// Check the database.
+	if db, err := database.Pg.DB(); err == nil {
+		response.Database = db.PingContext(c.Context()) == nil
+	}
+
+	// Return the health.
+	switch {
+	case !response.Database:
+		response.Status = "unhealthy"
+		return c.Status(fiber.StatusServiceUnavailable).JSON(response)
+	case !response.Cache:
+		response.Status = "degraded"
+	}
+
+	return c.JSON(response)
+}
@@ -0,0 +1,190 @@
+package controllers
+
+import (
+	"api-app/main/src/dto/requests"
+	"api-app/main/src/dto/responses"
+	"api-app/main/src/enums"
+	"api-app/main/src/errors"
+	"api-app/main/src/models"
+	"api-app/main/src/services"
+	apputils "api-app/main/src/utils"
+	"fmt"
+	errorutil "github.com/ArnoldPMolenaar/api-utils/errors"
+	"github.com/ArnoldPMolenaar/api-utils/utils"
+	"github.com/gofiber/fiber/v2"
+	"slices"
+	"strings"
+)
+
+// GetPrincipals function fetches all principals with their grants.
+func GetPrincipals(c *fiber.Ctx) error {
+	// Get the principals.
+	principals, err := services.GetPrincipals()
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	// Return the principals.
+	response := make([]responses.Principal, len(*principals))
+	for i := range *principals {
+		response[i].SetPrincipal(&(*principals)[i])
+	}
+
+	return c.JSON(response)
+}
+
+// GetPrincipal function fetches a principal with its grants.
+func GetPrincipal(c *fiber.Ctx) error {
+	// Get the principal.
+	principal, err := findPrincipal(c)
+	if err != nil || principal == nil {
+		return err
+	}
+
+	// Return the principal.
+	response := responses.Principal{}
+	response.SetPrincipal(principal)
+
+	return c.JSON(response)
+}
+
+// CreatePrincipal func to create a principal, the response holds its key, which can not be retrieved again.
+func CreatePrincipal(c *fiber.Ctx) error {
+	// Parse the request.
+	request := requests.CreatePrincipal{}
+	if err := c.BodyParser(&request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.BodyParse, err.Error())
+	}
+
+	// Validate principal fields.
+	validate := utils.NewValidator()
+	if err := validate.Struct(request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.Validator, utils.ValidatorErrors(err))
+	}
+
+	// Check if the principal exists.
+	if available, err := services.IsPrincipalAvailable(request.Name); err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if available {
+		return errorutil.Response(c, fiber.StatusBadRequest, errors.PrincipalAvailable, "Principal already available.")
+	}
+
+	// Create the principal.
+	principal, key, err := services.CreatePrincipal(&request, apputils.GetActor(c))
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	// Return the principal with its key.
+	response := responses.CreatedPrincipal{Key: key}
+	response.SetPrincipal(principal)
+
+	return c.JSON(response)
+}
+
+// DeletePrincipal func to delete a principal with its grants.
+func DeletePrincipal(c *fiber.Ctx) error {
+	// Find the principal.
+	principal, err := findPrincipal(c)
+	if err != nil || principal == nil {
+		return err
+	}
+
+	// Delete the principal.
+	if err := services.DeletePrincipal(principal, apputils.GetActor(c)); err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// UpdateGrants func to replace the actions a principal may perform on an app.
+func UpdateGrants(c *fiber.Ctx) error {
+	// Get the appID parameter from the URL.
+	appIDParam := c.Params("appId")
+	if appIDParam == "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "App ID is required.")
+	}
+	appID, err := utils.StringToUint(appIDParam)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, "Invalid App ID.")
+	}
+
+	// Parse the request.
+	request := requests.UpdateGrants{}
+	if err := c.BodyParser(&request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.BodyParse, err.Error())
+	}
+
+	// Validate grant fields.
+	validate := utils.NewValidator()
+	if err := validate.Struct(request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.Validator, utils.ValidatorErrors(err))
+	}
+	if validationErrors := validateGrantActions(request.Actions); validationErrors != "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errors.Principal, validationErrors)
+	}
+
+	// Find the principal and the app.
+	principal, err := findPrincipal(c)
+	if err != nil || principal == nil {
+		return err
+	}
+	if app, err := services.GetAppById(appID); err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if app.ID == 0 {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.AppExists, "App does not exist.")
+	}
+
+	// Replace the grants.
+	principal, err = services.UpdateGrants(principal, appID, request.Actions, apputils.GetActor(c))
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	// Return the principal.
+	response := responses.Principal{}
+	response.SetPrincipal(principal)
+
+	return c.JSON(response)
+}
+
+// findPrincipal gets the principal of the id parameter.
+// When the principal can not be found, the error response is written and a nil principal is returned.
+func findPrincipal(c *fiber.Ctx) (*models.Principal, error) {
+	// Get the principalID parameter from the URL.
+	principalIDParam := c.Params("id")
+	if principalIDParam == "" {
+		return nil, errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "Principal ID is required.")
+	}
+	principalID, err := utils.StringToUint(principalIDParam)
+	if err != nil {
+		return nil, errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, "Invalid Principal ID.")
+	}
+
+	principal, err := services.GetPrincipalById(principalID)
+	if err != nil {
+		return nil, errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if principal.ID == 0 {
+		return nil, errorutil.Response(c, fiber.StatusNotFound, errors.PrincipalExists, "Principal does not exist.")
+	}
+
+	return principal, nil
+}
+
+// validateGrantActions validates the actions of a grant.
+func validateGrantActions(actions []string) string {
+	var validateErrors []string
+	seen := make(map[string]bool, len(actions))
+
+	for _, action := range actions {
+		if !slices.Contains(enums.Permissions, enums.Permission(action)) {
+			validateErrors = append(validateErrors, fmt.Sprintf("Unknown action %s", action))
+		} else if seen[action] {
+			validateErrors = append(validateErrors, fmt.Sprintf("Duplicate action %s", action))
+		}
+		seen[action] = true
+	}
+
+	return strings.Join(validateErrors, ", ")
+}
@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"api-app/main/src/dto/responses"
+	"api-app/main/src/enums"
+	"api-app/main/src/errors"
+	"api-app/main/src/services"
+	apputils "api-app/main/src/utils"
+	errorutil "github.com/ArnoldPMolenaar/api-utils/errors"
+	"github.com/gofiber/fiber/v2"
+	"net"
+)
+
+// ResolveHost function to resolve the app, domain and settings of a host.
+// The host can contain a port, which is ignored.
+func ResolveHost(c *fiber.Ctx, level enums.Level) error {
+	// Get the host parameter from the query string.
+	host := c.Query("host")
+	if host == "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "Host is required.")
+	}
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+	host, err := apputils.NormalizeDomain(host)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, err.Error())
+	} else if apputils.IsWildcardDomain(host) {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, "Host can not be a wildcard.")
+	}
+
+	// Resolve the host in the environment when it is given, or get it from the cache.
+	environment := c.Query("env")
+	response, err := services.LoadResolvedHost(host, level, environment, func() (*responses.ResolvedHost, error) {
+		// Find the domain of the host.
+		domain, err := services.ResolveHost(host, level == enums.Public && services.HideUnverifiedDomains())
+		if err != nil {
+			return nil, err
+		} else if domain.ID == 0 {
+			return nil, services.ErrHostNotFound
+		}
+
+		// Get the app settings.
+		appSettings, err := services.GetAppSettingsByAppID(domain.AppID, level, environment)
+		if err != nil {
+			return nil, err
+		} else if appSettings == nil {
+			return nil, services.ErrHostNotFound
+		}
+
+		// Get the domain settings.
+		domainSettings, err := services.GetDomainSettingsByDomainID(domain.ID, level, environment)
+		if err != nil {
+			return nil, err
+		} else if domainSettings == nil {
+			return nil, services.ErrHostNotFound
+		}
+
+		// Get the global settings.
+		globalSettings, err := services.GetGlobalSettings(level)
+		if err != nil {
+			return nil, err
+		}
+
+		// Get the setting definitions.
+		definitions, err := services.GetSettingDefinitionsByAppID(domain.AppID)
+		if err != nil {
+			return nil, err
+		}
+
+		settings, err := toSettingsResponse(globalSettings, appSettings, domainSettings, definitions, level)
+		if err != nil {
+			return nil, err
+		}
+
+		response := &responses.ResolvedHost{}
+		response.SetResolvedHost(domain, settings)
+
+		return response, nil
+	})
+	if err == services.ErrAmbiguousHost {
+		return errorutil.Response(c, fiber.StatusConflict, errors.HostAmbiguous, "Host matches the domains of multiple apps.")
+	} else if err == services.ErrHostNotFound && environment != "" {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.EnvironmentNotFound, "Domain or environment does not exist.")
+	} else if err == services.ErrHostNotFound {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.DomainNotFound, "Domain does not exist.")
+	} else if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	// Return the resolved host.
+	return c.JSON(response)
+}
@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"api-app/main/src/dto/responses"
+	"api-app/main/src/enums"
+	"api-app/main/src/errors"
+	"api-app/main/src/services"
+	apputils "api-app/main/src/utils"
+	errorutil "github.com/ArnoldPMolenaar/api-utils/errors"
+	"github.com/ArnoldPMolenaar/api-utils/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// RevealAppSetting func to reveal the value of a secret setting of an app.
+func RevealAppSetting(c *fiber.Ctx) error {
+	// Get the ID, name, level and environment from the URL.
+	appIDParam := c.Params("id")
+	if appIDParam == "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "App ID is required.")
+	}
+	appID, err := utils.StringToUint(appIDParam)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, "Invalid App ID.")
+	}
+	name := c.Params("name")
+	if name == "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "Setting Name is required.")
+	}
+	level := enums.Level(c.Query("level", enums.Private.String()))
+	environment := c.Query("env")
+
+	// Find the app.
+	app, err := services.GetAppById(appID)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if app.ID == 0 {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.AppExists, "App does not exist.")
+	}
+
+	// Find the setting.
+	for i := range app.Settings {
+		setting := &app.Settings[i]
+		if setting.Name != name || setting.Level != level || setting.Environment != environment {
+			continue
+		}
+
+		return sendRevealedSetting(c, setting.ValueType, setting.Value, &services.SecretRevealedEvent{
+			AppID:       app.ID,
+			Name:        setting.Name,
+			Level:       setting.Level.String(),
+			Environment: setting.Environment,
+		})
+	}
+
+	return errorutil.Response(c, fiber.StatusNotFound, errors.AppSettingExists, "Setting does not exist.")
+}
+
+// RevealDomainSetting func to reveal the value of a secret setting of a domain.
+func RevealDomainSetting(c *fiber.Ctx) error {
+	// Get the ID, name, level and environment from the URL.
+	domainIDParam := c.Params("id")
+	if domainIDParam == "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "Domain ID is required.")
+	}
+	domainID, err := utils.StringToUint(domainIDParam)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, "Invalid Domain ID.")
+	}
+	name := c.Params("name")
+	if name == "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "Setting Name is required.")
+	}
+	level := enums.Level(c.Query("level", enums.Private.String()))
+	environment := c.Query("env")
+
+	// Find the domain.
+	domain, err := services.GetDomainById(domainID)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if domain.ID == 0 {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.DomainExists, "Domain does not exist.")
+	}
+
+	// Find the setting.
+	for i := range domain.Settings {
+		setting := &domain.Settings[i]
+		if setting.Name != name || setting.Level != level || setting.Environment != environment {
+			continue
+		}
+
+		return sendRevealedSetting(c, setting.ValueType, setting.Value, &services.SecretRevealedEvent{
+			AppID:       domain.AppID,
+			DomainID:    &domain.ID,
+			Name:        setting.Name,
+			Level:       setting.Level.String(),
+			Environment: setting.Environment,
+		})
+	}
+
+	return errorutil.Response(c, fiber.StatusNotFound, errors.DomainSettingExists, "Setting does not exist.")
+}
+
+// RevealGlobalSetting func to reveal the value of a secret global setting.
+func RevealGlobalSetting(c *fiber.Ctx) error {
+	// Get the name and level from the URL.
+	name := c.Params("name")
+	if name == "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "Setting Name is required.")
+	}
+	level := enums.Level(c.Query("level", enums.Private.String()))
+
+	// Find the setting.
+	setting, err := services.GetGlobalSetting(name, level)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if setting.Name == "" {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.GlobalSettingExists, "Setting does not exist.")
+	}
+
+	return sendRevealedSetting(c, setting.ValueType, setting.Value, &services.SecretRevealedEvent{
+		Name:  setting.Name,
+		Level: setting.Level.String(),
+	})
+}
+
+// sendRevealedSetting decrypts the value of a secret setting and sends it.
+func sendRevealedSetting(c *fiber.Ctx, valueType enums.ValueType, value string, event *services.SecretRevealedEvent) error {
+	if valueType != enums.Secret {
+		return errorutil.Response(c, fiber.StatusBadRequest, errors.SettingNotSecret, "Setting is not a secret.")
+	}
+
+	// Decrypt the value, the reveal is audited.
+	plaintext, err := services.RevealSecret(value, event, apputils.GetActor(c))
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errors.SecretReveal, err.Error())
+	}
+
+	return c.JSON(responses.RevealedSetting{
+		Name:        event.Name,
+		Level:       event.Level,
+		Environment: event.Environment,
+		Value:       plaintext,
+	})
+}
@@ -0,0 +1,389 @@
+package controllers
+
+import (
+	"api-app/main/src/dto/requests"
+	"api-app/main/src/dto/responses"
+	"api-app/main/src/enums"
+	"api-app/main/src/errors"
+	"api-app/main/src/models"
+	"api-app/main/src/services"
+	apputils "api-app/main/src/utils"
+	"encoding/json"
+	"fmt"
+	errorutil "github.com/ArnoldPMolenaar/api-utils/errors"
+	"github.com/ArnoldPMolenaar/api-utils/utils"
+	"github.com/gofiber/fiber/v2"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GetSettingDefinitions function fetches all setting definitions of an app.
+func GetSettingDefinitions(c *fiber.Ctx) error {
+	// Get the appID parameter from the URL.
+	appIDParam := c.Params("id")
+	if appIDParam == "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "App ID is required.")
+	}
+	appID, err := utils.StringToUint(appIDParam)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, "Invalid App ID.")
+	}
+
+	// Get the definitions.
+	definitions, err := services.GetSettingDefinitionsByAppID(appID)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	// Return the definitions.
+	response := make([]responses.SettingDefinition, len(*definitions))
+	for i := range *definitions {
+		response[i].SetSettingDefinition(&(*definitions)[i])
+	}
+
+	return c.JSON(response)
+}
+
+// CreateSettingDefinition func to create a setting definition for an app.
+func CreateSettingDefinition(c *fiber.Ctx) error {
+	// Get the appID parameter from the URL.
+	appIDParam := c.Params("id")
+	if appIDParam == "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "App ID is required.")
+	}
+	appID, err := utils.StringToUint(appIDParam)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, "Invalid App ID.")
+	}
+
+	// Parse the request.
+	request := requests.CreateSettingDefinition{}
+	if err := c.BodyParser(&request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.BodyParse, err.Error())
+	}
+
+	// Validate definition fields.
+	validate := utils.NewValidator()
+	if err := validate.Struct(request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.Validator, utils.ValidatorErrors(err))
+	}
+	definition, err := services.BuildSettingDefinition(appID, request.Name, &request.UpdateSettingDefinition)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.BodyParse, err.Error())
+	}
+	if validationErrors := validateSettingDefinition(definition); validationErrors != "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errors.SettingDefinition, validationErrors)
+	}
+
+	// Find the app.
+	app, err := services.GetAppById(appID)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if app.ID == 0 {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.AppExists, "App does not exist.")
+	}
+
+	// Check if the definition exists.
+	if oldDefinition, err := services.GetSettingDefinitionByName(appID, request.Name); err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if oldDefinition.ID != 0 {
+		return errorutil.Response(c, fiber.StatusBadRequest, errors.SettingDefinitionAvailable, "Setting definition already available.")
+	}
+
+	// Create the definition.
+	definition, err = services.CreateSettingDefinition(app, definition, apputils.GetActor(c))
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	// Return the definition.
+	response := responses.SettingDefinition{}
+	response.SetSettingDefinition(definition)
+
+	return c.JSON(response)
+}
+
+// UpdateSettingDefinition func to update a setting definition of an app.
+func UpdateSettingDefinition(c *fiber.Ctx) error {
+	// Get the appID and name parameters from the URL.
+	appIDParam := c.Params("id")
+	if appIDParam == "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "App ID is required.")
+	}
+	appID, err := utils.StringToUint(appIDParam)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, "Invalid App ID.")
+	}
+	name := c.Params("name")
+	if name == "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "Setting Name is required.")
+	}
+
+	// Parse the request.
+	request := requests.UpdateSettingDefinition{}
+	if err := c.BodyParser(&request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.BodyParse, err.Error())
+	}
+
+	// Validate definition fields.
+	validate := utils.NewValidator()
+	if err := validate.Struct(request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.Validator, utils.ValidatorErrors(err))
+	}
+	definition, err := services.BuildSettingDefinition(appID, name, &request)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.BodyParse, err.Error())
+	}
+	if validationErrors := validateSettingDefinition(definition); validationErrors != "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errors.SettingDefinition, validationErrors)
+	}
+
+	// Find the app.
+	app, err := services.GetAppById(appID)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if app.ID == 0 {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.AppExists, "App does not exist.")
+	}
+
+	// Find the definition.
+	oldDefinition, err := services.GetSettingDefinitionByName(appID, name)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if oldDefinition.ID == 0 {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.SettingDefinitionExists, "Setting definition does not exist.")
+	}
+
+	// Update the definition.
+	definition, err = services.UpdateSettingDefinition(app, oldDefinition, definition, apputils.GetActor(c))
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	// Return the definition.
+	response := responses.SettingDefinition{}
+	response.SetSettingDefinition(definition)
+
+	return c.JSON(response)
+}
+
+// DeleteSettingDefinition func to delete a setting definition of an app.
+func DeleteSettingDefinition(c *fiber.Ctx) error {
+	// Get the appID and name parameters from the URL.
+	appIDParam := c.Params("id")
+	if appIDParam == "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "App ID is required.")
+	}
+	appID, err := utils.StringToUint(appIDParam)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, "Invalid App ID.")
+	}
+	name := c.Params("name")
+	if name == "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "Setting Name is required.")
+	}
+
+	// Find the app.
+	app, err := services.GetAppById(appID)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if app.ID == 0 {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.AppExists, "App does not exist.")
+	}
+
+	// Find the definition.
+	definition, err := services.GetSettingDefinitionByName(appID, name)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if definition.ID == 0 {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.SettingDefinitionExists, "Setting definition does not exist.")
+	}
+
+	// Delete the definition.
+	if err := services.DeleteSettingDefinition(app, definition, apputils.GetActor(c)); err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// validateSettingDefinition validates the constraints and default of a SettingDefinition.
+// If any validation errors occur, it returns a comma-separated string of error messages.
+// If the string is empty, it means all validations passed.
+func validateSettingDefinition(definition *models.SettingDefinition) string {
+	var validateErrors []string
+	isNumber := definition.ValueType == enums.Int || definition.ValueType == enums.Float
+
+	switch definition.ValueType {
+	case enums.Int, enums.Float, enums.String, enums.Bool, enums.Date, enums.DateTime, enums.JSON, enums.Secret:
+	default:
+		validateErrors = append(validateErrors, fmt.Sprintf("Unknown ValueType for definition %s", definition.Name))
+	}
+
+	// The default and allowed values of a definition are not encrypted, so a secret can not have them.
+	if definition.ValueType == enums.Secret {
+		if validationError := validateSecretLevel(definition.Name, definition.ValueType.String(), definition.Level.String()); validationError != "" {
+			validateErrors = append(validateErrors, validationError)
+		}
+		if definition.Default.Valid || definition.AllowedValues.Valid {
+			validateErrors = append(validateErrors, fmt.Sprintf("Default and allowed values are not allowed for secret definition %s", definition.Name))
+		}
+	}
+
+	if (definition.Min.Valid || definition.Max.Valid) && !isNumber {
+		validateErrors = append(validateErrors, fmt.Sprintf("Min and max are only allowed for int and float definition %s", definition.Name))
+	}
+	if definition.Min.Valid && definition.Max.Valid && definition.Min.Float64 > definition.Max.Float64 {
+		validateErrors = append(validateErrors, fmt.Sprintf("Min is greater than max for definition %s", definition.Name))
+	}
+	if definition.Regex.Valid {
+		if _, err := regexp.Compile(definition.Regex.String); err != nil {
+			validateErrors = append(validateErrors, fmt.Sprintf("Invalid regex for definition %s", definition.Name))
+		}
+	}
+	if definition.JSONSchema.Valid {
+		if definition.ValueType != enums.JSON {
+			validateErrors = append(validateErrors, fmt.Sprintf("JSON Schema is only allowed for json definition %s", definition.Name))
+		} else if _, err := apputils.CompileJSONSchema(definition.JSONSchema.String); err != nil {
+			validateErrors = append(validateErrors, fmt.Sprintf("Invalid JSON Schema for definition %s", definition.Name))
+		}
+	}
+	if definition.AllowedValues.Valid {
+		var allowedValues []string
+		_ = json.Unmarshal([]byte(definition.AllowedValues.String), &allowedValues)
+		for _, allowedValue := range allowedValues {
+			if validationError := validateSettingValue(definition.Name, definition.ValueType.String(), allowedValue); validationError != "" {
+				validateErrors = append(validateErrors, fmt.Sprintf("Invalid allowed value %s for definition %s", allowedValue, definition.Name))
+			}
+		}
+	}
+
+	if len(validateErrors) == 0 && definition.Default.Valid {
+		if validationError := validateSettingValue(definition.Name, definition.ValueType.String(), definition.Default.String); validationError != "" {
+			validateErrors = append(validateErrors, fmt.Sprintf("Invalid default value for definition %s", definition.Name))
+		} else {
+			validateErrors = append(validateErrors, checkSettingDefinition(definition, definition.Level.String(), definition.Default.String, definition.ValueType.String())...)
+		}
+	}
+
+	return strings.Join(validateErrors, ", ")
+}
+
+// validateSettingValue checks if the value of a setting is valid based on its ValueType.
+// It returns an error message, or an empty string when the value is valid.
+func validateSettingValue(name, valueType, value string) string {
+	switch enums.ValueType(valueType) {
+	case enums.Int:
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Sprintf("Invalid int value for setting %s", name)
+		}
+	case enums.Float:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Sprintf("Invalid float value for setting %s", name)
+		}
+	case enums.String, enums.Secret:
+		// No validation needed for string and secret types.
+	case enums.Bool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Sprintf("Invalid bool value for setting %s", name)
+		}
+	case enums.Date:
+		if _, err := time.Parse(time.DateOnly, value); err != nil {
+			return fmt.Sprintf("Invalid date value for setting %s", name)
+		}
+	case enums.DateTime:
+		if _, err := time.Parse(time.DateTime, value); err != nil {
+			return fmt.Sprintf("Invalid datetime value for setting %s", name)
+		}
+	case enums.JSON:
+		var js json.RawMessage
+		if err := json.Unmarshal([]byte(value), &js); err != nil {
+			return fmt.Sprintf("Invalid JSON value for setting %s", name)
+		}
+	default:
+		return fmt.Sprintf("Unknown ValueType for setting %s", name)
+	}
+
+	return ""
+}
+
+// validateSecretLevel checks if a secret setting is private, so it is never served by the public endpoints.
+// It returns an error message, or an empty string when the level is valid.
+func validateSecretLevel(name, valueType, level string) string {
+	if enums.ValueType(valueType) == enums.Secret && enums.Level(level) != enums.Private {
+		return fmt.Sprintf("Secret setting %s can only be private", name)
+	}
+
+	return ""
+}
+
+// validateSettingAgainstDefinitions checks a setting against the definitions of its app.
+// When the app has no definitions, every setting is allowed.
+func validateSettingAgainstDefinitions(name, level, value, valueType string, definitions *[]models.SettingDefinition) []string {
+	if definitions == nil || len(*definitions) == 0 {
+		return nil
+	}
+
+	definition := findSettingDefinition(definitions, name)
+	if definition == nil {
+		return []string{fmt.Sprintf("Unknown setting %s", name)}
+	}
+
+	return checkSettingDefinition(definition, level, value, valueType)
+}
+
+// checkSettingDefinition checks if a setting satisfies the constraints of its definition.
+func checkSettingDefinition(definition *models.SettingDefinition, level, value, valueType string) []string {
+	var validateErrors []string
+
+	if definition.ValueType.String() != valueType {
+		return []string{fmt.Sprintf("Invalid ValueType for setting %s, expected %s", definition.Name, definition.ValueType)}
+	}
+	if definition.Level.String() != level {
+		validateErrors = append(validateErrors, fmt.Sprintf("Invalid Level for setting %s, expected %s", definition.Name, definition.Level))
+	}
+
+	if definition.Min.Valid || definition.Max.Valid {
+		if number, err := strconv.ParseFloat(value, 64); err == nil {
+			if definition.Min.Valid && number < definition.Min.Float64 {
+				validateErrors = append(validateErrors, fmt.Sprintf("Value of setting %s is lower than %v", definition.Name, definition.Min.Float64))
+			}
+			if definition.Max.Valid && number > definition.Max.Float64 {
+				validateErrors = append(validateErrors, fmt.Sprintf("Value of setting %s is higher than %v", definition.Name, definition.Max.Float64))
+			}
+		}
+	}
+	if definition.Regex.Valid {
+		if matched, err := regexp.MatchString(definition.Regex.String, value); err != nil || !matched {
+			validateErrors = append(validateErrors, fmt.Sprintf("Value of setting %s does not match %s", definition.Name, definition.Regex.String))
+		}
+	}
+	if definition.AllowedValues.Valid {
+		var allowedValues []string
+		_ = json.Unmarshal([]byte(definition.AllowedValues.String), &allowedValues)
+		if !slices.Contains(allowedValues, value) {
+			validateErrors = append(validateErrors, fmt.Sprintf("Value of setting %s is not one of %s", definition.Name, strings.Join(allowedValues, ", ")))
+		}
+	}
+	if definition.JSONSchema.Valid {
+		if err := apputils.ValidateJSONSchema(definition.JSONSchema.String, value); err != nil {
+			validateErrors = append(validateErrors, fmt.Sprintf("Value of setting %s does not match the JSON Schema", definition.Name))
+		}
+	}
+
+	return validateErrors
+}
+
+// findSettingDefinition returns the definition with the given name, or nil when it does not exist.
+func findSettingDefinition(definitions *[]models.SettingDefinition, name string) *models.SettingDefinition {
+	for i := range *definitions {
+		if (*definitions)[i].Name == name {
+			return &(*definitions)[i]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,206 @@
+package controllers
+
+import (
+	"api-app/main/src/enums"
+	"api-app/main/src/errors"
+	"api-app/main/src/services"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	errorutil "github.com/ArnoldPMolenaar/api-utils/errors"
+	"github.com/ArnoldPMolenaar/api-utils/utils"
+	"github.com/gofiber/fiber/v2"
+	"time"
+)
+
+// streamKeepAlive is the interval in which a comment is sent to keep idle streams open.
+const streamKeepAlive = 15 * time.Second
+
+// StreamSettingsByAppID function to stream the settings of an app with Server-Sent Events.
+// The resolved settings are sent on connect and after every change.
+func StreamSettingsByAppID(c *fiber.Ctx, level enums.Level) error {
+	// Get the appID parameter from the URL.
+	appIDParam := c.Params("id")
+	if appIDParam == "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "App ID is required.")
+	}
+	appID, err := utils.StringToUint(appIDParam)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, "Invalid App ID.")
+	}
+	environment := c.Query("env")
+
+	resolve := func() (map[string]interface{}, error) {
+		appSettings, err := services.GetAppSettingsByAppID(appID, level, environment)
+		if err != nil || appSettings == nil {
+			return nil, err
+		}
+		globalSettings, err := services.GetGlobalSettings(level)
+		if err != nil {
+			return nil, err
+		}
+		definitions, err := services.GetSettingDefinitionsByAppID(appID)
+		if err != nil {
+			return nil, err
+		}
+
+		return toSettingsResponse(globalSettings, appSettings, nil, definitions, level)
+	}
+
+	// Subscribe before the settings are resolved, so a change in between is not missed.
+	events, unsubscribe := services.SubscribeSettingsChanged(func(event services.SettingsChangedEvent) bool {
+		// A change without an app is a change of the global settings.
+		return event.AppID == 0 || (event.AppID == appID && event.DomainID == 0)
+	})
+
+	// Resolve the settings once, so errors are returned before the stream starts.
+	settings, err := resolve()
+	if err != nil || settings == nil {
+		unsubscribe()
+	}
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errors.AppSettings, err.Error())
+	} else if settings == nil && environment != "" {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.EnvironmentNotFound, "App or environment does not exist.")
+	} else if settings == nil {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.AppNotFound, "App does not exist.")
+	}
+
+	return streamSettings(c, settings, resolve, events, unsubscribe)
+}
+
+// StreamSettingsByDomainID function to stream the settings of a domain with Server-Sent Events.
+// The resolved settings are sent on connect and after every change of the domain or its app.
+func StreamSettingsByDomainID(c *fiber.Ctx, level enums.Level) error {
+	// Get the domainID parameter from the URL.
+	domainIDParam := c.Params("id")
+	if domainIDParam == "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "Domain ID is required.")
+	}
+	domainID, err := utils.StringToUint(domainIDParam)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, "Invalid Domain ID.")
+	}
+
+	// Get the domain, for its app and canonical domain.
+	domain, err := services.GetDomainById(domainID)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if domain.ID == 0 {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.DomainNotFound, "Domain does not exist.")
+	}
+	appID := domain.AppID
+
+	// Check if the domain is hidden.
+	if hidden, err := services.IsDomainHidden(domainID, level); err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if hidden {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.DomainNotFound, "Domain does not exist.")
+	}
+
+	environment := c.Query("env")
+	resolve := func() (map[string]interface{}, error) {
+		appSettings, err := services.GetAppSettingsByAppID(appID, level, environment)
+		if err != nil {
+			return nil, err
+		}
+		domainSettings, err := services.GetDomainSettingsByDomainID(domainID, level, environment)
+		if err != nil || domainSettings == nil {
+			return nil, err
+		}
+		globalSettings, err := services.GetGlobalSettings(level)
+		if err != nil {
+			return nil, err
+		}
+		definitions, err := services.GetSettingDefinitionsByAppID(appID)
+		if err != nil {
+			return nil, err
+		}
+
+		return toSettingsResponse(globalSettings, appSettings, domainSettings, definitions, level)
+	}
+
+	// Subscribe before the settings are resolved, so a change in between is not missed.
+	events, unsubscribe := services.SubscribeSettingsChanged(func(event services.SettingsChangedEvent) bool {
+		// An alias domain inherits the settings of its canonical domain.
+		isCanonical := domain.AliasOfID != nil && event.DomainID == *domain.AliasOfID
+		return event.AppID == 0 || (event.AppID == appID && (event.DomainID == 0 || event.DomainID == domainID || isCanonical))
+	})
+
+	// Resolve the settings once, so errors are returned before the stream starts.
+	settings, err := resolve()
+	if err != nil || settings == nil {
+		unsubscribe()
+	}
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errors.DomainSettings, err.Error())
+	} else if settings == nil && environment != "" {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.EnvironmentNotFound, "Domain or environment does not exist.")
+	} else if settings == nil {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.DomainNotFound, "Domain does not exist.")
+	}
+
+	return streamSettings(c, settings, resolve, events, unsubscribe)
+}
+
+// streamSettings writes the settings as Server-Sent Events until the client disconnects, and then unsubscribes.
+// The settings are resolved again for every change of the subscription.
+func streamSettings(c *fiber.Ctx, settings map[string]interface{}, resolve func() (map[string]interface{}, error), events <-chan services.SettingsChangedEvent, unsubscribe func()) error {
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+
+		if err := writeSettingsEvent(w, settings); err != nil {
+			return
+		}
+
+		keepAlive := time.NewTicker(streamKeepAlive)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case <-events:
+				settings, err := resolve()
+				if err != nil {
+					if _, err := fmt.Fprintf(w, "event: error\ndata: %q\n\n", err.Error()); err != nil {
+						return
+					}
+				} else if settings == nil {
+					// The app or domain no longer exists.
+					return
+				} else if err := writeSettingsEvent(w, settings); err != nil {
+					return
+				}
+			case <-keepAlive.C:
+				if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+					return
+				}
+			}
+
+			// A failed flush means the client has disconnected.
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+
+	return nil
+}
+
+// writeSettingsEvent writes the settings as a single Server-Sent Event and flushes it.
+func writeSettingsEvent(w *bufio.Writer, settings map[string]interface{}) error {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(w, "event: settings\ndata: %s\n\n", data); err != nil {
+		return err
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"api-app/main/src/dto/responses"
+	"api-app/main/src/models"
+	"api-app/main/src/services"
+	errorutil "github.com/ArnoldPMolenaar/api-utils/errors"
+	"github.com/ArnoldPMolenaar/api-utils/pagination"
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetTrash function fetches the deleted apps and domains from the database.
+func GetTrash(c *fiber.Ctx) error {
+	items := make([]models.TrashItem, 0)
+	values := c.Request().URI().QueryArgs()
+	allowedColumns := map[string]bool{
+		"type":           true,
+		"id":             true,
+		"name":           true,
+		"app_id":         true,
+		"deleted_at":     true,
+		"deletion_batch": true,
+	}
+
+	queryFunc := pagination.Query(values, allowedColumns)
+	sortFunc := pagination.Sort(values, allowedColumns)
+	page := c.QueryInt("page", 1)
+	if page < 1 {
+		page = 1
+	}
+	limit := c.QueryInt("limit", 10)
+	if limit < 1 {
+		limit = 10
+	}
+	offset := pagination.Offset(page, limit)
+
+	query := services.TrashQuery().Scopes(queryFunc, sortFunc)
+	if c.Query("sortBy") == "" {
+		query = query.Order("deleted_at DESC")
+	}
+
+	db := query.Limit(limit).Offset(offset).Find(&items)
+	if db.Error != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, db.Error.Error())
+	}
+
+	total := int64(0)
+	services.TrashQuery().Scopes(queryFunc).Count(&total)
+	pageCount := pagination.Count(int(total), limit)
+
+	trashItems := make([]responses.TrashItem, len(items))
+	for i := range items {
+		trashItem := responses.TrashItem{}
+		trashItem.SetTrashItem(&items[i])
+		trashItems[i] = trashItem
+	}
+
+	paginationModel := pagination.CreatePaginationModel(limit, page, pageCount, int(total), trashItems)
+
+	return c.Status(fiber.StatusOK).JSON(paginationModel)
+}
@@ -0,0 +1,268 @@
+package controllers
+
+import (
+	"api-app/main/src/database"
+	"api-app/main/src/dto/requests"
+	"api-app/main/src/dto/responses"
+	"api-app/main/src/enums"
+	"api-app/main/src/errors"
+	"api-app/main/src/models"
+	"api-app/main/src/services"
+	apputils "api-app/main/src/utils"
+	"fmt"
+	errorutil "github.com/ArnoldPMolenaar/api-utils/errors"
+	"github.com/ArnoldPMolenaar/api-utils/pagination"
+	"github.com/ArnoldPMolenaar/api-utils/utils"
+	"github.com/gofiber/fiber/v2"
+	"slices"
+	"strings"
+)
+
+// GetWebhooks function fetches all webhooks of an app.
+func GetWebhooks(c *fiber.Ctx) error {
+	// Get the appID parameter from the URL.
+	appIDParam := c.Params("id")
+	if appIDParam == "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "App ID is required.")
+	}
+	appID, err := utils.StringToUint(appIDParam)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, "Invalid App ID.")
+	}
+
+	// Get the webhooks.
+	webhooks, err := services.GetWebhooksByAppID(appID)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	// Return the webhooks.
+	response := make([]responses.Webhook, len(*webhooks))
+	for i := range *webhooks {
+		response[i].SetWebhook(&(*webhooks)[i])
+	}
+
+	return c.JSON(response)
+}
+
+// GetWebhook function fetches a webhook of an app.
+func GetWebhook(c *fiber.Ctx) error {
+	// Get the webhook.
+	webhook, err := findWebhook(c)
+	if err != nil || webhook == nil {
+		return err
+	}
+
+	// Return the webhook.
+	response := responses.Webhook{}
+	response.SetWebhook(webhook)
+
+	return c.JSON(response)
+}
+
+// CreateWebhook func to create a webhook for an app.
+func CreateWebhook(c *fiber.Ctx) error {
+	// Get the appID parameter from the URL.
+	appIDParam := c.Params("id")
+	if appIDParam == "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "App ID is required.")
+	}
+	appID, err := utils.StringToUint(appIDParam)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, "Invalid App ID.")
+	}
+
+	// Parse the request.
+	request := requests.CreateWebhook{}
+	if err := c.BodyParser(&request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.BodyParse, err.Error())
+	}
+
+	// Validate webhook fields.
+	validate := utils.NewValidator()
+	if err := validate.Struct(request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.Validator, utils.ValidatorErrors(err))
+	}
+	if validationErrors := validateWebhookEvents(request.Events); validationErrors != "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errors.Webhook, validationErrors)
+	}
+	if err := services.ValidateWebhookURL(c.UserContext(), request.URL); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errors.Webhook, err.Error())
+	}
+
+	// Find the app.
+	app, err := services.GetAppById(appID)
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if app.ID == 0 {
+		return errorutil.Response(c, fiber.StatusNotFound, errors.AppExists, "App does not exist.")
+	}
+
+	// Create the webhook.
+	webhook, err := services.CreateWebhook(app.ID, &request, apputils.GetActor(c))
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	// Return the webhook.
+	response := responses.Webhook{}
+	response.SetWebhook(webhook)
+
+	return c.JSON(response)
+}
+
+// UpdateWebhook func to update a webhook of an app.
+func UpdateWebhook(c *fiber.Ctx) error {
+	// Parse the request.
+	request := requests.UpdateWebhook{}
+	if err := c.BodyParser(&request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.BodyParse, err.Error())
+	}
+
+	// Validate webhook fields.
+	validate := utils.NewValidator()
+	if err := validate.Struct(request); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errorutil.Validator, utils.ValidatorErrors(err))
+	}
+	if validationErrors := validateWebhookEvents(request.Events); validationErrors != "" {
+		return errorutil.Response(c, fiber.StatusBadRequest, errors.Webhook, validationErrors)
+	}
+	if err := services.ValidateWebhookURL(c.UserContext(), request.URL); err != nil {
+		return errorutil.Response(c, fiber.StatusBadRequest, errors.Webhook, err.Error())
+	}
+
+	// Find the webhook.
+	webhook, err := findWebhook(c)
+	if err != nil || webhook == nil {
+		return err
+	}
+
+	// Update the webhook.
+	webhook, err = services.UpdateWebhook(webhook, &request, apputils.GetActor(c))
+	if err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	// Return the webhook.
+	response := responses.Webhook{}
+	response.SetWebhook(webhook)
+
+	return c.JSON(response)
+}
+
+// DeleteWebhook func to delete a webhook of an app.
+func DeleteWebhook(c *fiber.Ctx) error {
+	// Find the webhook.
+	webhook, err := findWebhook(c)
+	if err != nil || webhook == nil {
+		return err
+	}
+
+	// Delete the webhook.
+	if err := services.DeleteWebhook(webhook, apputils.GetActor(c)); err != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetWebhookDeliveries function fetches the paginated delivery log of a webhook.
+func GetWebhookDeliveries(c *fiber.Ctx) error {
+	// Find the webhook.
+	webhook, err := findWebhook(c)
+	if err != nil || webhook == nil {
+		return err
+	}
+
+	deliveries := make([]models.WebhookDelivery, 0)
+	values := c.Request().URI().QueryArgs()
+	allowedColumns := map[string]bool{
+		"id":              true,
+		"event_id":        true,
+		"event_type":      true,
+		"attempts":        true,
+		"status_code":     true,
+		"next_attempt_at": true,
+		"delivered_at":    true,
+		"failed_at":       true,
+		"created_at":      true,
+	}
+
+	queryFunc := pagination.Query(values, allowedColumns)
+	sortFunc := pagination.Sort(values, allowedColumns)
+	page := c.QueryInt("page", 1)
+	if page < 1 {
+		page = 1
+	}
+	limit := c.QueryInt("limit", 10)
+	if limit < 1 {
+		limit = 10
+	}
+	offset := pagination.Offset(page, limit)
+
+	query := database.Pg.Scopes(queryFunc, sortFunc).Where("webhook_id = ?", webhook.ID)
+	if c.Query("sortBy") == "" {
+		query = query.Order("id DESC")
+	}
+	if db := query.Limit(limit).Offset(offset).Find(&deliveries); db.Error != nil {
+		return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, db.Error.Error())
+	}
+
+	total := int64(0)
+	database.Pg.Scopes(queryFunc).Model(&models.WebhookDelivery{}).Where("webhook_id = ?", webhook.ID).Count(&total)
+	pageCount := pagination.Count(int(total), limit)
+
+	webhookDeliveries := make([]responses.WebhookDelivery, len(deliveries))
+	for i := range deliveries {
+		webhookDeliveries[i].SetWebhookDelivery(&deliveries[i])
+	}
+
+	paginationModel := pagination.CreatePaginationModel(limit, page, pageCount, int(total), webhookDeliveries)
+
+	return c.Status(fiber.StatusOK).JSON(paginationModel)
+}
+
+// findWebhook gets the webhook with the app ID and webhook ID parameters from the URL.
+// When the webhook can not be found, the error response is written and a nil webhook is returned.
+func findWebhook(c *fiber.Ctx) (*models.Webhook, error) {
+	// Get the appID and webhookID parameters from the URL.
+	appIDParam := c.Params("id")
+	if appIDParam == "" {
+		return nil, errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "App ID is required.")
+	}
+	appID, err := utils.StringToUint(appIDParam)
+	if err != nil {
+		return nil, errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, "Invalid App ID.")
+	}
+	webhookIDParam := c.Params("wid")
+	if webhookIDParam == "" {
+		return nil, errorutil.Response(c, fiber.StatusBadRequest, errorutil.MissingRequiredParam, "Webhook ID is required.")
+	}
+	webhookID, err := utils.StringToUint(webhookIDParam)
+	if err != nil {
+		return nil, errorutil.Response(c, fiber.StatusBadRequest, errorutil.InvalidParam, "Invalid Webhook ID.")
+	}
+
+	webhook, err := services.GetWebhookById(appID, webhookID)
+	if err != nil {
+		return nil, errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+	} else if webhook.ID == 0 {
+		return nil, errorutil.Response(c, fiber.StatusNotFound, errors.WebhookExists, "Webhook does not exist.")
+	}
+
+	return webhook, nil
+}
+
+// validateWebhookEvents validates the subscribed event types of a webhook.
+// If any validation errors occur, it returns a comma-separated string of error messages.
+func validateWebhookEvents(events []string) string {
+	var validateErrors []string
+
+	for _, event := range events {
+		if !slices.Contains(enums.EventTypes, enums.EventType(event)) {
+			validateErrors = append(validateErrors, fmt.Sprintf("Unknown event type %s", event))
+		}
+	}
+
+	return strings.Join(validateErrors, ", ")
+}
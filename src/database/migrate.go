@@ -22,16 +22,93 @@ func Migrate(db *gorm.DB) error {
 	if tx := db.Exec(`DO $$ 
 	BEGIN 
 		IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'value_type') THEN 
-			CREATE TYPE value_type AS ENUM ('int', 'float', 'string', 'bool', 'date', 'datetime', 'json'); 
+			CREATE TYPE value_type AS ENUM ('int', 'float', 'string', 'bool', 'date', 'datetime', 'json', 'secret'); 
 		END IF; 
 	END $$;`); tx.Error != nil {
 		return tx.Error
 	}
 
-	err := db.AutoMigrate(&models.App{}, &models.AppSetting{}, &models.Domain{}, &models.DomainSetting{})
+	// Adds the secret value type to the value_type enum types that were created before secrets existed.
+	if tx := db.Exec(`ALTER TYPE value_type ADD VALUE IF NOT EXISTS 'secret'`); tx.Error != nil {
+		return tx.Error
+	}
+
+	// Adds the verification_status enum type to the database.
+	if tx := db.Exec(`DO $$ 
+	BEGIN 
+		IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'verification_status') THEN 
+			CREATE TYPE verification_status AS ENUM ('pending', 'verified', 'failed'); 
+		END IF; 
+	END $$;`); tx.Error != nil {
+		return tx.Error
+	}
+
+	// Drops the unique indexes that included deleted rows, they are replaced by partial indexes,
+	// so the name of a deleted app or domain can be taken again.
+	if tx := db.Exec(`DROP INDEX IF EXISTS idx_name; DROP INDEX IF EXISTS idx_app_name;`); tx.Error != nil {
+		return tx.Error
+	}
+
+	err := db.AutoMigrate(
+		&models.App{},
+		&models.AppSetting{},
+		&models.AppSettingRevision{},
+		&models.Domain{},
+		&models.DomainSetting{},
+		&models.DomainSettingRevision{},
+		&models.SettingDefinition{},
+		&models.OutboxEvent{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
+		&models.Environment{},
+		&models.GlobalSetting{},
+		&models.AuditEvent{},
+		&models.Principal{},
+		&models.Grant{},
+	)
 	if err != nil {
 		return err
 	}
 
+	// Adds the environment to the primary keys of the settings that were created before environments existed.
+	if tx := db.Exec(`DO $$
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM information_schema.key_column_usage
+			WHERE table_name = 'app_settings' AND constraint_name = 'app_settings_pkey' AND column_name = 'environment') THEN
+			ALTER TABLE app_settings DROP CONSTRAINT app_settings_pkey, ADD PRIMARY KEY (app_id, name, level, environment);
+		END IF;
+		IF NOT EXISTS (SELECT 1 FROM information_schema.key_column_usage
+			WHERE table_name = 'domain_settings' AND constraint_name = 'domain_settings_pkey' AND column_name = 'environment') THEN
+			ALTER TABLE domain_settings DROP CONSTRAINT domain_settings_pkey, ADD PRIMARY KEY (domain_id, name, level, environment);
+		END IF;
+	END $$;`); tx.Error != nil {
+		return tx.Error
+	}
+
+	// Makes the audit log append-only, by rejecting every update and delete of its rows.
+	if tx := db.Exec(`CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+	BEGIN
+		RAISE EXCEPTION 'audit events are append-only';
+	END $$ LANGUAGE plpgsql;
+	DROP TRIGGER IF EXISTS audit_events_append_only ON audit_events;
+	CREATE TRIGGER audit_events_append_only BEFORE UPDATE OR DELETE ON audit_events
+		FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();`); tx.Error != nil {
+		return tx.Error
+	}
+
+	// Generates a verification token for the domains that were created before verification existed.
+	if tx := db.Exec(`UPDATE domains SET verification_token = md5(random()::text || id::text) WHERE verification_token = ''`); tx.Error != nil {
+		return tx.Error
+	}
+
+	// Deletes the domains of the apps that were deleted before the domains were deleted with their app.
+	if tx := db.Exec(`UPDATE apps SET deletion_batch = md5(random()::text || id::text)::uuid WHERE deleted_at IS NOT NULL AND deletion_batch IS NULL`); tx.Error != nil {
+		return tx.Error
+	}
+	if tx := db.Exec(`UPDATE domains SET deleted_at = apps.deleted_at, deletion_batch = apps.deletion_batch
+		FROM apps WHERE apps.id = domains.app_id AND apps.deleted_at IS NOT NULL AND domains.deleted_at IS NULL`); tx.Error != nil {
+		return tx.Error
+	}
+
 	return nil
 }
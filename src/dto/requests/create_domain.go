@@ -6,5 +6,6 @@ type CreateDomain struct {
 	SSL       bool            `json:"ssl"`
 	Name      string          `json:"name" validate:"required"`
 	IpAddress string          `json:"ipAddress" validate:"required"`
+	AliasOfID *uint           `json:"aliasOfId"`
 	Settings  []DomainSetting `json:"settings" validate:"dive"`
 }
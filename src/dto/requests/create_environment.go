@@ -0,0 +1,6 @@
+package requests
+
+// CreateEnvironment struct for creating a new Environment.
+type CreateEnvironment struct {
+	Name string `json:"name" validate:"required,max=50"`
+}
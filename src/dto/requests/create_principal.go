@@ -0,0 +1,6 @@
+package requests
+
+// CreatePrincipal struct for creating a new Principal.
+type CreatePrincipal struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
@@ -0,0 +1,7 @@
+package requests
+
+// CreateSettingDefinition struct for creating a new SettingDefinition.
+type CreateSettingDefinition struct {
+	Name string `json:"name" validate:"required"`
+	UpdateSettingDefinition
+}
@@ -0,0 +1,6 @@
+package requests
+
+// CreateWebhook struct for creating a new Webhook.
+type CreateWebhook struct {
+	UpdateWebhook
+}
@@ -7,4 +7,8 @@ type DomainSetting struct {
 	Level     string `json:"level" validate:"required"`
 	Value     string `json:"value" validate:"required"`
 	ValueType string `json:"valueType" validate:"required"`
+	// Environment is the name of the environment the setting overrides, or empty for the base setting.
+	Environment string `json:"environment"`
+	// Locked stops the layers below, like the domains of an app, from overriding the setting.
+	Locked bool `json:"locked"`
 }
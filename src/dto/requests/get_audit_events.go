@@ -0,0 +1,15 @@
+package requests
+
+// GetAuditEvents struct holds the filters of the audit listing and export.
+// From and To are RFC 3339 timestamps, From is inclusive and To is exclusive.
+type GetAuditEvents struct {
+	Actor        string `query:"actor"`
+	ClaimedActor string `query:"claimedActor"`
+	RequestID    string `query:"requestId"`
+	Action       string `query:"action"`
+	Target       string `query:"target"`
+	AppID        *uint  `query:"appId"`
+	DomainID     *uint  `query:"domainId"`
+	From         string `query:"from"`
+	To           string `query:"to"`
+}
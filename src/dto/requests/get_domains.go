@@ -0,0 +1,13 @@
+package requests
+
+// GetDomains struct holds the filters of the domain listing.
+// Deleted is "false" for live domains, "true" for deleted domains or "all" for both.
+type GetDomains struct {
+	AppID              *uint  `query:"appId"`
+	SSL                *bool  `query:"ssl"`
+	TopLevel           string `query:"topLevel"`
+	SecondLevel        string `query:"secondLevel"`
+	IpAddress          string `query:"ipAddress"`
+	VerificationStatus string `query:"verificationStatus"`
+	Deleted            string `query:"deleted"`
+}
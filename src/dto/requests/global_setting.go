@@ -0,0 +1,11 @@
+package requests
+
+// GlobalSetting struct for creating or updating a GlobalSetting.
+type GlobalSetting struct {
+	Name      string `json:"name" validate:"required"`
+	Level     string `json:"level" validate:"required"`
+	Value     string `json:"value" validate:"required"`
+	ValueType string `json:"valueType" validate:"required"`
+	// Locked stops the apps and domains from overriding the setting.
+	Locked bool `json:"locked"`
+}
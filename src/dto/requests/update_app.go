@@ -1,11 +1,10 @@
 package requests
 
-import "time"
-
 // UpdateApp struct for updating a existing App.
+// The Version is only used when the request has no If-Match header.
 type UpdateApp struct {
-	Name      string            `json:"name" validate:"required"`
-	Settings  []AppSetting      `json:"settings" validate:"dive"`
-	Domains   []UpdateAppDomain `json:"domains" validate:"required,dive"`
-	UpdatedAt time.Time         `json:"updatedAt" validate:"required"`
+	Name     string            `json:"name" validate:"required"`
+	Settings []AppSetting      `json:"settings" validate:"dive"`
+	Domains  []UpdateAppDomain `json:"domains" validate:"required,dive"`
+	Version  uint              `json:"version"`
 }
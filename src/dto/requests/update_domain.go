@@ -1,12 +1,12 @@
 package requests
 
-import "time"
-
 // UpdateDomain struct for updating a existing Domain.
+// The Version is only used when the request has no If-Match header.
 type UpdateDomain struct {
 	SSL       bool            `json:"ssl"`
 	Name      string          `json:"name" validate:"required"`
 	IpAddress string          `json:"ipAddress" validate:"required"`
-	UpdatedAt time.Time       `json:"updatedAt" validate:"required"`
+	AliasOfID *uint           `json:"aliasOfId"`
+	Version   uint            `json:"version"`
 	Settings  []DomainSetting `json:"settings" validate:"dive"`
 }
@@ -0,0 +1,6 @@
+package requests
+
+// UpdateGrants struct for replacing the actions a Principal may perform on an App.
+type UpdateGrants struct {
+	Actions []string `json:"actions" validate:"dive,required"`
+}
@@ -0,0 +1,18 @@
+package requests
+
+import "encoding/json"
+
+// UpdateSettingDefinition struct for updating a existing SettingDefinition.
+type UpdateSettingDefinition struct {
+	Description   string          `json:"description"`
+	Level         string          `json:"level" validate:"required,oneof=public private both"`
+	ValueType     string          `json:"valueType" validate:"required"`
+	Default       *string         `json:"default"`
+	Min           *float64        `json:"min"`
+	Max           *float64        `json:"max"`
+	Regex         *string         `json:"regex"`
+	AllowedValues []string        `json:"allowedValues"`
+	JSONSchema    json.RawMessage `json:"jsonSchema"`
+	Required      bool            `json:"required"`
+	Owner         string          `json:"owner"`
+}
@@ -0,0 +1,9 @@
+package requests
+
+// UpdateWebhook struct for updating a existing Webhook.
+type UpdateWebhook struct {
+	URL    string   `json:"url" validate:"required,url"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret" validate:"required,min=16"`
+	Active bool     `json:"active"`
+}
@@ -0,0 +1,10 @@
+package requests
+
+// UpsertSetting struct for creating or updating a single AppSetting or DomainSetting.
+// The name of the setting is taken from the URL.
+type UpsertSetting struct {
+	Level     string `json:"level" validate:"required,oneof=public private both"`
+	Value     string `json:"value" validate:"required"`
+	ValueType string `json:"valueType" validate:"required"`
+	Locked    bool   `json:"locked"`
+}
@@ -6,29 +6,46 @@ import (
 )
 
 // App struct to hold app data.
+// The relations are only set when they are included.
 type App struct {
-	ID        uint         `json:"id"`
-	Name      string       `json:"name"`
-	CreatedAt time.Time    `json:"createdAt"`
-	UpdatedAt time.Time    `json:"updatedAt"`
-	Settings  []AppSetting `json:"settings"`
-	Domains   []AppDomain  `json:"domains"`
+	ID        uint          `json:"id"`
+	Name      string        `json:"name"`
+	Version   uint          `json:"version"`
+	CreatedAt time.Time     `json:"createdAt"`
+	UpdatedAt time.Time     `json:"updatedAt"`
+	Settings  *[]AppSetting `json:"settings,omitempty"`
+	Domains   *[]AppDomain  `json:"domains,omitempty"`
 }
 
-// SetApp method to set app data from models.App{}.
+// SetApp method to set app data from models.App{}, with its settings and domains.
 func (a *App) SetApp(app *models.App) {
+	a.SetExpandedApp(app, map[string]bool{"settings": true, "domains": true})
+}
+
+// SetExpandedApp method to set app data from models.App{}, with only the included relations.
+func (a *App) SetExpandedApp(app *models.App, includes map[string]bool) {
 	a.ID = app.ID
 	a.Name = app.Name
+	a.Version = app.Version
 	a.CreatedAt = app.CreatedAt
 	a.UpdatedAt = app.UpdatedAt
 
-	a.Settings = make([]AppSetting, len(app.Settings))
-	for i := range app.Settings {
-		a.Settings[i].SetAppSetting(&app.Settings[i])
+	if includes["settings"] {
+		settings := make([]AppSetting, len(app.Settings))
+		for i := range app.Settings {
+			settings[i].SetAppSetting(&app.Settings[i])
+		}
+		a.Settings = &settings
 	}
 
-	a.Domains = make([]AppDomain, len(app.Domains))
-	for i := range app.Domains {
-		a.Domains[i].SetDomain(&app.Domains[i])
+	if includes["domains"] {
+		domains := make([]AppDomain, len(app.Domains))
+		for i := range app.Domains {
+			domains[i].SetDomain(&app.Domains[i])
+			if includes["domains.settings"] {
+				domains[i].SetDomainSettings(app.Domains[i].Settings)
+			}
+		}
+		a.Domains = &domains
 	}
 }
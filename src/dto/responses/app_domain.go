@@ -6,17 +6,22 @@ import (
 )
 
 // AppDomain struct to handle domain response.
+// The settings are only set when they are included.
 type AppDomain struct {
-	ID          uint      `json:"id"`
-	AppID       uint      `json:"appId"`
-	SSL         bool      `json:"ssl"`
-	Name        string    `json:"name"`
-	Sub         *string   `json:"sub"`
-	SecondLevel string    `json:"secondLevel"`
-	TopLevel    string    `json:"topLevel"`
-	IpAddress   string    `json:"ipAddress"`
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
+	ID           uint             `json:"id"`
+	AppID        uint             `json:"appId"`
+	SSL          bool             `json:"ssl"`
+	Name         string           `json:"name"`
+	Sub          *string          `json:"sub"`
+	SecondLevel  string           `json:"secondLevel"`
+	TopLevel     string           `json:"topLevel"`
+	IpAddress    string           `json:"ipAddress"`
+	AliasOfID    *uint            `json:"aliasOfId"`
+	Version      uint             `json:"version"`
+	Verification Verification     `json:"verification"`
+	CreatedAt    time.Time        `json:"createdAt"`
+	UpdatedAt    time.Time        `json:"updatedAt"`
+	Settings     *[]DomainSetting `json:"settings,omitempty"`
 }
 
 // SetDomain method to set domain data from models.Domain{}.
@@ -31,6 +36,18 @@ func (d *AppDomain) SetDomain(domain *models.Domain) {
 	d.SecondLevel = domain.SecondLevel
 	d.TopLevel = domain.TopLevel
 	d.IpAddress = domain.IpAddress
+	d.AliasOfID = domain.AliasOfID
+	d.Version = domain.Version
+	d.Verification.SetVerification(domain)
 	d.CreatedAt = domain.CreatedAt
 	d.UpdatedAt = domain.UpdatedAt
 }
+
+// SetDomainSettings method to set the settings of the domain from []models.DomainSetting{}.
+func (d *AppDomain) SetDomainSettings(domainSettings []models.DomainSetting) {
+	settings := make([]DomainSetting, len(domainSettings))
+	for i := range domainSettings {
+		settings[i].SetDomainSetting(&domainSettings[i])
+	}
+	d.Settings = &settings
+}
@@ -1,19 +1,31 @@
 package responses
 
-import "api-app/main/src/models"
+import (
+	"api-app/main/src/enums"
+	"api-app/main/src/models"
+	"api-app/main/src/secrets"
+)
 
 // AppSetting struct to handle app setting response.
 type AppSetting struct {
-	Name      string `json:"name"`
-	Level     string `json:"level"`
-	Value     string `json:"value"`
-	ValueType string `json:"valueType"`
+	Name        string `json:"name"`
+	Level       string `json:"level"`
+	Value       string `json:"value"`
+	ValueType   string `json:"valueType"`
+	Environment string `json:"environment,omitempty"`
+	Locked      bool   `json:"locked"`
 }
 
 // SetAppSetting method to set app setting data from models.AppSetting{}.
+// The value of a secret setting is masked.
 func (as *AppSetting) SetAppSetting(appSetting *models.AppSetting) {
 	as.Name = appSetting.Name
 	as.Level = appSetting.Level.String()
 	as.Value = appSetting.Value
+	if appSetting.ValueType == enums.Secret {
+		as.Value = secrets.Mask
+	}
 	as.ValueType = appSetting.ValueType.String()
+	as.Environment = appSetting.Environment
+	as.Locked = appSetting.Locked
 }
@@ -0,0 +1,50 @@
+package responses
+
+import (
+	"api-app/main/src/models"
+	"encoding/json"
+	"time"
+)
+
+// AuditEvent struct to handle audit event response.
+type AuditEvent struct {
+	ID           uint            `json:"id"`
+	Actor        string          `json:"actor"`
+	ClaimedActor string          `json:"claimedActor"`
+	IpAddress    string          `json:"ipAddress"`
+	RequestID    string          `json:"requestId"`
+	Action       string          `json:"action"`
+	Target       string          `json:"target"`
+	AppID        *int64          `json:"appId"`
+	DomainID     *int64          `json:"domainId"`
+	Before       json.RawMessage `json:"before"`
+	After        json.RawMessage `json:"after"`
+	CreatedAt    time.Time       `json:"createdAt"`
+}
+
+// SetAuditEvent method to set audit event data from models.AuditEvent{}.
+// The values of secret settings in the before and after states are masked.
+func (ae *AuditEvent) SetAuditEvent(event *models.AuditEvent) {
+	ae.ID = event.ID
+	ae.Actor = event.Actor
+	ae.ClaimedActor = event.ClaimedActor
+	ae.IpAddress = event.IpAddress
+	ae.RequestID = event.RequestID
+	ae.Action = event.Action.String()
+	ae.Target = event.Target
+	if event.AppID.Valid {
+		ae.AppID = &event.AppID.Int64
+	}
+	if event.DomainID.Valid {
+		ae.DomainID = &event.DomainID.Int64
+	}
+	ae.Before = json.RawMessage("null")
+	if event.Before.Valid {
+		ae.Before = maskSecretValues(event.Before.String, nil)
+	}
+	ae.After = json.RawMessage("null")
+	if event.After.Valid {
+		ae.After = maskSecretValues(event.After.String, nil)
+	}
+	ae.CreatedAt = event.CreatedAt
+}
@@ -0,0 +1,27 @@
+package responses
+
+import "api-app/main/src/cache"
+
+// CacheStats struct for the cache statistics response.
+type CacheStats struct {
+	LocalHits    uint64           `json:"localHits"`
+	ValkeyHits   uint64           `json:"valkeyHits"`
+	Misses       uint64           `json:"misses"`
+	LocalEntries int              `json:"localEntries"`
+	Keys         map[string]int64 `json:"keys"`
+}
+
+// SetCacheStats method to set the cache statistics from the counters of this instance and the key counts in Valkey.
+func (cs *CacheStats) SetCacheStats(stats cache.Stats, keys map[string]int64) {
+	cs.LocalHits = stats.LocalHits
+	cs.ValkeyHits = stats.ValkeyHits
+	cs.Misses = stats.Misses
+	cs.LocalEntries = stats.LocalEntries
+	cs.Keys = keys
+}
+
+// CacheWarm struct for the cache warm-up response.
+type CacheWarm struct {
+	Apps    int `json:"apps"`
+	Domains int `json:"domains"`
+}
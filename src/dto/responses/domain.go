@@ -6,22 +6,31 @@ import (
 )
 
 // Domain struct to handle domain response.
+// The settings are only set when they are included.
 type Domain struct {
-	ID          uint            `json:"id"`
-	AppID       uint            `json:"appId"`
-	SSL         bool            `json:"ssl"`
-	Name        string          `json:"name"`
-	Sub         *string         `json:"sub"`
-	SecondLevel string          `json:"secondLevel"`
-	TopLevel    string          `json:"topLevel"`
-	IpAddress   string          `json:"ipAddress"`
-	CreatedAt   time.Time       `json:"createdAt"`
-	UpdatedAt   time.Time       `json:"updatedAt"`
-	Settings    []DomainSetting `json:"settings"`
+	ID           uint             `json:"id"`
+	AppID        uint             `json:"appId"`
+	SSL          bool             `json:"ssl"`
+	Name         string           `json:"name"`
+	Sub          *string          `json:"sub"`
+	SecondLevel  string           `json:"secondLevel"`
+	TopLevel     string           `json:"topLevel"`
+	IpAddress    string           `json:"ipAddress"`
+	AliasOfID    *uint            `json:"aliasOfId"`
+	Version      uint             `json:"version"`
+	Verification Verification     `json:"verification"`
+	CreatedAt    time.Time        `json:"createdAt"`
+	UpdatedAt    time.Time        `json:"updatedAt"`
+	Settings     *[]DomainSetting `json:"settings,omitempty"`
 }
 
-// SetDomain method to set domain data from models.Domain{}.
+// SetDomain method to set domain data from models.Domain{}, with its settings.
 func (d *Domain) SetDomain(domain *models.Domain) {
+	d.SetExpandedDomain(domain, map[string]bool{"settings": true})
+}
+
+// SetExpandedDomain method to set domain data from models.Domain{}, with only the included relations.
+func (d *Domain) SetExpandedDomain(domain *models.Domain, includes map[string]bool) {
 	d.ID = domain.ID
 	d.AppID = domain.AppID
 	d.SSL = domain.SSL
@@ -32,11 +41,17 @@ func (d *Domain) SetDomain(domain *models.Domain) {
 	d.SecondLevel = domain.SecondLevel
 	d.TopLevel = domain.TopLevel
 	d.IpAddress = domain.IpAddress
+	d.AliasOfID = domain.AliasOfID
+	d.Version = domain.Version
+	d.Verification.SetVerification(domain)
 	d.CreatedAt = domain.CreatedAt
 	d.UpdatedAt = domain.UpdatedAt
-	d.Settings = make([]DomainSetting, len(domain.Settings))
-	for i := range domain.Settings {
-		d.Settings[i] = DomainSetting{}
-		d.Settings[i].SetDomainSetting(&domain.Settings[i])
+
+	if includes["settings"] {
+		settings := make([]DomainSetting, len(domain.Settings))
+		for i := range domain.Settings {
+			settings[i].SetDomainSetting(&domain.Settings[i])
+		}
+		d.Settings = &settings
 	}
 }
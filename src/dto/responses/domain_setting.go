@@ -1,21 +1,33 @@
 package responses
 
-import "api-app/main/src/models"
+import (
+	"api-app/main/src/enums"
+	"api-app/main/src/models"
+	"api-app/main/src/secrets"
+)
 
 // DomainSetting struct to handle domain setting response.
 type DomainSetting struct {
-	DomainID  uint   `json:"domainId"`
-	Name      string `json:"name"`
-	Level     string `json:"level"`
-	Value     string `json:"value"`
-	ValueType string `json:"valueType"`
+	DomainID    uint   `json:"domainId"`
+	Name        string `json:"name"`
+	Level       string `json:"level"`
+	Value       string `json:"value"`
+	ValueType   string `json:"valueType"`
+	Environment string `json:"environment,omitempty"`
+	Locked      bool   `json:"locked"`
 }
 
 // SetDomainSetting method to set domain setting data from models.DomainSetting{}.
+// The value of a secret setting is masked.
 func (ds *DomainSetting) SetDomainSetting(domainSetting *models.DomainSetting) {
 	ds.DomainID = domainSetting.DomainID
 	ds.Name = domainSetting.Name
 	ds.Level = domainSetting.Level.String()
 	ds.Value = domainSetting.Value
+	if domainSetting.ValueType == enums.Secret {
+		ds.Value = secrets.Mask
+	}
 	ds.ValueType = domainSetting.ValueType.String()
+	ds.Environment = domainSetting.Environment
+	ds.Locked = domainSetting.Locked
 }
@@ -0,0 +1,24 @@
+package responses
+
+import (
+	"api-app/main/src/models"
+	"time"
+)
+
+// Environment struct to handle environment response.
+type Environment struct {
+	ID        uint      `json:"id"`
+	AppID     uint      `json:"appId"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// SetEnvironment method to set environment data from models.Environment{}.
+func (e *Environment) SetEnvironment(environment *models.Environment) {
+	e.ID = environment.ID
+	e.AppID = environment.AppID
+	e.Name = environment.Name
+	e.CreatedAt = environment.CreatedAt
+	e.UpdatedAt = environment.UpdatedAt
+}
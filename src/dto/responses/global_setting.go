@@ -0,0 +1,29 @@
+package responses
+
+import (
+	"api-app/main/src/enums"
+	"api-app/main/src/models"
+	"api-app/main/src/secrets"
+)
+
+// GlobalSetting struct to handle global setting response.
+type GlobalSetting struct {
+	Name      string `json:"name"`
+	Level     string `json:"level"`
+	Value     string `json:"value"`
+	ValueType string `json:"valueType"`
+	Locked    bool   `json:"locked"`
+}
+
+// SetGlobalSetting method to set global setting data from models.GlobalSetting{}.
+// The value of a secret setting is masked.
+func (gs *GlobalSetting) SetGlobalSetting(globalSetting *models.GlobalSetting) {
+	gs.Name = globalSetting.Name
+	gs.Level = globalSetting.Level.String()
+	gs.Value = globalSetting.Value
+	if globalSetting.ValueType == enums.Secret {
+		gs.Value = secrets.Mask
+	}
+	gs.ValueType = globalSetting.ValueType.String()
+	gs.Locked = globalSetting.Locked
+}
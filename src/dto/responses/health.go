@@ -0,0 +1,9 @@
+package responses
+
+// Health struct for the health response.
+// The status is "degraded" when the cache is unavailable and the settings are served from the database.
+type Health struct {
+	Status   string `json:"status"`
+	Database bool   `json:"database"`
+	Cache    bool   `json:"cache"`
+}
@@ -6,17 +6,39 @@ import (
 )
 
 // PaginatedApp struct to hold paginated app data.
+// The settings count and the relations are only set when they are included.
 type PaginatedApp struct {
-	ID        uint      `json:"id"`
-	Name      string    `json:"name"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+	ID            uint          `json:"id"`
+	Name          string        `json:"name"`
+	Version       uint          `json:"version"`
+	CreatedAt     time.Time     `json:"createdAt"`
+	UpdatedAt     time.Time     `json:"updatedAt"`
+	SettingsCount *int64        `json:"settingsCount,omitempty"`
+	Settings      *[]AppSetting `json:"settings,omitempty"`
+	Domains       *[]AppDomain  `json:"domains,omitempty"`
 }
 
 // SetPaginatedApp method to set app data from models.App{}.
 func (a *PaginatedApp) SetPaginatedApp(app *models.App) {
 	a.ID = app.ID
 	a.Name = app.Name
+	a.Version = app.Version
 	a.CreatedAt = app.CreatedAt
 	a.UpdatedAt = app.UpdatedAt
 }
+
+// SetExpandedPaginatedApp method to set app data from models.App{}, with only the included relations.
+// The settings count is read from the counts per app ID.
+func (a *PaginatedApp) SetExpandedPaginatedApp(app *models.App, includes map[string]bool, settingsCounts map[uint]int64) {
+	a.SetPaginatedApp(app)
+
+	if includes["settingsCount"] {
+		count := settingsCounts[app.ID]
+		a.SettingsCount = &count
+	}
+
+	expanded := App{}
+	expanded.SetExpandedApp(app, includes)
+	a.Settings = expanded.Settings
+	a.Domains = expanded.Domains
+}
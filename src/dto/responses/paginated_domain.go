@@ -0,0 +1,55 @@
+package responses
+
+import (
+	"api-app/main/src/models"
+	"time"
+)
+
+// PaginatedDomain struct to hold paginated domain data.
+// The settings are only set when they are included.
+type PaginatedDomain struct {
+	ID           uint             `json:"id"`
+	AppID        uint             `json:"appId"`
+	SSL          bool             `json:"ssl"`
+	Name         string           `json:"name"`
+	Sub          *string          `json:"sub"`
+	SecondLevel  string           `json:"secondLevel"`
+	TopLevel     string           `json:"topLevel"`
+	IpAddress    string           `json:"ipAddress"`
+	AliasOfID    *uint            `json:"aliasOfId"`
+	Version      uint             `json:"version"`
+	Verification Verification     `json:"verification"`
+	CreatedAt    time.Time        `json:"createdAt"`
+	UpdatedAt    time.Time        `json:"updatedAt"`
+	DeletedAt    *time.Time       `json:"deletedAt,omitempty"`
+	Settings     *[]DomainSetting `json:"settings,omitempty"`
+}
+
+// SetPaginatedDomain method to set domain data from models.Domain{}.
+func (d *PaginatedDomain) SetPaginatedDomain(domain *models.Domain, includeSettings bool) {
+	d.ID = domain.ID
+	d.AppID = domain.AppID
+	d.SSL = domain.SSL
+	d.Name = domain.Name
+	if domain.Sub.Valid {
+		d.Sub = &domain.Sub.String
+	}
+	d.SecondLevel = domain.SecondLevel
+	d.TopLevel = domain.TopLevel
+	d.IpAddress = domain.IpAddress
+	d.AliasOfID = domain.AliasOfID
+	d.Version = domain.Version
+	d.Verification.SetVerification(domain)
+	d.CreatedAt = domain.CreatedAt
+	d.UpdatedAt = domain.UpdatedAt
+	if domain.DeletedAt.Valid {
+		d.DeletedAt = &domain.DeletedAt.Time
+	}
+	if includeSettings {
+		settings := make([]DomainSetting, len(domain.Settings))
+		for i := range domain.Settings {
+			settings[i].SetDomainSetting(&domain.Settings[i])
+		}
+		d.Settings = &settings
+	}
+}
@@ -0,0 +1,45 @@
+package responses
+
+import (
+	"api-app/main/src/models"
+	"time"
+)
+
+// Principal struct to handle principal response.
+// The key is never returned, except by CreatedPrincipal.
+type Principal struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	Grants    []Grant   `json:"grants"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Grant struct to handle the actions a principal may perform on an app.
+type Grant struct {
+	AppID   uint     `json:"appId"`
+	Actions []string `json:"actions"`
+}
+
+// CreatedPrincipal struct to handle the response of a created principal, with its key.
+type CreatedPrincipal struct {
+	Principal
+	Key string `json:"key"`
+}
+
+// SetPrincipal method to set principal data from models.Principal{}.
+// The grants are grouped by app.
+func (p *Principal) SetPrincipal(principal *models.Principal) {
+	p.ID = principal.ID
+	p.Name = principal.Name
+	p.Grants = make([]Grant, 0)
+	for i := range principal.Grants {
+		grant := &principal.Grants[i]
+		if len(p.Grants) == 0 || p.Grants[len(p.Grants)-1].AppID != grant.AppID {
+			p.Grants = append(p.Grants, Grant{AppID: grant.AppID, Actions: make([]string, 0)})
+		}
+		p.Grants[len(p.Grants)-1].Actions = append(p.Grants[len(p.Grants)-1].Actions, grant.Action.String())
+	}
+	p.CreatedAt = principal.CreatedAt
+	p.UpdatedAt = principal.UpdatedAt
+}
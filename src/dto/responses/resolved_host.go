@@ -0,0 +1,27 @@
+package responses
+
+import "api-app/main/src/models"
+
+// ResolvedHost struct to handle the response of a resolved host.
+type ResolvedHost struct {
+	AppID      uint                   `json:"appId"`
+	AppName    string                 `json:"appName"`
+	DomainID   uint                   `json:"domainId"`
+	DomainName string                 `json:"domainName"`
+	SSL        bool                   `json:"ssl"`
+	IpAddress  string                 `json:"ipAddress"`
+	AliasOfID  *uint                  `json:"aliasOfId"`
+	Settings   map[string]interface{} `json:"settings"`
+}
+
+// SetResolvedHost method to set resolved host data from models.Domain{} with its app and the resolved settings.
+func (rh *ResolvedHost) SetResolvedHost(domain *models.Domain, settings map[string]interface{}) {
+	rh.AppID = domain.AppID
+	rh.AppName = domain.App.Name
+	rh.DomainID = domain.ID
+	rh.DomainName = domain.Name
+	rh.SSL = domain.SSL
+	rh.IpAddress = domain.IpAddress
+	rh.AliasOfID = domain.AliasOfID
+	rh.Settings = settings
+}
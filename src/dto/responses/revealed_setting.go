@@ -0,0 +1,9 @@
+package responses
+
+// RevealedSetting struct to handle the response of a revealed secret setting.
+type RevealedSetting struct {
+	Name        string `json:"name"`
+	Level       string `json:"level"`
+	Environment string `json:"environment,omitempty"`
+	Value       string `json:"value"`
+}
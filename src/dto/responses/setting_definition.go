@@ -0,0 +1,60 @@
+package responses
+
+import (
+	"api-app/main/src/models"
+	"encoding/json"
+	"time"
+)
+
+// SettingDefinition struct to handle setting definition response.
+type SettingDefinition struct {
+	ID            uint            `json:"id"`
+	AppID         uint            `json:"appId"`
+	Name          string          `json:"name"`
+	Description   string          `json:"description"`
+	Level         string          `json:"level"`
+	ValueType     string          `json:"valueType"`
+	Default       *string         `json:"default"`
+	Min           *float64        `json:"min"`
+	Max           *float64        `json:"max"`
+	Regex         *string         `json:"regex"`
+	AllowedValues []string        `json:"allowedValues"`
+	JSONSchema    json.RawMessage `json:"jsonSchema"`
+	Required      bool            `json:"required"`
+	Owner         string          `json:"owner"`
+	CreatedAt     time.Time       `json:"createdAt"`
+	UpdatedAt     time.Time       `json:"updatedAt"`
+}
+
+// SetSettingDefinition method to set setting definition data from models.SettingDefinition{}.
+func (sd *SettingDefinition) SetSettingDefinition(definition *models.SettingDefinition) {
+	sd.ID = definition.ID
+	sd.AppID = definition.AppID
+	sd.Name = definition.Name
+	sd.Description = definition.Description
+	sd.Level = definition.Level.String()
+	sd.ValueType = definition.ValueType.String()
+	if definition.Default.Valid {
+		sd.Default = &definition.Default.String
+	}
+	if definition.Min.Valid {
+		sd.Min = &definition.Min.Float64
+	}
+	if definition.Max.Valid {
+		sd.Max = &definition.Max.Float64
+	}
+	if definition.Regex.Valid {
+		sd.Regex = &definition.Regex.String
+	}
+	sd.AllowedValues = make([]string, 0)
+	if definition.AllowedValues.Valid {
+		_ = json.Unmarshal([]byte(definition.AllowedValues.String), &sd.AllowedValues)
+	}
+	if definition.JSONSchema.Valid {
+		sd.JSONSchema = json.RawMessage(definition.JSONSchema.String)
+	}
+	sd.Required = definition.Required
+	sd.Owner = definition.Owner
+	sd.CreatedAt = definition.CreatedAt
+	sd.UpdatedAt = definition.UpdatedAt
+}
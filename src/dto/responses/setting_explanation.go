@@ -0,0 +1,20 @@
+package responses
+
+// SettingLayer struct to handle a single value of a setting in the layer it is set on.
+type SettingLayer struct {
+	Layer       string      `json:"layer"`
+	DomainID    *uint       `json:"domainId,omitempty"`
+	Environment string      `json:"environment,omitempty"`
+	Value       interface{} `json:"value"`
+	ValueType   string      `json:"valueType"`
+	Locked      bool        `json:"locked"`
+}
+
+// SettingExplanation struct to handle the explanation of a resolved setting.
+// The winning value is followed by the values it shadowed and the values a lock ignored,
+// both from the lowest to the highest precedence.
+type SettingExplanation struct {
+	SettingLayer
+	Shadowed []SettingLayer `json:"shadowed"`
+	Ignored  []SettingLayer `json:"ignored"`
+}
@@ -0,0 +1,101 @@
+package responses
+
+import (
+	"api-app/main/src/enums"
+	"api-app/main/src/models"
+	"api-app/main/src/secrets"
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+// SettingRevision struct to handle app and domain setting revision response.
+type SettingRevision struct {
+	Revision   uint            `json:"revision"`
+	Actor      string          `json:"actor"`
+	RollbackOf *uint           `json:"rollbackOf"`
+	Settings   json.RawMessage `json:"settings"`
+	Diff       json.RawMessage `json:"diff"`
+	CreatedAt  time.Time       `json:"createdAt"`
+}
+
+// SetAppSettingRevision method to set revision data from models.AppSettingRevision{}.
+// The values of secret settings are masked, and so are the values of the secret names, which are secrets now.
+func (sr *SettingRevision) SetAppSettingRevision(revision *models.AppSettingRevision, secretNames map[string]bool) {
+	sr.Revision = revision.Revision
+	sr.Actor = revision.Actor
+	if revision.RollbackOf.Valid {
+		rollbackOf := uint(revision.RollbackOf.Int64)
+		sr.RollbackOf = &rollbackOf
+	}
+	sr.Settings = maskSecretValues(revision.Settings, secretNames)
+	sr.Diff = maskSecretValues(revision.Diff, secretNames)
+	sr.CreatedAt = revision.CreatedAt
+}
+
+// SetDomainSettingRevision method to set revision data from models.DomainSettingRevision{}.
+// The values of secret settings are masked, and so are the values of the secret names, which are secrets now.
+func (sr *SettingRevision) SetDomainSettingRevision(revision *models.DomainSettingRevision, secretNames map[string]bool) {
+	sr.Revision = revision.Revision
+	sr.Actor = revision.Actor
+	if revision.RollbackOf.Valid {
+		rollbackOf := uint(revision.RollbackOf.Int64)
+		sr.RollbackOf = &rollbackOf
+	}
+	sr.Settings = maskSecretValues(revision.Settings, secretNames)
+	sr.Diff = maskSecretValues(revision.Diff, secretNames)
+	sr.CreatedAt = revision.CreatedAt
+}
+
+// maskSecretValues masks the values of the settings and setting changes in the JSON of which a value type is
+// a secret, or of which the name is one of the secret names. The JSON is returned as it is when nothing is masked.
+func maskSecretValues(value string, secretNames map[string]bool) json.RawMessage {
+	decoder := json.NewDecoder(bytes.NewReader([]byte(value)))
+	decoder.UseNumber()
+
+	var data interface{}
+	if err := decoder.Decode(&data); err != nil || !maskSecretData(data, secretNames) {
+		return json.RawMessage(value)
+	}
+
+	masked, err := json.Marshal(data)
+	if err != nil {
+		return json.RawMessage("null")
+	}
+
+	return masked
+}
+
+// maskSecretData masks the secret values in the decoded JSON, and returns whether anything has been masked.
+func maskSecretData(data interface{}, secretNames map[string]bool) bool {
+	masked := false
+
+	switch data := data.(type) {
+	case []interface{}:
+		for _, item := range data {
+			masked = maskSecretData(item, secretNames) || masked
+		}
+	case map[string]interface{}:
+		// A change from or to a secret masks both values, so the plain value before a setting became a secret is masked too.
+		name, _ := data["name"].(string)
+		secret := secretNames[name]
+		for _, typeKey := range []string{"valueType", "oldValueType", "newValueType"} {
+			if valueType, _ := data[typeKey].(string); valueType == enums.Secret.String() {
+				secret = true
+			}
+		}
+		if secret {
+			for _, valueKey := range []string{"value", "oldValue", "newValue"} {
+				if value, exists := data[valueKey]; exists && value != nil {
+					data[valueKey] = secrets.Mask
+					masked = true
+				}
+			}
+		}
+		for _, item := range data {
+			masked = maskSecretData(item, secretNames) || masked
+		}
+	}
+
+	return masked
+}
@@ -0,0 +1,32 @@
+package responses
+
+import (
+	"api-app/main/src/models"
+	"time"
+)
+
+// TrashItem struct to hold a deleted app or domain.
+type TrashItem struct {
+	Type          string    `json:"type"`
+	ID            uint      `json:"id"`
+	Name          string    `json:"name"`
+	AppID         *uint     `json:"appId,omitempty"`
+	DeletedAt     time.Time `json:"deletedAt"`
+	DeletionBatch *string   `json:"deletionBatch"`
+}
+
+// SetTrashItem method to set trash item data from models.TrashItem{}.
+func (t *TrashItem) SetTrashItem(item *models.TrashItem) {
+	t.Type = item.Type
+	t.ID = item.ID
+	t.Name = item.Name
+	t.DeletedAt = item.DeletedAt
+
+	if item.AppID.Valid {
+		appID := uint(item.AppID.Int64)
+		t.AppID = &appID
+	}
+	if item.DeletionBatch.Valid {
+		t.DeletionBatch = &item.DeletionBatch.String
+	}
+}
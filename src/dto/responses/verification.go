@@ -0,0 +1,22 @@
+package responses
+
+import (
+	"api-app/main/src/models"
+	"time"
+)
+
+// Verification struct to handle the verification response of a domain.
+type Verification struct {
+	Token      string     `json:"token"`
+	Status     string     `json:"status"`
+	VerifiedAt *time.Time `json:"verifiedAt"`
+}
+
+// SetVerification method to set verification data from models.Domain{}.
+func (v *Verification) SetVerification(domain *models.Domain) {
+	v.Token = domain.VerificationToken
+	v.Status = domain.VerificationStatus.String()
+	if domain.VerifiedAt.Valid {
+		v.VerifiedAt = &domain.VerifiedAt.Time
+	}
+}
@@ -0,0 +1,31 @@
+package responses
+
+import (
+	"api-app/main/src/models"
+	"encoding/json"
+	"time"
+)
+
+// Webhook struct to handle webhook response.
+// The secret is never returned.
+type Webhook struct {
+	ID        uint      `json:"id"`
+	AppID     uint      `json:"appId"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// SetWebhook method to set webhook data from models.Webhook{}.
+func (w *Webhook) SetWebhook(webhook *models.Webhook) {
+	w.ID = webhook.ID
+	w.AppID = webhook.AppID
+	w.URL = webhook.URL
+	w.Events = make([]string, 0)
+	_ = json.Unmarshal([]byte(webhook.Events), &w.Events)
+	w.Active = webhook.Active
+	w.CreatedAt = webhook.CreatedAt
+	w.UpdatedAt = webhook.UpdatedAt
+}
@@ -0,0 +1,47 @@
+package responses
+
+import (
+	"api-app/main/src/models"
+	"encoding/json"
+	"time"
+)
+
+// WebhookDelivery struct to handle webhook delivery response.
+type WebhookDelivery struct {
+	ID            uint            `json:"id"`
+	WebhookID     uint            `json:"webhookId"`
+	EventID       uint            `json:"eventId"`
+	EventType     string          `json:"eventType"`
+	Payload       json.RawMessage `json:"payload"`
+	Attempts      uint            `json:"attempts"`
+	StatusCode    *int64          `json:"statusCode"`
+	LastError     *string         `json:"lastError"`
+	NextAttemptAt time.Time       `json:"nextAttemptAt"`
+	DeliveredAt   *time.Time      `json:"deliveredAt"`
+	FailedAt      *time.Time      `json:"failedAt"`
+	CreatedAt     time.Time       `json:"createdAt"`
+}
+
+// SetWebhookDelivery method to set webhook delivery data from models.WebhookDelivery{}.
+func (wd *WebhookDelivery) SetWebhookDelivery(delivery *models.WebhookDelivery) {
+	wd.ID = delivery.ID
+	wd.WebhookID = delivery.WebhookID
+	wd.EventID = delivery.EventID
+	wd.EventType = delivery.EventType.String()
+	wd.Payload = json.RawMessage(delivery.Payload)
+	wd.Attempts = delivery.Attempts
+	if delivery.StatusCode.Valid {
+		wd.StatusCode = &delivery.StatusCode.Int64
+	}
+	if delivery.LastError.Valid {
+		wd.LastError = &delivery.LastError.String
+	}
+	wd.NextAttemptAt = delivery.NextAttemptAt
+	if delivery.DeliveredAt.Valid {
+		wd.DeliveredAt = &delivery.DeliveredAt.Time
+	}
+	if delivery.FailedAt.Valid {
+		wd.FailedAt = &delivery.FailedAt.Time
+	}
+	wd.CreatedAt = delivery.CreatedAt
+}
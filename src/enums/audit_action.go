@@ -0,0 +1,81 @@
+package enums
+
+import "database/sql/driver"
+
+type AuditAction string
+
+const (
+	AuditAppCreated           AuditAction = "app.created"
+	AuditAppUpdated           AuditAction = "app.updated"
+	AuditAppDeleted           AuditAction = "app.deleted"
+	AuditAppRestored          AuditAction = "app.restored"
+	AuditAppPurged            AuditAction = "app.purged"
+	AuditDomainCreated        AuditAction = "domain.created"
+	AuditDomainUpdated        AuditAction = "domain.updated"
+	AuditDomainDeleted        AuditAction = "domain.deleted"
+	AuditDomainRestored       AuditAction = "domain.restored"
+	AuditDomainPurged         AuditAction = "domain.purged"
+	AuditDomainVerified       AuditAction = "domain.verified"
+	AuditDomainVerifyFailed   AuditAction = "domain.verify_failed"
+	AuditDomainReparsed       AuditAction = "domain.reparsed"
+	AuditSettingsChanged      AuditAction = "settings.changed"
+	AuditGlobalSettingSaved   AuditAction = "global_setting.saved"
+	AuditGlobalSettingDeleted AuditAction = "global_setting.deleted"
+	AuditSettingRevealed      AuditAction = "setting.revealed"
+	AuditDefinitionCreated    AuditAction = "definition.created"
+	AuditDefinitionUpdated    AuditAction = "definition.updated"
+	AuditDefinitionDeleted    AuditAction = "definition.deleted"
+	AuditWebhookCreated       AuditAction = "webhook.created"
+	AuditWebhookUpdated       AuditAction = "webhook.updated"
+	AuditWebhookDeleted       AuditAction = "webhook.deleted"
+	AuditEnvironmentCreated   AuditAction = "environment.created"
+	AuditEnvironmentDeleted   AuditAction = "environment.deleted"
+	AuditPrincipalCreated     AuditAction = "principal.created"
+	AuditPrincipalDeleted     AuditAction = "principal.deleted"
+	AuditGrantsChanged        AuditAction = "grants.changed"
+)
+
+// AuditActions holds all known audit actions.
+var AuditActions = []AuditAction{
+	AuditAppCreated,
+	AuditAppUpdated,
+	AuditAppDeleted,
+	AuditAppRestored,
+	AuditAppPurged,
+	AuditDomainCreated,
+	AuditDomainUpdated,
+	AuditDomainDeleted,
+	AuditDomainRestored,
+	AuditDomainPurged,
+	AuditDomainVerified,
+	AuditDomainVerifyFailed,
+	AuditDomainReparsed,
+	AuditSettingsChanged,
+	AuditGlobalSettingSaved,
+	AuditGlobalSettingDeleted,
+	AuditSettingRevealed,
+	AuditDefinitionCreated,
+	AuditDefinitionUpdated,
+	AuditDefinitionDeleted,
+	AuditWebhookCreated,
+	AuditWebhookUpdated,
+	AuditWebhookDeleted,
+	AuditEnvironmentCreated,
+	AuditEnvironmentDeleted,
+	AuditPrincipalCreated,
+	AuditPrincipalDeleted,
+	AuditGrantsChanged,
+}
+
+func (aa *AuditAction) Scan(value interface{}) error {
+	*aa = AuditAction(value.(string))
+	return nil
+}
+
+func (aa AuditAction) Value() (driver.Value, error) {
+	return string(aa), nil
+}
+
+func (aa AuditAction) String() string {
+	return string(aa)
+}
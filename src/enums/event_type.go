@@ -0,0 +1,49 @@
+package enums
+
+import "database/sql/driver"
+
+type EventType string
+
+const (
+	AppCreated      EventType = "app.created"
+	AppUpdated      EventType = "app.updated"
+	AppRenamed      EventType = "app.renamed"
+	AppDeleted      EventType = "app.deleted"
+	AppRestored     EventType = "app.restored"
+	DomainAdded     EventType = "domain.added"
+	DomainUpdated   EventType = "domain.updated"
+	DomainRemoved   EventType = "domain.removed"
+	DomainRestored  EventType = "domain.restored"
+	DomainVerified  EventType = "domain.verified"
+	SettingsChanged EventType = "settings.changed"
+	SettingRevealed EventType = "setting.revealed"
+)
+
+// EventTypes holds all known event types.
+var EventTypes = []EventType{
+	AppCreated,
+	AppUpdated,
+	AppRenamed,
+	AppDeleted,
+	AppRestored,
+	DomainAdded,
+	DomainUpdated,
+	DomainRemoved,
+	DomainRestored,
+	DomainVerified,
+	SettingsChanged,
+	SettingRevealed,
+}
+
+func (et *EventType) Scan(value interface{}) error {
+	*et = EventType(value.(string))
+	return nil
+}
+
+func (et EventType) Value() (driver.Value, error) {
+	return string(et), nil
+}
+
+func (et EventType) String() string {
+	return string(et)
+}
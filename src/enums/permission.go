@@ -0,0 +1,34 @@
+package enums
+
+import "database/sql/driver"
+
+// Permission is an action on an app that is granted to a principal.
+type Permission string
+
+const (
+	ReadPrivate   Permission = "read-private"
+	WriteSettings Permission = "write-settings"
+	ManageDomains Permission = "manage-domains"
+	Delete        Permission = "delete"
+)
+
+// Permissions holds all known permissions.
+var Permissions = []Permission{
+	ReadPrivate,
+	WriteSettings,
+	ManageDomains,
+	Delete,
+}
+
+func (p *Permission) Scan(value interface{}) error {
+	*p = Permission(value.(string))
+	return nil
+}
+
+func (p Permission) Value() (driver.Value, error) {
+	return string(p), nil
+}
+
+func (p Permission) String() string {
+	return string(p)
+}
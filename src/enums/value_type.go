@@ -12,6 +12,8 @@ const (
 	Date     ValueType = "date"
 	DateTime ValueType = "datetime"
 	JSON     ValueType = "json"
+	// Secret is a string that is encrypted at rest and masked in the responses.
+	Secret ValueType = "secret"
 )
 
 func (vt *ValueType) Scan(value interface{}) error {
@@ -0,0 +1,12 @@
+package enums
+
+type VerificationMethod string
+
+const (
+	DNS  VerificationMethod = "dns"
+	HTTP VerificationMethod = "http"
+)
+
+func (vm VerificationMethod) String() string {
+	return string(vm)
+}
@@ -0,0 +1,24 @@
+package enums
+
+import "database/sql/driver"
+
+type VerificationStatus string
+
+const (
+	Pending  VerificationStatus = "pending"
+	Verified VerificationStatus = "verified"
+	Failed   VerificationStatus = "failed"
+)
+
+func (vs *VerificationStatus) Scan(value interface{}) error {
+	*vs = VerificationStatus(value.(string))
+	return nil
+}
+
+func (vs VerificationStatus) Value() (driver.Value, error) {
+	return string(vs), nil
+}
+
+func (vs VerificationStatus) String() string {
+	return string(vs)
+}
@@ -2,11 +2,37 @@ package errors
 
 // Define error codes as constants.
 const (
-	AppAvailable    = "appAvailable"
-	AppExists       = "appExists"
-	AppSettings     = "appSettings"
-	DomainAvailable = "domainAvailable"
-	DomainExists    = "domainExists"
-	DomainSettings  = "domainSettings"
+	AppAvailable               = "appAvailable"
+	AppExists                  = "appExists"
+	AppNotFound                = "appNotFound"
+	AppDeleted                 = "appDeleted"
+	AppSettings                = "appSettings"
+	AppSettingExists           = "appSettingExists"
+	AppRevisionExists          = "appRevisionExists"
+	DomainAvailable            = "domainAvailable"
+	DomainAlias                = "domainAlias"
+	DomainVerification         = "domainVerification"
+	DomainName                 = "domainName"
+	DomainExists               = "domainExists"
+	DomainNotFound             = "domainNotFound"
+	DomainSettings             = "domainSettings"
+	DomainSettingExists        = "domainSettingExists"
+	DomainRevisionExists       = "domainRevisionExists"
+	Environment                = "environment"
+	EnvironmentAvailable       = "environmentAvailable"
+	EnvironmentNotFound        = "environmentNotFound"
+	GlobalSettings             = "globalSettings"
+	GlobalSettingExists        = "globalSettingExists"
+	HostAmbiguous              = "hostAmbiguous"
+	Principal                  = "principal"
+	PrincipalAvailable         = "principalAvailable"
+	PrincipalExists            = "principalExists"
+	SecretReveal               = "secretReveal"
+	SettingNotSecret           = "settingNotSecret"
+	SettingDefinition          = "settingDefinition"
+	SettingDefinitionAvailable = "settingDefinitionAvailable"
+	SettingDefinitionExists    = "settingDefinitionExists"
+	Webhook                    = "webhook"
+	WebhookExists              = "webhookExists"
 	// Add more error codes as needed.
 )
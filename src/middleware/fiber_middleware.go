@@ -5,6 +5,7 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
 	"os"
 	"strings"
 )
@@ -23,9 +24,13 @@ func FiberMiddleware(a *fiber.App) {
 				fiber.MethodHead,
 				fiber.MethodOptions,
 			}, ","),
-			AllowHeaders: "Accept,Content-Type",
+			AllowHeaders:  "Accept,Content-Type,If-Match",
+			ExposeHeaders: "ETag",
 		}),
 
+		// Add an X-Request-ID header to each response, for the audit log.
+		requestid.New(),
+
 		// Add simple logger.
 		logger.New(),
 
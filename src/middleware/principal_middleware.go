@@ -0,0 +1,148 @@
+package middleware
+
+import (
+	"api-app/main/src/enums"
+	"api-app/main/src/models"
+	"api-app/main/src/services"
+	"api-app/main/src/utils"
+	"crypto/subtle"
+	"encoding/json"
+	errorutil "github.com/ArnoldPMolenaar/api-utils/errors"
+	apiutils "github.com/ArnoldPMolenaar/api-utils/utils"
+	"github.com/gofiber/fiber/v2"
+	"os"
+)
+
+// principalLocal is the key of the local that holds the principal of a request.
+const principalLocal = "principal"
+
+// superuserActor is the actor of the requests with the MACHINE_KEY.
+const superuserActor = "machine"
+
+// AppResolver returns the ID of the app a request acts on, or 0 when it can not be determined.
+type AppResolver func(c *fiber.Ctx) (uint, error)
+
+// PrincipalProtected middleware checks if the machine key is valid.
+// It reads the header x-machine-key, which is either the MACHINE_KEY from the .env file, which may do everything,
+// or the key of a principal, which may only do what it is granted by Authorize.
+func PrincipalProtected() func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		headerKey := c.Get("x-machine-key")
+		if headerKey == "" {
+			return errorutil.Response(c, fiber.StatusUnauthorized, errorutil.Unauthorized, "Machine key is invalid.")
+		}
+
+		if machineKey := os.Getenv("MACHINE_KEY"); machineKey != "" && subtle.ConstantTimeCompare([]byte(headerKey), []byte(machineKey)) == 1 {
+			c.Locals(utils.ActorLocal, superuserActor)
+			return c.Next()
+		}
+
+		principal, err := services.GetPrincipalByKey(headerKey)
+		if err != nil {
+			return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+		} else if principal.ID == 0 {
+			return errorutil.Response(c, fiber.StatusUnauthorized, errorutil.Unauthorized, "Machine key is invalid.")
+		}
+
+		c.Locals(principalLocal, principal)
+		c.Locals(utils.ActorLocal, principal.Name)
+
+		return c.Next()
+	}
+}
+
+// Superuser middleware only allows the MACHINE_KEY, the principals are forbidden.
+// It is used after PrincipalProtected.
+func Superuser() func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		if GetPrincipal(c) != nil {
+			return errorutil.Response(c, fiber.StatusForbidden, errorutil.Forbidden, "Only the machine key may perform this action.")
+		}
+
+		return c.Next()
+	}
+}
+
+// Authorize middleware checks if the principal is granted all actions on the app of the request.
+// The MACHINE_KEY is granted everything. It is used after PrincipalProtected.
+func Authorize(resolve AppResolver, actions ...enums.Permission) func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		principal := GetPrincipal(c)
+		if principal == nil {
+			return c.Next()
+		}
+
+		appID, err := resolve(c)
+		if err != nil {
+			return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+		}
+
+		for _, action := range actions {
+			granted := false
+			if appID != 0 {
+				if granted, err = services.IsGranted(principal.ID, appID, action); err != nil {
+					return errorutil.Response(c, fiber.StatusInternalServerError, errorutil.QueryError, err.Error())
+				}
+			}
+			if !granted {
+				return errorutil.Response(c, fiber.StatusForbidden, errorutil.Forbidden, "Principal is not granted "+action.String()+" on the app.")
+			}
+		}
+
+		return c.Next()
+	}
+}
+
+// GetPrincipal returns the principal of the request, or nil for the MACHINE_KEY.
+func GetPrincipal(c *fiber.Ctx) *models.Principal {
+	principal, _ := c.Locals(principalLocal).(*models.Principal)
+
+	return principal
+}
+
+// AppFromParam resolves the app of the id parameter of an app route.
+func AppFromParam(c *fiber.Ctx) (uint, error) {
+	appID, err := apiutils.StringToUint(c.Params("id"))
+	if err != nil {
+		return 0, nil
+	}
+
+	return appID, nil
+}
+
+// AppOfDomainParam resolves the app of the domain of the id parameter of a domain route, including deleted domains.
+func AppOfDomainParam(c *fiber.Ctx) (uint, error) {
+	domainID, err := apiutils.StringToUint(c.Params("id"))
+	if err != nil {
+		return 0, nil
+	}
+
+	return services.GetAppIDByDomainID(domainID, true)
+}
+
+// AppFromNameQuery resolves the app of the app query parameter, which holds the name of the app.
+func AppFromNameQuery(c *fiber.Ctx) (uint, error) {
+	return services.GetAppIDByName(c.Query("app"))
+}
+
+// AppFromIDQuery resolves the app of the appId query parameter.
+func AppFromIDQuery(c *fiber.Ctx) (uint, error) {
+	appID := c.QueryInt("appId")
+	if appID < 0 {
+		return 0, nil
+	}
+
+	return uint(appID), nil
+}
+
+// AppFromBody resolves the app of the appId field of the JSON body.
+func AppFromBody(c *fiber.Ctx) (uint, error) {
+	body := struct {
+		AppID uint `json:"appId"`
+	}{}
+	if err := json.Unmarshal(c.Body(), &body); err != nil {
+		return 0, nil
+	}
+
+	return body.AppID, nil
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"api-app/main/src/errors"
+	"crypto/subtle"
+	errorutil "github.com/ArnoldPMolenaar/api-utils/errors"
+	"github.com/gofiber/fiber/v2"
+	"os"
+)
+
+// SecretRevealProtected middleware checks if the machine may reveal secret settings.
+// It reads the header x-reveal-key and compares it with SECRET_REVEAL_KEY from the .env file.
+// Revealing is forbidden when SECRET_REVEAL_KEY is not configured.
+// It is used after PrincipalProtected, as an elevated scope on top of the machine key.
+func SecretRevealProtected() func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		revealKey := os.Getenv("SECRET_REVEAL_KEY")
+		headerKey := c.Get("x-reveal-key")
+
+		if revealKey == "" || subtle.ConstantTimeCompare([]byte(headerKey), []byte(revealKey)) != 1 {
+			return errorutil.Response(c, fiber.StatusForbidden, errors.SecretReveal, "Reveal key is invalid.")
+		}
+
+		return c.Next()
+	}
+}
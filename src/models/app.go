@@ -1,12 +1,20 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"database/sql"
+	"gorm.io/gorm"
+)
 
 type App struct {
 	gorm.Model
-	Name string `gorm:"uniqueIndex:idx_name,sort:asc;not null"`
+	Name          string         `gorm:"uniqueIndex:idx_apps_name,sort:asc,where:deleted_at IS NULL;not null"`
+	DeletionBatch sql.NullString `gorm:"type:uuid;index"`
+
+	// Version is incremented by every change, it is the ETag of the app.
+	Version uint `gorm:"default:1;not null"`
 
 	// Relationships.
-	Settings []AppSetting
-	Domains  []Domain
+	Settings     []AppSetting
+	Domains      []Domain
+	Environments []Environment
 }
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+type AppSettingRevision struct {
+	ID         uint          `gorm:"primarykey"`
+	AppID      uint          `gorm:"uniqueIndex:idx_app_setting_revision;not null"`
+	Revision   uint          `gorm:"uniqueIndex:idx_app_setting_revision,sort:desc;not null"`
+	Actor      string        `gorm:"not null"`
+	Settings   string        `gorm:"type:jsonb;not null"`
+	Diff       string        `gorm:"type:jsonb;not null"`
+	RollbackOf sql.NullInt64 `gorm:"default:null"`
+	CreatedAt  time.Time
+
+	// Relationships.
+	App App `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:AppID;references:ID"`
+}
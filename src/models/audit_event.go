@@ -0,0 +1,25 @@
+package models
+
+import (
+	"api-app/main/src/enums"
+	"database/sql"
+	"time"
+)
+
+// AuditEvent is an append-only record of a mutation, written within the transaction of the mutation.
+// Actor is the authenticated machine or principal, and ClaimedActor the unverified x-actor header of the request.
+// Before and After hold the JSON of the target before and after the mutation, and are null when it did not exist.
+type AuditEvent struct {
+	ID           uint              `gorm:"primarykey"`
+	Actor        string            `gorm:"index;not null"`
+	ClaimedActor string            `gorm:"index;not null;default:''"`
+	IpAddress    string            `gorm:"not null;default:''"`
+	RequestID    string            `gorm:"index;not null;default:''"`
+	Action       enums.AuditAction `gorm:"index;not null"`
+	Target       string            `gorm:"not null"`
+	AppID        sql.NullInt64     `gorm:"index"`
+	DomainID     sql.NullInt64     `gorm:"index"`
+	Before       sql.NullString    `gorm:"type:jsonb"`
+	After        sql.NullString    `gorm:"type:jsonb"`
+	CreatedAt    time.Time         `gorm:"index"`
+}
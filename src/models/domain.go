@@ -1,21 +1,34 @@
 package models
 
 import (
+	"api-app/main/src/enums"
 	"database/sql"
 	"gorm.io/gorm"
 )
 
 type Domain struct {
 	gorm.Model
-	AppID       uint   `gorm:"uniqueIndex:idx_app_name;not null"`
+	AppID       uint   `gorm:"uniqueIndex:idx_domains_app_name,where:deleted_at IS NULL;not null"`
 	SSL         bool   `gorm:"default:false;not null"`
-	Name        string `gorm:"uniqueIndex:idx_app_name;not null"`
+	Name        string `gorm:"uniqueIndex:idx_domains_app_name,where:deleted_at IS NULL;not null"`
 	Sub         sql.NullString
 	SecondLevel string `gorm:"not null"`
 	TopLevel    string `gorm:"not null"`
 	IpAddress   string `gorm:"not null"`
+	AliasOfID   *uint  `gorm:"index"`
+
+	// DeletionBatch is the ID of the delete operation that deleted the domain, possibly together with its app.
+	DeletionBatch sql.NullString `gorm:"type:uuid;index"`
+
+	// Version is incremented by every change, it is the ETag of the domain.
+	Version uint `gorm:"default:1;not null"`
+
+	VerificationToken  string                   `gorm:"default:'';not null"`
+	VerificationStatus enums.VerificationStatus `gorm:"type:verification_status;default:pending;not null"`
+	VerifiedAt         sql.NullTime
 
 	// Relationships.
-	App      App `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:AppID;references:ID"`
+	App      App     `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:AppID;references:ID"`
+	AliasOf  *Domain `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;foreignKey:AliasOfID;references:ID"`
 	Settings []DomainSetting
 }
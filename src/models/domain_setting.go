@@ -8,6 +8,10 @@ type DomainSetting struct {
 	Level     enums.Level     `gorm:"primaryKey;autoIncrement:false;type:level"`
 	Value     string          `gorm:"not null"`
 	ValueType enums.ValueType `gorm:"not null;type:value_type"`
+	// Environment is the name of the environment the setting overrides, or empty for the base setting.
+	Environment string `gorm:"primaryKey;autoIncrement:false;default:''"`
+	// Locked stops the layers below, like the domains of an app, from overriding the setting.
+	Locked bool `gorm:"not null;default:false"`
 
 	// Relationships.
 	Domain Domain `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:DomainID;references:ID"`
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+type DomainSettingRevision struct {
+	ID         uint          `gorm:"primarykey"`
+	DomainID   uint          `gorm:"uniqueIndex:idx_domain_setting_revision;not null"`
+	Revision   uint          `gorm:"uniqueIndex:idx_domain_setting_revision,sort:desc;not null"`
+	Actor      string        `gorm:"not null"`
+	Settings   string        `gorm:"type:jsonb;not null"`
+	Diff       string        `gorm:"type:jsonb;not null"`
+	RollbackOf sql.NullInt64 `gorm:"default:null"`
+	CreatedAt  time.Time
+
+	// Relationships.
+	Domain Domain `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:DomainID;references:ID"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// Environment is a named environment of an app, like staging or production.
+// The settings of an app and its domains can be overridden per environment.
+type Environment struct {
+	ID        uint   `gorm:"primarykey"`
+	AppID     uint   `gorm:"uniqueIndex:idx_environments_app_name;not null"`
+	Name      string `gorm:"uniqueIndex:idx_environments_app_name;not null"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	// Relationships.
+	App App `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:AppID;references:ID"`
+}
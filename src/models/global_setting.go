@@ -0,0 +1,13 @@
+package models
+
+import "api-app/main/src/enums"
+
+// GlobalSetting is a setting of the organisation, which every app and domain inherits.
+type GlobalSetting struct {
+	Name      string          `gorm:"primaryKey;autoIncrement:false"`
+	Level     enums.Level     `gorm:"primaryKey;autoIncrement:false;type:level"`
+	Value     string          `gorm:"not null"`
+	ValueType enums.ValueType `gorm:"not null;type:value_type"`
+	// Locked stops the apps and domains from overriding the setting.
+	Locked bool `gorm:"not null;default:false"`
+}
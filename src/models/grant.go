@@ -0,0 +1,14 @@
+package models
+
+import "api-app/main/src/enums"
+
+// Grant allows a principal to perform an action on an app.
+type Grant struct {
+	PrincipalID uint             `gorm:"primaryKey;autoIncrement:false"`
+	AppID       uint             `gorm:"primaryKey;autoIncrement:false;index"`
+	Action      enums.Permission `gorm:"primaryKey"`
+
+	// Relationships.
+	Principal Principal `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:PrincipalID;references:ID"`
+	App       App       `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:AppID;references:ID"`
+}
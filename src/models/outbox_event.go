@@ -0,0 +1,21 @@
+package models
+
+import (
+	"api-app/main/src/enums"
+	"database/sql"
+	"time"
+)
+
+type OutboxEvent struct {
+	ID            uint            `gorm:"primarykey"`
+	Type          enums.EventType `gorm:"not null"`
+	AppID         uint            `gorm:"index;not null"`
+	DomainID      sql.NullInt64
+	Payload       string `gorm:"type:jsonb;not null"`
+	Attempts      uint   `gorm:"default:0;not null"`
+	LastError     sql.NullString
+	NextAttemptAt time.Time `gorm:"index:idx_outbox_pending,where:published_at IS NULL AND failed_at IS NULL;not null"`
+	PublishedAt   sql.NullTime
+	FailedAt      sql.NullTime
+	CreatedAt     time.Time
+}
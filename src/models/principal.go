@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// Principal is a machine that calls the API with its own key, and may only act on the apps it is granted.
+// Only the SHA-256 hash of the key is stored.
+type Principal struct {
+	ID        uint   `gorm:"primarykey"`
+	Name      string `gorm:"uniqueIndex;not null"`
+	KeyHash   string `gorm:"uniqueIndex;not null"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	// Relationships.
+	Grants []Grant `gorm:"foreignKey:PrincipalID"`
+}
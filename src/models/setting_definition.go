@@ -0,0 +1,29 @@
+package models
+
+import (
+	"api-app/main/src/enums"
+	"database/sql"
+	"time"
+)
+
+type SettingDefinition struct {
+	ID            uint            `gorm:"primarykey"`
+	AppID         uint            `gorm:"uniqueIndex:idx_setting_definition;not null"`
+	Name          string          `gorm:"uniqueIndex:idx_setting_definition;not null"`
+	Description   string          `gorm:"not null"`
+	Level         enums.Level     `gorm:"not null;type:level"`
+	ValueType     enums.ValueType `gorm:"not null;type:value_type"`
+	Default       sql.NullString
+	Min           sql.NullFloat64
+	Max           sql.NullFloat64
+	Regex         sql.NullString
+	AllowedValues sql.NullString `gorm:"type:jsonb"`
+	JSONSchema    sql.NullString `gorm:"column:json_schema;type:jsonb"`
+	Required      bool           `gorm:"default:false;not null"`
+	Owner         string         `gorm:"not null"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+
+	// Relationships.
+	App App `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:AppID;references:ID"`
+}
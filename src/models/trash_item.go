@@ -0,0 +1,17 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// TrashItem is a deleted app or domain, read from the union of both tables.
+// The AppID is only set for domains.
+type TrashItem struct {
+	Type          string
+	ID            uint
+	Name          string
+	AppID         sql.NullInt64
+	DeletedAt     time.Time
+	DeletionBatch sql.NullString
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+type Webhook struct {
+	ID        uint   `gorm:"primarykey"`
+	AppID     uint   `gorm:"index;not null"`
+	URL       string `gorm:"column:url;not null"`
+	Events    string `gorm:"type:jsonb;default:'[]';not null"`
+	Secret    string `gorm:"not null"`
+	Active    bool   `gorm:"not null"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	// Relationships.
+	App App `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:AppID;references:ID"`
+}
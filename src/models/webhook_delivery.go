@@ -0,0 +1,25 @@
+package models
+
+import (
+	"api-app/main/src/enums"
+	"database/sql"
+	"time"
+)
+
+type WebhookDelivery struct {
+	ID            uint            `gorm:"primarykey"`
+	WebhookID     uint            `gorm:"index;not null"`
+	EventID       uint            `gorm:"not null"`
+	EventType     enums.EventType `gorm:"not null"`
+	Payload       string          `gorm:"type:jsonb;not null"`
+	Attempts      uint            `gorm:"default:0;not null"`
+	StatusCode    sql.NullInt64
+	LastError     sql.NullString
+	NextAttemptAt time.Time `gorm:"index:idx_webhook_delivery_pending,where:delivered_at IS NULL AND failed_at IS NULL;not null"`
+	DeliveredAt   sql.NullTime
+	FailedAt      sql.NullTime
+	CreatedAt     time.Time
+
+	// Relationships.
+	Webhook Webhook `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:WebhookID;references:ID"`
+}
@@ -3,42 +3,122 @@ package routes
 import (
 	"api-app/main/src/controllers"
 	"api-app/main/src/enums"
-	"github.com/ArnoldPMolenaar/api-utils/middleware"
+	appmiddleware "api-app/main/src/middleware"
 	"github.com/gofiber/fiber/v2"
 )
 
 // PrivateRoutes func for describe group of private routes.
+// The MACHINE_KEY may use every route, a principal only the app and domain routes it is granted.
 func PrivateRoutes(a *fiber.App) {
 	// Create private routes group.
 	route := a.Group("/v1")
 
+	// Permissions on the app of the id parameter.
+	readApp := appmiddleware.Authorize(appmiddleware.AppFromParam, enums.ReadPrivate)
+	writeApp := appmiddleware.Authorize(appmiddleware.AppFromParam, enums.WriteSettings)
+	deleteApp := appmiddleware.Authorize(appmiddleware.AppFromParam, enums.Delete)
+
 	// Register CRUD routes for /v1/apps.
-	apps := route.Group("/apps", middleware.MachineProtected())
-	apps.Get("/", controllers.GetApps)
-	apps.Post("/", controllers.CreateApp)
-	apps.Get("/settings", func(c *fiber.Ctx) error {
+	apps := route.Group("/apps", appmiddleware.PrincipalProtected())
+	apps.Get("/", appmiddleware.Superuser(), controllers.GetApps)
+	apps.Post("/", appmiddleware.Superuser(), controllers.CreateApp)
+	apps.Get("/settings", appmiddleware.Authorize(appmiddleware.AppFromNameQuery, enums.ReadPrivate), func(c *fiber.Ctx) error {
 		return controllers.GetSettingsByAppName(c, enums.Private)
 	})
-	apps.Get("/exists", controllers.AreAppsAvailable)
-	apps.Get("/:id", controllers.GetApp)
-	apps.Put("/:id", controllers.UpdateApp)
-	apps.Delete("/:id", controllers.DeleteApp)
-	apps.Put("/:id/restore", controllers.RestoreApp)
-	apps.Get("/:id/settings", func(c *fiber.Ctx) error {
+	apps.Get("/exists", appmiddleware.Superuser(), controllers.AreAppsAvailable)
+	apps.Get("/:id", readApp, controllers.GetApp)
+	apps.Put("/:id", appmiddleware.Authorize(appmiddleware.AppFromParam, enums.WriteSettings, enums.ManageDomains), controllers.UpdateApp)
+	apps.Delete("/:id", deleteApp, controllers.DeleteApp)
+	apps.Put("/:id/restore", deleteApp, controllers.RestoreApp)
+	apps.Get("/:id/settings", readApp, func(c *fiber.Ctx) error {
 		return controllers.GetSettingsByAppID(c, enums.Private)
 	})
+	apps.Get("/:id/settings/stream", readApp, func(c *fiber.Ctx) error {
+		return controllers.StreamSettingsByAppID(c, enums.Private)
+	})
+	apps.Put("/:id/settings/:name", writeApp, controllers.UpsertAppSetting)
+	apps.Delete("/:id/settings/:name", writeApp, controllers.DeleteAppSetting)
+	apps.Get("/:id/settings/revisions", readApp, controllers.GetAppSettingRevisions)
+	apps.Get("/:id/settings/revisions/:rev", readApp, controllers.GetAppSettingRevision)
+	apps.Post("/:id/settings/revisions/:rev/rollback", writeApp, controllers.RollbackAppSettings)
+	apps.Get("/:id/settings/:name/reveal", readApp, appmiddleware.SecretRevealProtected(), controllers.RevealAppSetting)
+	apps.Get("/:id/definitions", readApp, controllers.GetSettingDefinitions)
+	apps.Post("/:id/definitions", writeApp, controllers.CreateSettingDefinition)
+	apps.Put("/:id/definitions/:name", writeApp, controllers.UpdateSettingDefinition)
+	apps.Delete("/:id/definitions/:name", writeApp, controllers.DeleteSettingDefinition)
+	apps.Get("/:id/environments", readApp, controllers.GetEnvironments)
+	apps.Post("/:id/environments", writeApp, controllers.CreateEnvironment)
+	apps.Delete("/:id/environments/:env", writeApp, controllers.DeleteEnvironment)
+	apps.Get("/:id/environments/:env/promote/:target", readApp, controllers.GetPromotion)
+	apps.Post("/:id/environments/:env/promote/:target", writeApp, controllers.PromoteEnvironment)
+	apps.Get("/:id/webhooks", readApp, controllers.GetWebhooks)
+	apps.Post("/:id/webhooks", writeApp, controllers.CreateWebhook)
+	apps.Get("/:id/webhooks/:wid", readApp, controllers.GetWebhook)
+	apps.Put("/:id/webhooks/:wid", writeApp, controllers.UpdateWebhook)
+	apps.Delete("/:id/webhooks/:wid", writeApp, controllers.DeleteWebhook)
+	apps.Get("/:id/webhooks/:wid/deliveries", readApp, controllers.GetWebhookDeliveries)
+
+	// Register routes for /v1/admin.
+	admin := route.Group("/admin", appmiddleware.PrincipalProtected(), appmiddleware.Superuser())
+	admin.Post("/cache/warm", controllers.WarmCache)
+	admin.Get("/cache/stats", controllers.GetCacheStats)
+	admin.Delete("/cache", controllers.ClearCache)
+
+	// Register routes for /v1/globals.
+	globals := route.Group("/globals", appmiddleware.PrincipalProtected(), appmiddleware.Superuser())
+	globals.Get("/", controllers.GetGlobalSettings)
+	globals.Put("/:name", controllers.UpsertGlobalSetting)
+	globals.Delete("/:name", controllers.DeleteGlobalSetting)
+	globals.Get("/:name/reveal", appmiddleware.SecretRevealProtected(), controllers.RevealGlobalSetting)
+
+	// Register routes for /v1/principals.
+	principals := route.Group("/principals", appmiddleware.PrincipalProtected(), appmiddleware.Superuser())
+	principals.Get("/", controllers.GetPrincipals)
+	principals.Post("/", controllers.CreatePrincipal)
+	principals.Get("/:id", controllers.GetPrincipal)
+	principals.Delete("/:id", controllers.DeletePrincipal)
+	principals.Put("/:id/grants/:appId", controllers.UpdateGrants)
+
+	// Register routes for /v1/audit.
+	audit := route.Group("/audit", appmiddleware.PrincipalProtected(), appmiddleware.Superuser())
+	audit.Get("/", controllers.GetAuditEvents)
+	audit.Get("/export", controllers.ExportAuditEvents)
+
+	// Register route for /v1/trash.
+	route.Get("/trash", appmiddleware.PrincipalProtected(), appmiddleware.Superuser(), controllers.GetTrash)
+
+	// Register route for /v1/resolve.
+	route.Get("/resolve", appmiddleware.PrincipalProtected(), appmiddleware.Superuser(), func(c *fiber.Ctx) error {
+		return controllers.ResolveHost(c, enums.Private)
+	})
+
+	// Permissions on the app of the domain of the id parameter.
+	readDomain := appmiddleware.Authorize(appmiddleware.AppOfDomainParam, enums.ReadPrivate)
+	writeDomain := appmiddleware.Authorize(appmiddleware.AppOfDomainParam, enums.WriteSettings)
+	manageDomain := appmiddleware.Authorize(appmiddleware.AppOfDomainParam, enums.ManageDomains)
 
 	// Register CRUD routes for /v1/domains.
-	domains := route.Group("/domains", middleware.MachineProtected())
-	domains.Post("/", controllers.CreateDomain)
-	domains.Get("/settings", func(c *fiber.Ctx) error {
+	domains := route.Group("/domains", appmiddleware.PrincipalProtected())
+	domains.Get("/", appmiddleware.Authorize(appmiddleware.AppFromIDQuery, enums.ReadPrivate), controllers.GetDomains)
+	domains.Post("/", appmiddleware.Authorize(appmiddleware.AppFromBody, enums.ManageDomains, enums.WriteSettings), controllers.CreateDomain)
+	domains.Get("/settings", appmiddleware.Authorize(appmiddleware.AppFromNameQuery, enums.ReadPrivate), func(c *fiber.Ctx) error {
 		return controllers.GetSettingsByDomainName(c, enums.Private)
 	})
-	domains.Get("/:id", controllers.GetDomain)
-	domains.Put("/:id", controllers.UpdateDomain)
-	domains.Delete("/:id", controllers.DeleteDomain)
-	domains.Put("/:id/restore", controllers.RestoreDomain)
-	domains.Get("/:id/settings", func(c *fiber.Ctx) error {
+	domains.Get("/:id", readDomain, controllers.GetDomain)
+	domains.Put("/:id", appmiddleware.Authorize(appmiddleware.AppOfDomainParam, enums.ManageDomains, enums.WriteSettings), controllers.UpdateDomain)
+	domains.Delete("/:id", manageDomain, controllers.DeleteDomain)
+	domains.Put("/:id/restore", manageDomain, controllers.RestoreDomain)
+	domains.Post("/:id/verify", manageDomain, controllers.VerifyDomain)
+	domains.Get("/:id/settings", readDomain, func(c *fiber.Ctx) error {
 		return controllers.GetSettingsByDomainID(c, enums.Private)
 	})
+	domains.Get("/:id/settings/stream", readDomain, func(c *fiber.Ctx) error {
+		return controllers.StreamSettingsByDomainID(c, enums.Private)
+	})
+	domains.Put("/:id/settings/:name", writeDomain, controllers.UpsertDomainSetting)
+	domains.Delete("/:id/settings/:name", writeDomain, controllers.DeleteDomainSetting)
+	domains.Get("/:id/settings/revisions", readDomain, controllers.GetDomainSettingRevisions)
+	domains.Get("/:id/settings/revisions/:rev", readDomain, controllers.GetDomainSettingRevision)
+	domains.Post("/:id/settings/revisions/:rev/rollback", writeDomain, controllers.RollbackDomainSettings)
+	domains.Get("/:id/settings/:name/reveal", readDomain, appmiddleware.SecretRevealProtected(), controllers.RevealDomainSetting)
 }
@@ -11,8 +11,14 @@ func PublicRoutes(a *fiber.App) {
 	// Create private routes group.
 	route := a.Group("/v1")
 
+	// Register route for /v1/health.
+	route.Get("/health", controllers.GetHealth)
+
 	// Register routes for /v1/settings.
 	settings := route.Group("/settings")
+	settings.Get("/resolve", func(c *fiber.Ctx) error {
+		return controllers.ResolveHost(c, enums.Public)
+	})
 
 	// Register routes for /v1/settings/apps.
 	apps := settings.Group("/apps")
@@ -22,6 +28,9 @@ func PublicRoutes(a *fiber.App) {
 	apps.Get("/:id", func(c *fiber.Ctx) error {
 		return controllers.GetSettingsByAppID(c, enums.Public)
 	})
+	apps.Get("/:id/stream", func(c *fiber.Ctx) error {
+		return controllers.StreamSettingsByAppID(c, enums.Public)
+	})
 
 	// Register routes for /v1/settings/domains.
 	domains := settings.Group("/domains")
@@ -31,4 +40,7 @@ func PublicRoutes(a *fiber.App) {
 	domains.Get("/:id", func(c *fiber.Ctx) error {
 		return controllers.GetSettingsByDomainID(c, enums.Public)
 	})
+	domains.Get("/:id/stream", func(c *fiber.Ctx) error {
+		return controllers.StreamSettingsByDomainID(c, enums.Public)
+	})
 }
@@ -0,0 +1,94 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// KeyProvider wraps and unwraps the data keys that encrypt the secrets.
+// The local provider wraps them with a master key from the environment,
+// a KMS can be plugged in by implementing this interface and passing it to SetKeyProvider.
+type KeyProvider interface {
+	// WrapKey encrypts a data key.
+	WrapKey(dataKey []byte) ([]byte, error)
+	// UnwrapKey decrypts a data key that was encrypted by WrapKey.
+	UnwrapKey(wrappedKey []byte) ([]byte, error)
+}
+
+// LocalKeyProvider wraps the data keys with AES-256-GCM and a master key.
+type LocalKeyProvider struct {
+	aead cipher.AEAD
+}
+
+// NewLocalKeyProvider returns a LocalKeyProvider with a 32 byte master key.
+func NewLocalKeyProvider(masterKey []byte) (*LocalKeyProvider, error) {
+	if len(masterKey) != 32 {
+		return nil, fmt.Errorf("the master key must be 32 bytes, got %d", len(masterKey))
+	}
+
+	aead, err := newAEAD(masterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LocalKeyProvider{aead: aead}, nil
+}
+
+// NewLocalKeyProviderFromEnv returns a LocalKeyProvider with the base64 encoded master key of SECRET_MASTER_KEY.
+func NewLocalKeyProviderFromEnv() (*LocalKeyProvider, error) {
+	encoded := os.Getenv("SECRET_MASTER_KEY")
+	if encoded == "" {
+		return nil, errors.New("SECRET_MASTER_KEY is not configured")
+	}
+
+	masterKey, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("SECRET_MASTER_KEY is not valid base64: %v", err)
+	}
+
+	return NewLocalKeyProvider(masterKey)
+}
+
+// WrapKey encrypts a data key with the master key.
+func (p *LocalKeyProvider) WrapKey(dataKey []byte) ([]byte, error) {
+	return seal(p.aead, dataKey)
+}
+
+// UnwrapKey decrypts a data key with the master key.
+func (p *LocalKeyProvider) UnwrapKey(wrappedKey []byte) ([]byte, error) {
+	return open(p.aead, wrappedKey)
+}
+
+// newAEAD returns AES-GCM with the given key.
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts the plaintext with a random nonce, which is prepended to the ciphertext.
+func seal(aead cipher.AEAD, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// open decrypts a ciphertext that was encrypted by seal.
+func open(aead cipher.AEAD, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("the ciphertext is too short")
+	}
+
+	return aead.Open(nil, ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():], nil)
+}
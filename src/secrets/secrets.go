@@ -0,0 +1,120 @@
+package secrets
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// Mask replaces the value of a secret in every response, except the reveal endpoints.
+const Mask = "********"
+
+// prefix marks a value that is encrypted by Encrypt, and the version of its format.
+const prefix = "enc:v1:"
+
+// ErrNotEncrypted is returned when a value is decrypted that was not encrypted by Encrypt.
+var ErrNotEncrypted = errors.New("the value is not encrypted")
+
+var (
+	providerMutex sync.Mutex
+	provider      KeyProvider
+)
+
+// SetKeyProvider replaces the provider that wraps the data keys, like a KMS.
+// Without it, the LocalKeyProvider with SECRET_MASTER_KEY is used.
+func SetKeyProvider(keyProvider KeyProvider) {
+	providerMutex.Lock()
+	defer providerMutex.Unlock()
+
+	provider = keyProvider
+}
+
+// getKeyProvider returns the provider that wraps the data keys, and creates the local provider on first use.
+func getKeyProvider() (KeyProvider, error) {
+	providerMutex.Lock()
+	defer providerMutex.Unlock()
+
+	if provider == nil {
+		localProvider, err := NewLocalKeyProviderFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		provider = localProvider
+	}
+
+	return provider, nil
+}
+
+// Encrypt encrypts a value with envelope encryption: the value is encrypted with a new random data key,
+// which is stored next to it after it is wrapped by the key provider.
+func Encrypt(plaintext string) (string, error) {
+	keyProvider, err := getKeyProvider()
+	if err != nil {
+		return "", err
+	}
+
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := seal(aead, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	wrappedKey, err := keyProvider.WrapKey(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + base64.StdEncoding.EncodeToString(wrappedKey) + ":" + base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// Decrypt decrypts a value that was encrypted by Encrypt.
+func Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return "", ErrNotEncrypted
+	}
+	wrappedKeyPart, ciphertextPart, found := strings.Cut(strings.TrimPrefix(value, prefix), ":")
+	if !found {
+		return "", ErrNotEncrypted
+	}
+
+	wrappedKey, err := base64.StdEncoding.DecodeString(wrappedKeyPart)
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(ciphertextPart)
+	if err != nil {
+		return "", err
+	}
+
+	keyProvider, err := getKeyProvider()
+	if err != nil {
+		return "", err
+	}
+	dataKey, err := keyProvider.UnwrapKey(wrappedKey)
+	if err != nil {
+		return "", err
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(aead, ciphertext)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+// IsEncrypted returns true when the value is encrypted by Encrypt.
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
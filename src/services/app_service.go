@@ -1,12 +1,15 @@
 package services
 
 import (
+	"api-app/main/src/cache"
 	"api-app/main/src/database"
 	"api-app/main/src/dto/requests"
 	"api-app/main/src/enums"
 	"api-app/main/src/models"
 	"api-app/main/src/utils"
 	"database/sql"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 // IsAppAvailable method to check if an app is available.
@@ -42,9 +45,15 @@ func IsAppDeleted(id uint) (bool, error) {
 }
 
 // GetAppIDByDomainID method to get the app ID by domain ID.
-func GetAppIDByDomainID(domainID uint) (uint, error) {
+func GetAppIDByDomainID(domainID uint, unscoped ...bool) (uint, error) {
 	var appID uint
-	if result := database.Pg.Model(&models.Domain{}).
+	query := database.Pg
+
+	if len(unscoped) > 0 && unscoped[0] {
+		query = query.Unscoped()
+	}
+
+	if result := query.Model(&models.Domain{}).
 		Select("app_id").
 		Where("id = ?", domainID).
 		Scan(&appID); result.Error != nil {
@@ -70,8 +79,57 @@ func GetAppById(id uint, unscoped ...bool) (*models.App, error) {
 	return app, nil
 }
 
+// GetExpandedAppById method to get an app by its ID, with only the included relations preloaded.
+func GetExpandedAppById(id uint, includes map[string]bool) (*models.App, error) {
+	app := &models.App{}
+
+	if result := database.Pg.Scopes(PreloadAppIncludes(includes)).Find(app, "id = ?", id); result.Error != nil {
+		return nil, result.Error
+	}
+
+	return app, nil
+}
+
+// PreloadAppIncludes returns a scope that preloads the included relations of apps.
+func PreloadAppIncludes(includes map[string]bool) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if includes["settings"] {
+			db = db.Preload("Settings")
+		}
+		if includes["domains.settings"] {
+			db = db.Preload("Domains.Settings")
+		} else if includes["domains"] {
+			db = db.Preload("Domains")
+		}
+
+		return db
+	}
+}
+
+// CountAppSettings method to count the settings of the apps, by app ID.
+func CountAppSettings(appIDs []uint) (map[uint]int64, error) {
+	var rows []struct {
+		AppID uint
+		Count int64
+	}
+	if result := database.Pg.Model(&models.AppSetting{}).
+		Select("app_id, COUNT(*) AS count").
+		Where("app_id IN ?", appIDs).
+		Group("app_id").
+		Find(&rows); result.Error != nil {
+		return nil, result.Error
+	}
+
+	counts := make(map[uint]int64, len(rows))
+	for i := range rows {
+		counts[rows[i].AppID] = rows[i].Count
+	}
+
+	return counts, nil
+}
+
 // CreateApp method to create an app.
-func CreateApp(request *requests.CreateApp) (*models.App, error) {
+func CreateApp(request *requests.CreateApp, actor *utils.Actor) (*models.App, error) {
 	app := models.App{
 		Name:     request.Name,
 		Settings: make([]models.AppSetting, len(request.Settings)),
@@ -80,15 +138,23 @@ func CreateApp(request *requests.CreateApp) (*models.App, error) {
 
 	for i := range request.Settings {
 		app.Settings[i] = models.AppSetting{
-			Name:      request.Settings[i].Name,
-			Level:     enums.Level(request.Settings[i].Level),
-			Value:     request.Settings[i].Value,
-			ValueType: enums.ValueType(request.Settings[i].ValueType),
+			Name:        request.Settings[i].Name,
+			Level:       enums.Level(request.Settings[i].Level),
+			Value:       request.Settings[i].Value,
+			ValueType:   enums.ValueType(request.Settings[i].ValueType),
+			Environment: request.Settings[i].Environment,
+			Locked:      request.Settings[i].Locked,
+		}
+		if err := sealAppSetting(&app.Settings[i], nil); err != nil {
+			return nil, err
 		}
 	}
 
 	for i := range request.Domains {
-		subdomain, secondLevelDomain, topLevelDomain := utils.ExtractDomain(request.Domains[i].Name)
+		subdomain, secondLevelDomain, topLevelDomain, err := utils.ExtractDomain(request.Domains[i].Name)
+		if err != nil {
+			return nil, err
+		}
 
 		app.Domains[i] = models.Domain{
 			SSL:         request.Domains[i].SSL,
@@ -98,24 +164,78 @@ func CreateApp(request *requests.CreateApp) (*models.App, error) {
 			TopLevel:    topLevelDomain,
 			IpAddress:   request.Domains[i].IpAddress,
 		}
+		if err := resetDomainVerification(&app.Domains[i]); err != nil {
+			return nil, err
+		}
 	}
 
-	if result := database.Pg.Create(&app); result.Error != nil {
+	// Start a new transaction
+	tx := database.Pg.Begin()
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	if result := tx.Create(&app); result.Error != nil {
+		tx.Rollback()
 		return nil, result.Error
 	}
 
+	if err := recordEvent(tx, enums.AppCreated, app.ID, 0, AppEvent{ID: app.ID, Name: app.Name}); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := recordAppAudit(tx, actor, enums.AuditAppCreated, app.ID, nil, &AppEvent{ID: app.ID, Name: app.Name}); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	for i := range app.Domains {
+		if err := recordDomainEvent(tx, enums.DomainAdded, &app.Domains[i], nil); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if err := recordDomainAudit(tx, actor, enums.AuditDomainCreated, &app.Domains[i], nil, newDomainEvent(&app.Domains[i], nil)); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if _, err := createAppSettingRevision(tx, app.ID, nil, app.Settings, actor, 0); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// Delete the cached lookups of the app and its domains from before they existed.
+	_ = cache.Invalidate(appCacheTags(&app)...)
+	_ = publishSettingsChanged(app.ID, 0)
+
 	return &app, nil
 }
 
 // UpdateApp method to update an app.
-func UpdateApp(oldApp *models.App, request *requests.UpdateApp) (*models.App, error) {
+// The change is based on the given version of the app, and on the versions of its domains in the request.
+func UpdateApp(oldApp *models.App, version uint, request *requests.UpdateApp, actor *utils.Actor) (*models.App, error) {
 	// Start a new transaction
 	tx := database.Pg.Begin()
 	if tx.Error != nil {
 		return nil, tx.Error
 	}
 
+	// Check the version the change is based on, the app stays locked until the change is committed.
+	if err := checkVersion(tx, &models.App{}, oldApp.ID, version); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	oldApp.Version = version + 1
+
+	oldName := oldApp.Name
 	oldApp.Name = request.Name
+	oldSettings := oldApp.Settings
 
 	for i := range oldApp.Settings {
 		// Delete old settings.
@@ -128,11 +248,17 @@ func UpdateApp(oldApp *models.App, request *requests.UpdateApp) (*models.App, er
 	oldApp.Settings = make([]models.AppSetting, len(request.Settings))
 	for i := range request.Settings {
 		oldApp.Settings[i] = models.AppSetting{
-			AppID:     oldApp.ID,
-			Name:      request.Settings[i].Name,
-			Level:     enums.Level(request.Settings[i].Level),
-			Value:     request.Settings[i].Value,
-			ValueType: enums.ValueType(request.Settings[i].ValueType),
+			AppID:       oldApp.ID,
+			Name:        request.Settings[i].Name,
+			Level:       enums.Level(request.Settings[i].Level),
+			Value:       request.Settings[i].Value,
+			ValueType:   enums.ValueType(request.Settings[i].ValueType),
+			Environment: request.Settings[i].Environment,
+			Locked:      request.Settings[i].Locked,
+		}
+		if err := sealAppSetting(&oldApp.Settings[i], oldSettings); err != nil {
+			tx.Rollback()
+			return nil, err
 		}
 	}
 
@@ -145,12 +271,38 @@ func UpdateApp(oldApp *models.App, request *requests.UpdateApp) (*models.App, er
 	}
 
 	// Iterate through old domains to update or mark as deleted.
+	oldDomainsCount := len(oldApp.Domains)
 	for i := range oldApp.Domains {
 		oldDomain := &oldApp.Domains[i]
 		if newDomain, exists := newDomainsMap[oldDomain.ID]; exists {
 			// Update existing domain.
+			before := newDomainEvent(oldDomain, nil)
+			eventType, auditAction := enums.DomainUpdated, enums.AuditDomainUpdated
+			if oldDomain.DeletedAt.Valid {
+				oldDomain.Version++
+			} else {
+				if err := checkVersion(tx, &models.Domain{}, oldDomain.ID, newDomain.Version); err != nil {
+					tx.Rollback()
+					return nil, err
+				}
+				oldDomain.Version = newDomain.Version + 1
+			}
+			var oldDomainName *string
+			if oldDomain.Name != newDomain.Name {
+				previousName := oldDomain.Name
+				oldDomainName = &previousName
+				if err := resetDomainVerification(oldDomain); err != nil {
+					tx.Rollback()
+					return nil, err
+				}
+			}
 			oldDomain.SSL = newDomain.SSL
-			subdomain, secondLevelDomain, topLevelDomain := utils.ExtractDomain(newDomain.Name)
+			oldDomain.Name = newDomain.Name
+			subdomain, secondLevelDomain, topLevelDomain, err := utils.ExtractDomain(newDomain.Name)
+			if err != nil {
+				tx.Rollback()
+				return nil, err
+			}
 			oldDomain.Sub = sql.NullString{String: subdomain, Valid: subdomain != ""}
 			oldDomain.SecondLevel = secondLevelDomain
 			oldDomain.TopLevel = topLevelDomain
@@ -159,6 +311,8 @@ func UpdateApp(oldApp *models.App, request *requests.UpdateApp) (*models.App, er
 			// Restore if it was previously deleted.
 			if oldDomain.DeletedAt.Valid {
 				oldDomain.DeletedAt.Valid = false
+				oldDomain.DeletionBatch = sql.NullString{}
+				eventType, auditAction = enums.DomainRestored, enums.AuditDomainRestored
 			}
 
 			if result := tx.Save(&oldDomain); result.Error != nil {
@@ -166,28 +320,46 @@ func UpdateApp(oldApp *models.App, request *requests.UpdateApp) (*models.App, er
 				return nil, result.Error
 			}
 
+			if err := recordDomainEvent(tx, eventType, oldDomain, oldDomainName); err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+			if err := recordDomainAudit(tx, actor, auditAction, oldDomain, before, newDomainEvent(oldDomain, nil)); err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+
 			// Remove from newDomainsMap as it is already processed.
 			delete(newDomainsMap, oldDomain.ID)
-		} else {
+		} else if !oldDomain.DeletedAt.Valid {
 			// Mark as deleted if not in new domains
-			if result := tx.Delete(&oldDomain); result.Error != nil {
+			if err := deleteDomains(tx, oldApp.Domains[i:i+1], uuid.NewString(), actor); err != nil {
 				tx.Rollback()
-				return nil, result.Error
+				return nil, err
 			}
 		}
 	}
 
 	// Add new domains that were not in old domains.
 	for _, newDomain := range newDomainsMap {
-		subdomain, secondLevelDomain, topLevelDomain := utils.ExtractDomain(newDomain.Name)
-		oldApp.Domains = append(oldApp.Domains, models.Domain{
+		subdomain, secondLevelDomain, topLevelDomain, err := utils.ExtractDomain(newDomain.Name)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		domain := models.Domain{
 			SSL:         newDomain.SSL,
 			Name:        newDomain.Name,
 			Sub:         sql.NullString{String: subdomain, Valid: subdomain != ""},
 			SecondLevel: secondLevelDomain,
 			TopLevel:    topLevelDomain,
 			IpAddress:   newDomain.IpAddress,
-		})
+		}
+		if err := resetDomainVerification(&domain); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		oldApp.Domains = append(oldApp.Domains, domain)
 	}
 
 	if result := tx.Save(oldApp); result.Error != nil {
@@ -195,13 +367,45 @@ func UpdateApp(oldApp *models.App, request *requests.UpdateApp) (*models.App, er
 		return nil, result.Error
 	}
 
+	// Record the events of the app and of the new domains, which have IDs after saving.
+	if err := recordEvent(tx, enums.AppUpdated, oldApp.ID, 0, AppEvent{ID: oldApp.ID, Name: oldApp.Name}); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if oldName != oldApp.Name {
+		if err := recordEvent(tx, enums.AppRenamed, oldApp.ID, 0, AppEvent{ID: oldApp.ID, Name: oldApp.Name, OldName: &oldName}); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	if err := recordAppAudit(tx, actor, enums.AuditAppUpdated, oldApp.ID, &AppEvent{ID: oldApp.ID, Name: oldName}, &AppEvent{ID: oldApp.ID, Name: oldApp.Name}); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	for i := oldDomainsCount; i < len(oldApp.Domains); i++ {
+		if err := recordDomainEvent(tx, enums.DomainAdded, &oldApp.Domains[i], nil); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if err := recordDomainAudit(tx, actor, enums.AuditDomainCreated, &oldApp.Domains[i], nil, newDomainEvent(&oldApp.Domains[i], nil)); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if _, err := createAppSettingRevision(tx, oldApp.ID, oldSettings, oldApp.Settings, actor, 0); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
 	// Commit the transaction
 	if err := tx.Commit().Error; err != nil {
 		tx.Rollback()
 		return nil, err
 	}
 
-	_ = deleteAppSettingsCache(oldApp.ID, request.Name)
+	_ = cache.Invalidate(appCacheTags(oldApp, oldName)...)
+	_ = publishSettingsChanged(oldApp.ID, 0)
 
 	// Retrieve the updated app. Because new domains are added and now have IDs.
 	newApp, err := GetAppById(oldApp.ID)
@@ -213,32 +417,133 @@ func UpdateApp(oldApp *models.App, request *requests.UpdateApp) (*models.App, er
 }
 
 // DeleteApp method to delete an app.
-func DeleteApp(app *models.App) error {
-	_ = deleteAppSettingsCache(app.ID, app.Name)
+// A version of 0 deletes the app regardless of its version.
+func DeleteApp(app *models.App, version uint, actor *utils.Actor) error {
+	// Start a new transaction
+	tx := database.Pg.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
 
-	return database.Pg.Delete(app).Error
-}
+	// Check the version the delete is based on.
+	if version != 0 {
+		if err := checkVersion(tx, &models.App{}, app.ID, version); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
 
-// RestoreApp method to restore a deleted app.
-func RestoreApp(id uint) error {
-	return database.Pg.Unscoped().Model(&models.App{}).Where("id = ?", id).Update("deleted_at", nil).Error
-}
+	// The domains of the app are deleted in the same batch, so they are restored with it.
+	batch := uuid.NewString()
+	var domains []models.Domain
+	if result := tx.Where("app_id = ?", app.ID).Find(&domains); result.Error != nil {
+		tx.Rollback()
+		return result.Error
+	}
+	if err := deleteDomains(tx, domains, batch, actor); err != nil {
+		tx.Rollback()
+		return err
+	}
 
-// deleteAppSettingsCache method to delete the settings cache.
-func deleteAppSettingsCache(appID uint, appName string) error {
-	if err := DeleteAppSettingsFromCache(AppSettingsCacheKeyOnId(appID, enums.Private)); err != nil {
+	app.DeletionBatch = sql.NullString{String: batch, Valid: true}
+	if result := tx.Model(app).Update("deletion_batch", app.DeletionBatch); result.Error != nil {
+		tx.Rollback()
+		return result.Error
+	}
+
+	if result := tx.Delete(app); result.Error != nil {
+		tx.Rollback()
+		return result.Error
+	}
+
+	if err := recordEvent(tx, enums.AppDeleted, app.ID, 0, AppEvent{ID: app.ID, Name: app.Name}); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := recordAppAudit(tx, actor, enums.AuditAppDeleted, app.ID, &AppEvent{ID: app.ID, Name: app.Name}, nil); err != nil {
+		tx.Rollback()
 		return err
 	}
-	if err := DeleteAppSettingsFromCache(AppSettingsCacheKeyOnId(appID, enums.Public)); err != nil {
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
 		return err
 	}
 
-	if err := DeleteAppSettingsFromCache(AppSettingsCacheKeyOnName(appName, enums.Private)); err != nil {
+	_ = cache.Invalidate(appCacheTags(app)...)
+	_ = publishSettingsChanged(app.ID, 0)
+
+	return nil
+}
+
+// RestoreApp method to restore a deleted app.
+func RestoreApp(id uint, actor *utils.Actor) error {
+	// Start a new transaction
+	tx := database.Pg.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	app := &models.App{}
+	if result := tx.Unscoped().Find(app, "id = ?", id); result.Error != nil {
+		tx.Rollback()
+		return result.Error
+	}
+
+	// Restore the domains that were deleted together with the app.
+	if app.DeletionBatch.Valid {
+		if result := tx.Unscoped().Where("app_id = ? AND deletion_batch = ?", app.ID, app.DeletionBatch.String).Find(&app.Domains); result.Error != nil {
+			tx.Rollback()
+			return result.Error
+		}
+		if err := restoreDomains(tx, app.Domains, actor); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if result := tx.Unscoped().Model(app).Updates(map[string]interface{}{
+		"deleted_at":     nil,
+		"deletion_batch": nil,
+	}); result.Error != nil {
+		tx.Rollback()
+		return result.Error
+	}
+
+	if err := recordEvent(tx, enums.AppRestored, app.ID, 0, AppEvent{ID: app.ID, Name: app.Name}); err != nil {
+		tx.Rollback()
 		return err
 	}
-	if err := DeleteAppSettingsFromCache(AppSettingsCacheKeyOnName(appName, enums.Public)); err != nil {
+	if err := recordAppAudit(tx, actor, enums.AuditAppRestored, app.ID, nil, &AppEvent{ID: app.ID, Name: app.Name}); err != nil {
+		tx.Rollback()
 		return err
 	}
 
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_ = cache.Invalidate(appCacheTags(app)...)
+	_ = publishSettingsChanged(id, 0)
+
 	return nil
 }
+
+// appCacheTags returns the cache tags of an app, its previous names and its domains.
+// Every resolved host is included, because the domains of the app can take over hosts.
+func appCacheTags(app *models.App, previousNames ...string) []string {
+	tags := []string{AppCacheTag(app.ID), AppNameCacheTag(app.Name), ResolveCacheTag}
+	for _, name := range previousNames {
+		if name != app.Name {
+			tags = append(tags, AppNameCacheTag(name))
+		}
+	}
+	for i := range app.Domains {
+		tags = append(tags, DomainCacheTag(app.Domains[i].ID), DomainNameCacheTag(app.Domains[i].Name))
+	}
+
+	return tags
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"api-app/main/src/cache"
+	"api-app/main/src/database"
+	"api-app/main/src/enums"
+	"api-app/main/src/models"
+	"api-app/main/src/utils"
+	"database/sql"
+	"encoding/json"
+	"gorm.io/gorm"
+)
+
+// GetAppSettingRevisionById method to get a revision of the app settings by its number.
+func GetAppSettingRevisionById(appID, revision uint) (*models.AppSettingRevision, error) {
+	appSettingRevision := &models.AppSettingRevision{}
+
+	if result := database.Pg.Find(appSettingRevision, "app_id = ? AND revision = ?", appID, revision); result.Error != nil {
+		return nil, result.Error
+	}
+
+	return appSettingRevision, nil
+}
+
+// RollbackAppSettings method to restore the app settings to the state of a revision.
+// The rollback itself is stored as a new revision, so it can be undone as well.
+// Returns nil when the settings already match the revision.
+func RollbackAppSettings(app *models.App, appSettingRevision *models.AppSettingRevision, actor *utils.Actor) (*models.AppSettingRevision, error) {
+	var snapshots []SettingSnapshot
+	if err := json.Unmarshal([]byte(appSettingRevision.Settings), &snapshots); err != nil {
+		return nil, err
+	}
+
+	// Start a new transaction
+	tx := database.Pg.Begin()
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	var oldSettings []models.AppSetting
+	if result := tx.Where("app_id = ?", app.ID).Find(&oldSettings); result.Error != nil {
+		tx.Rollback()
+		return nil, result.Error
+	}
+
+	if result := tx.Where("app_id = ?", app.ID).Delete(&models.AppSetting{}); result.Error != nil {
+		tx.Rollback()
+		return nil, result.Error
+	}
+
+	newSettings := make([]models.AppSetting, len(snapshots))
+	for i := range snapshots {
+		newSettings[i] = models.AppSetting{
+			AppID:       app.ID,
+			Name:        snapshots[i].Name,
+			Level:       enums.Level(snapshots[i].Level),
+			Value:       snapshots[i].Value,
+			ValueType:   enums.ValueType(snapshots[i].ValueType),
+			Environment: snapshots[i].Environment,
+			Locked:      snapshots[i].Locked,
+		}
+	}
+	if len(newSettings) > 0 {
+		if result := tx.Create(&newSettings); result.Error != nil {
+			tx.Rollback()
+			return nil, result.Error
+		}
+	}
+
+	revision, err := createAppSettingRevision(tx, app.ID, oldSettings, newSettings, actor, appSettingRevision.Revision)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := bumpVersion(tx, &models.App{}, app.ID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	_ = cache.Invalidate(AppCacheTag(app.ID), AppNameCacheTag(app.Name))
+	_ = publishSettingsChanged(app.ID, 0)
+
+	return revision, nil
+}
+
+// createAppSettingRevision method to store a new revision of the app settings within a transaction, and adds the change to the audit log.
+// No revision is stored when the old and new settings are equal.
+// The rollbackOf parameter holds the revision that is restored, or zero for a regular change.
+func createAppSettingRevision(tx *gorm.DB, appID uint, oldSettings, newSettings []models.AppSetting, actor *utils.Actor, rollbackOf uint) (*models.AppSettingRevision, error) {
+	oldSnapshots := appSettingsToSnapshots(oldSettings)
+	snapshots := appSettingsToSnapshots(newSettings)
+	changes := diffSettingSnapshots(oldSnapshots, snapshots)
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	settings, diff, err := marshalRevision(snapshots, changes)
+	if err != nil {
+		return nil, err
+	}
+
+	// Lock the app, so concurrent changes can not claim the same revision number.
+	if result := tx.Exec("SELECT id FROM apps WHERE id = ? FOR UPDATE", appID); result.Error != nil {
+		return nil, result.Error
+	}
+
+	var lastRevision uint
+	if result := tx.Model(&models.AppSettingRevision{}).
+		Select("COALESCE(MAX(revision), 0)").
+		Where("app_id = ?", appID).
+		Scan(&lastRevision); result.Error != nil {
+		return nil, result.Error
+	}
+
+	revision := models.AppSettingRevision{
+		AppID:      appID,
+		Revision:   lastRevision + 1,
+		Actor:      actor.Name,
+		Settings:   settings,
+		Diff:       diff,
+		RollbackOf: sql.NullInt64{Int64: int64(rollbackOf), Valid: rollbackOf != 0},
+	}
+
+	if result := tx.Create(&revision); result.Error != nil {
+		return nil, result.Error
+	}
+
+	if err := recordEvent(tx, enums.SettingsChanged, appID, 0, SettingsEvent{
+		AppID:    appID,
+		Revision: revision.Revision,
+		Changes:  newSettingsEventChanges(changes),
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := recordAudit(tx, actor, enums.AuditSettingsChanged, appID, 0, AppAuditTarget(appID), oldSnapshots, snapshots); err != nil {
+		return nil, err
+	}
+
+	return &revision, nil
+}
@@ -3,143 +3,205 @@ package services
 import (
 	"api-app/main/src/cache"
 	"api-app/main/src/database"
+	"api-app/main/src/dto/requests"
 	"api-app/main/src/enums"
 	"api-app/main/src/models"
-	"context"
-	"encoding/json"
+	"api-app/main/src/utils"
 	"fmt"
-	"github.com/valkey-io/valkey-go"
-	"os"
-	"time"
+	"gorm.io/gorm/clause"
 )
 
 // GetAppSettingsByName method to get settings by app name.
-func GetAppSettingsByName(appName string, level enums.Level) (*[]models.AppSetting, error) {
-	var settings []models.AppSetting
-	cacheKey := AppSettingsCacheKeyOnName(appName, level)
-
-	if inCache, err := IsAppSettingsInCache(cacheKey); err != nil {
-		return nil, err
-	} else if inCache {
-		if cacheSettings, err := GetAppSettingsFromCache(cacheKey); err != nil {
-			return nil, err
-		} else if cacheSettings != nil && len(*cacheSettings) > 0 {
-			settings = *cacheSettings
+// The settings of the environment follow the base settings they override, so they take precedence.
+// It returns nil when the app or the environment does not exist.
+func GetAppSettingsByName(appName string, level enums.Level, environment string) (*[]models.AppSetting, error) {
+	settings, found, err := cache.Load(AppSettingsCacheKeyOnName(appName, level, environment), func(tags *cache.Tags) ([]models.AppSetting, bool, error) {
+		tags.Add(AppNameCacheTag(appName))
+
+		var appID uint
+		if result := database.Pg.Model(&models.App{}).
+			Where("name = ?", appName).
+			Select("id").
+			Scan(&appID); result.Error != nil || appID == 0 {
+			return nil, false, result.Error
 		}
-	}
+		tags.Add(AppCacheTag(appID))
 
-	if len(settings) == 0 {
-		if result := database.Pg.Model(&models.AppSetting{}).
-			Joins("JOIN apps ON apps.id = app_settings.app_id").
-			Where("apps.name = ? AND (level = 'both' OR level = ?)", appName, level.String()).
-			Find(&settings); result.Error != nil {
-			return nil, result.Error
-		}
-		_ = SetAppSettingsToCache(cacheKey, &settings)
+		return findAppSettings(appID, level, environment)
+	})
+	if err != nil || !found {
+		return nil, err
 	}
 
 	return &settings, nil
 }
 
 // GetAppSettingsByAppID method to get settings by app ID.
-func GetAppSettingsByAppID(appID uint, level enums.Level) (*[]models.AppSetting, error) {
-	var settings []models.AppSetting
-	cacheKey := AppSettingsCacheKeyOnId(appID, level)
+// The settings of the environment follow the base settings they override, so they take precedence.
+// It returns nil when the app or the environment does not exist.
+func GetAppSettingsByAppID(appID uint, level enums.Level, environment string) (*[]models.AppSetting, error) {
+	settings, found, err := cache.Load(AppSettingsCacheKeyOnId(appID, level, environment), func(tags *cache.Tags) ([]models.AppSetting, bool, error) {
+		tags.Add(AppCacheTag(appID))
+
+		var count int64
+		if result := database.Pg.Model(&models.App{}).
+			Where("id = ?", appID).
+			Count(&count); result.Error != nil || count == 0 {
+			return nil, false, result.Error
+		}
 
-	if inCache, err := IsAppSettingsInCache(cacheKey); err != nil {
+		return findAppSettings(appID, level, environment)
+	})
+	if err != nil || !found {
 		return nil, err
-	} else if inCache {
-		if cacheSettings, err := GetAppSettingsFromCache(cacheKey); err != nil {
-			return nil, err
-		} else if cacheSettings != nil && len(*cacheSettings) > 0 {
-			settings = *cacheSettings
-		}
 	}
 
-	if len(settings) == 0 {
-		if result := database.Pg.Model(&models.AppSetting{}).
-			Where("app_id = ? AND (level = 'both' OR level = ?)", appID, level.String()).
-			Find(&settings); result.Error != nil {
-			return nil, result.Error
+	return &settings, nil
+}
+
+// findAppSettings method to find the settings of an app that exists, in the environment when it is not empty.
+func findAppSettings(appID uint, level enums.Level, environment string) ([]models.AppSetting, bool, error) {
+	if environment != "" {
+		if exists, err := IsEnvironmentAvailable(appID, environment); err != nil || !exists {
+			return nil, false, err
 		}
-		_ = SetAppSettingsToCache(cacheKey, &settings)
 	}
 
-	return &settings, nil
+	settings := make([]models.AppSetting, 0)
+	if result := database.Pg.Model(&models.AppSetting{}).
+		Where("app_id = ? AND environment IN ('', ?) AND (level = 'both' OR level = ?)", appID, environment, level.String()).
+		Order("environment <> ''").
+		Find(&settings); result.Error != nil {
+		return nil, false, result.Error
+	}
+
+	return settings, true, nil
 }
 
-// IsAppSettingsInCache checks if the settings exists in the cache.
-func IsAppSettingsInCache(key string) (bool, error) {
-	result := cache.Valkey.Do(context.Background(), cache.Valkey.B().Exists().Key(key).Build())
-	if result.Error() != nil {
-		return false, result.Error()
+// UpsertAppSetting method to create or update a single setting of an app.
+// The setting is identified by its name, level and environment.
+func UpsertAppSetting(app *models.App, request *requests.AppSetting, actor *utils.Actor) (*models.AppSetting, error) {
+	setting := models.AppSetting{
+		AppID:       app.ID,
+		Name:        request.Name,
+		Level:       enums.Level(request.Level),
+		Value:       request.Value,
+		ValueType:   enums.ValueType(request.ValueType),
+		Environment: request.Environment,
+		Locked:      request.Locked,
 	}
 
-	value, err := result.ToInt64()
-	if err != nil {
-		return false, err
+	// Start a new transaction
+	tx := database.Pg.Begin()
+	if tx.Error != nil {
+		return nil, tx.Error
 	}
 
-	return value == 1, nil
-}
+	var oldSettings []models.AppSetting
+	if result := tx.Where("app_id = ?", app.ID).Find(&oldSettings); result.Error != nil {
+		tx.Rollback()
+		return nil, result.Error
+	}
+
+	if err := sealAppSetting(&setting, oldSettings); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if result := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "app_id"}, {Name: "name"}, {Name: "level"}, {Name: "environment"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "value_type", "locked"}),
+	}).Create(&setting); result.Error != nil {
+		tx.Rollback()
+		return nil, result.Error
+	}
 
-// GetAppSettingsFromCache gets the settings from the cache.
-func GetAppSettingsFromCache(key string) (*[]models.AppSetting, error) {
-	result := cache.Valkey.Do(context.Background(), cache.Valkey.B().Get().Key(key).Build())
-	if result.Error() != nil {
-		return nil, result.Error()
+	newSettings := make([]models.AppSetting, 0, len(oldSettings)+1)
+	for i := range oldSettings {
+		if oldSettings[i].Name != setting.Name || oldSettings[i].Level != setting.Level || oldSettings[i].Environment != setting.Environment {
+			newSettings = append(newSettings, oldSettings[i])
+		}
 	}
+	newSettings = append(newSettings, setting)
 
-	value, err := result.ToString()
-	if err != nil {
+	if _, err := createAppSettingRevision(tx, app.ID, oldSettings, newSettings, actor, 0); err != nil {
+		tx.Rollback()
 		return nil, err
 	}
 
-	var settings []models.AppSetting
-	if err := json.Unmarshal([]byte(value), &settings); err != nil {
+	if err := bumpVersion(tx, &models.App{}, app.ID); err != nil {
+		tx.Rollback()
 		return nil, err
 	}
 
-	return &settings, nil
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	_ = cache.Invalidate(AppCacheTag(app.ID), AppNameCacheTag(app.Name))
+	_ = publishSettingsChanged(app.ID, 0)
+
+	return &setting, nil
 }
 
-// SetAppSettingsToCache sets the settings to the cache.
-func SetAppSettingsToCache(key string, settings *[]models.AppSetting) error {
-	value, err := json.Marshal(settings)
-	if err != nil {
-		return err
+// DeleteAppSetting method to delete a single setting of an app.
+// An empty environment deletes the base setting.
+func DeleteAppSetting(app *models.App, name string, level enums.Level, environment string, actor *utils.Actor) error {
+	// Start a new transaction
+	tx := database.Pg.Begin()
+	if tx.Error != nil {
+		return tx.Error
 	}
 
-	expiration := os.Getenv("VALKEY_EXPIRATION")
-	duration, err := time.ParseDuration(expiration)
-	if err != nil {
-		return err
+	var oldSettings []models.AppSetting
+	if result := tx.Where("app_id = ?", app.ID).Find(&oldSettings); result.Error != nil {
+		tx.Rollback()
+		return result.Error
 	}
 
-	result := cache.Valkey.Do(context.Background(), cache.Valkey.B().Set().Key(key).Value(valkey.BinaryString(value)).Ex(duration).Build())
-	if result.Error() != nil {
-		return result.Error()
+	if result := tx.Where("app_id = ? AND name = ? AND level = ? AND environment = ?", app.ID, name, level.String(), environment).
+		Delete(&models.AppSetting{}); result.Error != nil {
+		tx.Rollback()
+		return result.Error
 	}
 
-	return nil
-}
+	newSettings := make([]models.AppSetting, 0, len(oldSettings))
+	for i := range oldSettings {
+		if oldSettings[i].Name != name || oldSettings[i].Level != level || oldSettings[i].Environment != environment {
+			newSettings = append(newSettings, oldSettings[i])
+		}
+	}
+
+	if _, err := createAppSettingRevision(tx, app.ID, oldSettings, newSettings, actor, 0); err != nil {
+		tx.Rollback()
+		return err
+	}
 
-// DeleteAppSettingsFromCache deletes an existing setting from the cache.
-func DeleteAppSettingsFromCache(key string) error {
-	result := cache.Valkey.Do(context.Background(), cache.Valkey.B().Del().Key(key).Build())
-	if result.Error() != nil {
-		return result.Error()
+	if err := bumpVersion(tx, &models.App{}, app.ID); err != nil {
+		tx.Rollback()
+		return err
 	}
 
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_ = cache.Invalidate(AppCacheTag(app.ID), AppNameCacheTag(app.Name))
+	_ = publishSettingsChanged(app.ID, 0)
+
 	return nil
 }
 
 // AppSettingsCacheKeyOnName returns the key for the settings cache with a name.
-func AppSettingsCacheKeyOnName(appName string, level enums.Level) string {
-	return fmt.Sprintf("%s:settings:%s", appName, level.String())
+func AppSettingsCacheKeyOnName(appName string, level enums.Level, environment string) string {
+	return fmt.Sprintf("%s:settings:%s", appName, level.String()) + environmentCacheKeySuffix(environment)
 }
 
 // AppSettingsCacheKeyOnId returns the key for the settings cache with an id.
-func AppSettingsCacheKeyOnId(appID uint, level enums.Level) string {
-	return fmt.Sprintf("settings:apps:%d:%s", appID, level.String())
+func AppSettingsCacheKeyOnId(appID uint, level enums.Level, environment string) string {
+	return fmt.Sprintf("settings:apps:%d:%s", appID, level.String()) + environmentCacheKeySuffix(environment)
 }
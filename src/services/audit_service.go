@@ -0,0 +1,138 @@
+package services
+
+import (
+	"api-app/main/src/database"
+	"api-app/main/src/dto/requests"
+	"api-app/main/src/enums"
+	"api-app/main/src/models"
+	"api-app/main/src/utils"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"gorm.io/gorm"
+)
+
+// auditBatchSize is the number of audit events that is loaded per batch of an export.
+const auditBatchSize = 500
+
+// AuditListQuery returns a query of the audit events that match the filters of the audit listing.
+func AuditListQuery(filter *requests.GetAuditEvents) *gorm.DB {
+	query := database.Pg.Model(&models.AuditEvent{})
+
+	if filter.Actor != "" {
+		query = query.Where("actor = ?", filter.Actor)
+	}
+	if filter.ClaimedActor != "" {
+		query = query.Where("claimed_actor = ?", filter.ClaimedActor)
+	}
+	if filter.RequestID != "" {
+		query = query.Where("request_id = ?", filter.RequestID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.Target != "" {
+		query = query.Where("target = ?", filter.Target)
+	}
+	if filter.AppID != nil {
+		query = query.Where("app_id = ?", *filter.AppID)
+	}
+	if filter.DomainID != nil {
+		query = query.Where("domain_id = ?", *filter.DomainID)
+	}
+	if filter.From != "" {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if filter.To != "" {
+		query = query.Where("created_at < ?", filter.To)
+	}
+
+	return query
+}
+
+// ExportAuditEvents method to pass the audit events that match the filters to the write function, oldest first, in batches.
+func ExportAuditEvents(filter *requests.GetAuditEvents, write func(events []models.AuditEvent) error) error {
+	var events []models.AuditEvent
+
+	return AuditListQuery(filter).
+		Order("id").
+		FindInBatches(&events, auditBatchSize, func(_ *gorm.DB, _ int) error {
+			return write(events)
+		}).Error
+}
+
+// recordAudit method to add an event to the audit log within the transaction of the mutation.
+// The before and after states are stored as JSON, and are nil when the target did not exist before or after the mutation.
+func recordAudit(tx *gorm.DB, actor *utils.Actor, action enums.AuditAction, appID, domainID uint, target string, before, after interface{}) error {
+	beforeJSON, err := marshalAuditState(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := marshalAuditState(after)
+	if err != nil {
+		return err
+	}
+
+	event := models.AuditEvent{
+		Actor:        actor.Name,
+		ClaimedActor: actor.ClaimedName,
+		IpAddress:    actor.IpAddress,
+		RequestID:    actor.RequestID,
+		Action:       action,
+		Target:       target,
+		AppID:        sql.NullInt64{Int64: int64(appID), Valid: appID != 0},
+		DomainID:     sql.NullInt64{Int64: int64(domainID), Valid: domainID != 0},
+		Before:       beforeJSON,
+		After:        afterJSON,
+	}
+
+	return tx.Create(&event).Error
+}
+
+// recordAppAudit method to add an event of an app to the audit log within the transaction of the mutation.
+func recordAppAudit(tx *gorm.DB, actor *utils.Actor, action enums.AuditAction, appID uint, before, after *AppEvent) error {
+	return recordAudit(tx, actor, action, appID, 0, AppAuditTarget(appID), auditState(before), auditState(after))
+}
+
+// recordDomainAudit method to add an event of a domain to the audit log within the transaction of the mutation.
+func recordDomainAudit(tx *gorm.DB, actor *utils.Actor, action enums.AuditAction, domain *models.Domain, before, after *DomainEvent) error {
+	return recordAudit(tx, actor, action, domain.AppID, domain.ID, DomainAuditTarget(domain.ID), auditState(before), auditState(after))
+}
+
+// AppAuditTarget returns the target of the audit events of an app.
+func AppAuditTarget(appID uint) string {
+	return fmt.Sprintf("app:%d", appID)
+}
+
+// DomainAuditTarget returns the target of the audit events of a domain.
+func DomainAuditTarget(domainID uint) string {
+	return fmt.Sprintf("domain:%d", domainID)
+}
+
+// GlobalAuditTarget returns the target of the audit events of a global setting.
+func GlobalAuditTarget(name string, level enums.Level) string {
+	return fmt.Sprintf("global:%s:%s", name, level)
+}
+
+// auditState returns the state as an untyped nil when it is a nil pointer, so it is stored as null.
+func auditState[T any](state *T) interface{} {
+	if state == nil {
+		return nil
+	}
+
+	return state
+}
+
+// marshalAuditState marshals a state of an audit event to JSON, or to null when there is no state.
+func marshalAuditState(state interface{}) (sql.NullString, error) {
+	if state == nil {
+		return sql.NullString{}, nil
+	}
+
+	value, err := json.Marshal(state)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+
+	return sql.NullString{String: string(value), Valid: true}, nil
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"api-app/main/src/cache"
+	"api-app/main/src/database"
+	"api-app/main/src/enums"
+	"api-app/main/src/models"
+	"fmt"
+	"gorm.io/gorm"
+	"strings"
+)
+
+// warmBatchSize is the number of apps that is warmed per batch.
+const warmBatchSize = 100
+
+// ResolveCacheTag is the tag of every resolved host in the cache.
+// A new or changed domain can take over hosts that were resolved to another domain, or to none.
+const ResolveCacheTag = "resolve"
+
+// GlobalCacheTag is the tag of the cached values that depend on the global settings.
+const GlobalCacheTag = "global"
+
+// AppCacheTag returns the tag of the cached values that depend on an app.
+func AppCacheTag(appID uint) string {
+	return fmt.Sprintf("app:%d", appID)
+}
+
+// AppNameCacheTag returns the tag of the cached values that are looked up by an app name.
+// It also covers lookups of an app name that does not exist yet.
+func AppNameCacheTag(appName string) string {
+	return fmt.Sprintf("app-name:%s", appName)
+}
+
+// DomainCacheTag returns the tag of the cached values that depend on a domain.
+func DomainCacheTag(domainID uint) string {
+	return fmt.Sprintf("domain:%d", domainID)
+}
+
+// DomainNameCacheTag returns the tag of the cached values that are looked up by a domain name.
+// It also covers lookups of a domain name that does not exist yet.
+func DomainNameCacheTag(domainName string) string {
+	return fmt.Sprintf("domain-name:%s", domainName)
+}
+
+// WarmCache method to load the global settings, and the settings and setting definitions of every app and domain
+// into the cache, by ID and by name at every level. Only the base settings are warmed, not those of the environments.
+// Keys that are cached already are left as they are.
+// Returns the number of apps and domains that were warmed.
+func WarmCache() (int, int, error) {
+	appCount, domainCount := 0, 0
+	levels := []enums.Level{enums.Private, enums.Public}
+	var apps []models.App
+
+	for _, level := range levels {
+		if _, err := GetGlobalSettings(level); err != nil {
+			return appCount, domainCount, err
+		}
+	}
+
+	result := database.Pg.Select("id", "name").
+		Preload("Domains", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id", "app_id", "name")
+		}).
+		Order("id").
+		FindInBatches(&apps, warmBatchSize, func(batch *gorm.DB, _ int) error {
+			for i := range apps {
+				app := &apps[i]
+				if _, err := GetSettingDefinitionsByAppID(app.ID); err != nil {
+					return err
+				}
+				if _, err := GetSettingDefinitionsByAppName(app.Name); err != nil {
+					return err
+				}
+
+				for _, level := range levels {
+					if _, err := GetAppSettingsByAppID(app.ID, level, ""); err != nil {
+						return err
+					}
+					if _, err := GetAppSettingsByName(app.Name, level, ""); err != nil {
+						return err
+					}
+
+					for j := range app.Domains {
+						if _, err := GetDomainSettingsByDomainID(app.Domains[j].ID, level, ""); err != nil {
+							return err
+						}
+						if _, err := GetDomainSettingsByName(app.Name, app.Domains[j].Name, level, ""); err != nil {
+							return err
+						}
+					}
+				}
+
+				appCount++
+				domainCount += len(app.Domains)
+			}
+
+			return nil
+		})
+
+	return appCount, domainCount, result.Error
+}
+
+// CacheKeyGroup returns the group of a key of this API, or an empty string when the key is not one of its keys.
+func CacheKeyGroup(key string) string {
+	switch {
+	case strings.HasPrefix(key, "settings:apps:"):
+		return "appSettingsById"
+	case strings.HasPrefix(key, "settings:domains:"):
+		return "domainSettingsById"
+	case strings.HasPrefix(key, "definitions:apps:"):
+		return "definitionsById"
+	case strings.HasPrefix(key, "settings:global:"):
+		return "globalSettings"
+	case strings.HasPrefix(key, "resolve:"):
+		return "resolvedHosts"
+	case strings.HasPrefix(key, "tags:"):
+		return "tags"
+	case strings.HasSuffix(key, ":definitions"):
+		return "definitionsByName"
+	case strings.Contains(key, ":settings:"):
+		if strings.Contains(key[:strings.Index(key, ":settings:")], ":") {
+			return "domainSettingsByName"
+		}
+		return "appSettingsByName"
+	default:
+		return ""
+	}
+}
+
+// ClearCache method to delete every key of this API from the cache.
+func ClearCache() error {
+	return cache.ScanKeys(func(keys []string) error {
+		ownKeys := make([]string, 0, len(keys))
+		for _, key := range keys {
+			if CacheKeyGroup(key) != "" {
+				ownKeys = append(ownKeys, key)
+			}
+		}
+
+		return cache.Delete(ownKeys...)
+	})
+}
+
+// ClearAppCache method to delete every key that depends on an app from the cache.
+// The apps are looked up by name, including deleted apps, so stale keys of deleted apps with the same name
+// are cleared as well.
+func ClearAppCache(appName string) error {
+	var apps []models.App
+	if result := database.Pg.Unscoped().
+		Preload("Domains", func(db *gorm.DB) *gorm.DB {
+			return db.Unscoped()
+		}).
+		Where("name = ?", appName).
+		Find(&apps); result.Error != nil {
+		return result.Error
+	}
+
+	tags := []string{AppNameCacheTag(appName)}
+	for i := range apps {
+		tags = append(tags, appCacheTags(&apps[i])...)
+	}
+
+	return cache.Invalidate(tags...)
+}
@@ -1,23 +1,52 @@
 package services
 
 import (
+	"api-app/main/src/cache"
 	"api-app/main/src/database"
 	"api-app/main/src/dto/requests"
 	"api-app/main/src/enums"
 	"api-app/main/src/models"
 	"api-app/main/src/utils"
 	"database/sql"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"strings"
 )
 
+// IsDomainNameAvailable method to check if a domain name is already used by the app.
+// The name is also checked against the domains of the other apps with which it overlaps: an identical wildcard,
+// a wildcard that would cover an exact host of another app, or an exact host below a wildcard of another app.
 func IsDomainNameAvailable(appID uint, name string) (bool, error) {
+	var count int64
+	conditions := database.Pg.Where("app_id = ? AND name = ?", appID, name)
+
+	if utils.IsWildcardDomain(name) {
+		// Identical wildcards of different apps would make every host they match ambiguous,
+		// and a wildcard over the hosts of another app would take over every host next to them.
+		suffix := strings.TrimPrefix(name, "*")
+		conditions = conditions.Or("app_id <> ? AND (name = ? OR (name NOT LIKE '*.%' AND RIGHT(name, ?) = ?))", appID, name, len(suffix), suffix)
+	} else if candidates := utils.HostCandidates(name); len(candidates) > 1 {
+		// An exact host below a wildcard of another app would take over that host.
+		conditions = conditions.Or("app_id <> ? AND name IN ?", appID, candidates[1:])
+	}
+
+	if result := database.Pg.Model(&models.Domain{}).Where(conditions).Count(&count); result.Error != nil {
+		return false, result.Error
+	}
+
+	return count > 0, nil
+}
+
+// HasDomainAliases method to check if other domains are an alias of the domain.
+func HasDomainAliases(id uint) (bool, error) {
 	var count int64
 	if result := database.Pg.Model(&models.Domain{}).
-		Where("app_id = ? AND name = ?", appID, name).
+		Where("alias_of_id = ?", id).
 		Count(&count); result.Error != nil {
 		return false, result.Error
 	}
 
-	return count == 1, nil
+	return count > 0, nil
 }
 
 // IsDomainDeleted method to check if a domain is deleted.
@@ -33,11 +62,65 @@ func IsDomainDeleted(id uint) (bool, error) {
 	return count == 1, nil
 }
 
+// DomainListQuery returns a query of the domains that match the filters of the domain listing.
+func DomainListQuery(filter *requests.GetDomains) *gorm.DB {
+	query := database.Pg.Model(&models.Domain{})
+
+	switch filter.Deleted {
+	case "true":
+		query = query.Unscoped().Where("deleted_at IS NOT NULL")
+	case "all":
+		query = query.Unscoped()
+	}
+
+	if filter.AppID != nil {
+		query = query.Where("app_id = ?", *filter.AppID)
+	}
+	if filter.SSL != nil {
+		query = query.Where("ssl = ?", *filter.SSL)
+	}
+	if filter.TopLevel != "" {
+		query = query.Where("top_level = ?", filter.TopLevel)
+	}
+	if filter.SecondLevel != "" {
+		query = query.Where("second_level = ?", filter.SecondLevel)
+	}
+	if filter.IpAddress != "" {
+		query = query.Where("ip_address = ?", filter.IpAddress)
+	}
+	if filter.VerificationStatus != "" {
+		query = query.Where("verification_status = ?", filter.VerificationStatus)
+	}
+
+	return query
+}
+
+// GetExpandedDomainById method to get a domain by its ID, with only the included relations preloaded.
+func GetExpandedDomainById(id uint, includes map[string]bool) (*models.Domain, error) {
+	domain := &models.Domain{}
+	query := database.Pg
+
+	if includes["settings"] {
+		query = query.Preload("Settings")
+	}
+
+	if result := query.Find(domain, "id = ?", id); result.Error != nil {
+		return nil, result.Error
+	}
+
+	return domain, nil
+}
+
 // GetDomainById method to get a domain by its ID.
-func GetDomainById(id uint) (*models.Domain, error) {
+func GetDomainById(id uint, unscoped ...bool) (*models.Domain, error) {
 	domain := &models.Domain{}
+	query := database.Pg
+
+	if len(unscoped) > 0 && unscoped[0] {
+		query = query.Unscoped()
+	}
 
-	if result := database.Pg.Preload("Settings").Find(domain, "id = ?", id); result.Error != nil {
+	if result := query.Preload("Settings").Find(domain, "id = ?", id); result.Error != nil {
 		return nil, result.Error
 	}
 
@@ -45,8 +128,11 @@ func GetDomainById(id uint) (*models.Domain, error) {
 }
 
 // CreateDomain method to create a domain.
-func CreateDomain(appID uint, ssl bool, name, ipAddress string, settings *[]requests.DomainSetting) (*models.Domain, error) {
-	subdomain, secondLevelDomain, topLevelDomain := utils.ExtractDomain(name)
+func CreateDomain(appID uint, ssl bool, name, ipAddress string, aliasOfID *uint, settings *[]requests.DomainSetting, actor *utils.Actor) (*models.Domain, error) {
+	subdomain, secondLevelDomain, topLevelDomain, err := utils.ExtractDomain(name)
+	if err != nil {
+		return nil, err
+	}
 	domain := models.Domain{
 		AppID:       appID,
 		SSL:         ssl,
@@ -55,34 +141,95 @@ func CreateDomain(appID uint, ssl bool, name, ipAddress string, settings *[]requ
 		SecondLevel: secondLevelDomain,
 		TopLevel:    topLevelDomain,
 		IpAddress:   ipAddress,
+		AliasOfID:   aliasOfID,
 		Settings:    make([]models.DomainSetting, len(*settings)),
 	}
+	if err := resetDomainVerification(&domain); err != nil {
+		return nil, err
+	}
 
 	for i := range *settings {
 		domain.Settings[i] = models.DomainSetting{
-			Name:      (*settings)[i].Name,
-			Level:     enums.Level((*settings)[i].Level),
-			Value:     (*settings)[i].Value,
-			ValueType: enums.ValueType((*settings)[i].ValueType),
+			Name:        (*settings)[i].Name,
+			Level:       enums.Level((*settings)[i].Level),
+			Value:       (*settings)[i].Value,
+			ValueType:   enums.ValueType((*settings)[i].ValueType),
+			Environment: (*settings)[i].Environment,
+			Locked:      (*settings)[i].Locked,
+		}
+		if err := sealDomainSetting(&domain.Settings[i], nil); err != nil {
+			return nil, err
 		}
 	}
 
-	if result := database.Pg.Create(&domain); result.Error != nil {
+	// Start a new transaction
+	tx := database.Pg.Begin()
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	if result := tx.Create(&domain); result.Error != nil {
+		tx.Rollback()
 		return nil, result.Error
 	}
 
+	if err := recordDomainEvent(tx, enums.DomainAdded, &domain, nil); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := recordDomainAudit(tx, actor, enums.AuditDomainCreated, &domain, nil, newDomainEvent(&domain, nil)); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if _, err := createDomainSettingRevision(tx, domain.ID, nil, domain.Settings, actor, 0); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := bumpVersion(tx, &models.App{}, domain.AppID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// Delete the cached lookups of the domain from before it existed.
+	_ = cache.Invalidate(DomainCacheTag(domain.ID), DomainNameCacheTag(domain.Name), ResolveCacheTag)
+	_ = publishSettingsChanged(domain.AppID, domain.ID)
+
 	return &domain, nil
 }
 
 // UpdateDomain method to update a domain.
-func UpdateDomain(oldDomain *models.Domain, ssl bool, name, ipAddress string, settings *[]requests.DomainSetting) (*models.Domain, error) {
-	subdomain, secondLevelDomain, topLevelDomain := utils.ExtractDomain(name)
+// The change is based on the given version of the domain.
+func UpdateDomain(oldDomain *models.Domain, version uint, ssl bool, name, ipAddress string, aliasOfID *uint, settings *[]requests.DomainSetting, actor *utils.Actor) (*models.Domain, error) {
+	before := newDomainEvent(oldDomain, nil)
+	var oldName *string
+	if oldDomain.Name != name {
+		previousName := oldDomain.Name
+		oldName = &previousName
+		if err := resetDomainVerification(oldDomain); err != nil {
+			return nil, err
+		}
+	}
+
+	subdomain, secondLevelDomain, topLevelDomain, err := utils.ExtractDomain(name)
+	if err != nil {
+		return nil, err
+	}
 	oldDomain.SSL = ssl
 	oldDomain.Name = name
 	oldDomain.Sub = sql.NullString{String: subdomain, Valid: subdomain != ""}
 	oldDomain.SecondLevel = secondLevelDomain
 	oldDomain.TopLevel = topLevelDomain
 	oldDomain.IpAddress = ipAddress
+	oldDomain.AliasOfID = aliasOfID
+	oldSettings := oldDomain.Settings
 
 	// Start a new transaction
 	tx := database.Pg.Begin()
@@ -90,6 +237,13 @@ func UpdateDomain(oldDomain *models.Domain, ssl bool, name, ipAddress string, se
 		return nil, tx.Error
 	}
 
+	// Check the version the change is based on, the domain stays locked until the change is committed.
+	if err := checkVersion(tx, &models.Domain{}, oldDomain.ID, version); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	oldDomain.Version = version + 1
+
 	for i := range oldDomain.Settings {
 		// Delete old settings.
 		if result := tx.Delete(&oldDomain.Settings[i]); result.Error != nil {
@@ -102,10 +256,16 @@ func UpdateDomain(oldDomain *models.Domain, ssl bool, name, ipAddress string, se
 	oldDomain.Settings = make([]models.DomainSetting, len(*settings))
 	for i := range *settings {
 		oldDomain.Settings[i] = models.DomainSetting{
-			Name:      (*settings)[i].Name,
-			Level:     enums.Level((*settings)[i].Level),
-			Value:     (*settings)[i].Value,
-			ValueType: enums.ValueType((*settings)[i].ValueType),
+			Name:        (*settings)[i].Name,
+			Level:       enums.Level((*settings)[i].Level),
+			Value:       (*settings)[i].Value,
+			ValueType:   enums.ValueType((*settings)[i].ValueType),
+			Environment: (*settings)[i].Environment,
+			Locked:      (*settings)[i].Locked,
+		}
+		if err := sealDomainSetting(&oldDomain.Settings[i], oldSettings); err != nil {
+			tx.Rollback()
+			return nil, err
 		}
 	}
 
@@ -114,53 +274,161 @@ func UpdateDomain(oldDomain *models.Domain, ssl bool, name, ipAddress string, se
 		return nil, result.Error
 	}
 
+	if err := recordDomainEvent(tx, enums.DomainUpdated, oldDomain, oldName); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := recordDomainAudit(tx, actor, enums.AuditDomainUpdated, oldDomain, before, newDomainEvent(oldDomain, nil)); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if _, err := createDomainSettingRevision(tx, oldDomain.ID, oldSettings, oldDomain.Settings, actor, 0); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
 	// Commit the transaction
 	if err := tx.Commit().Error; err != nil {
 		tx.Rollback()
 		return nil, err
 	}
 
-	_ = deleteDomainSettingsCache(oldDomain.ID, oldDomain.Name)
+	_ = cache.Invalidate(DomainCacheTag(oldDomain.ID), DomainNameCacheTag(oldDomain.Name), ResolveCacheTag)
+	_ = publishSettingsChanged(oldDomain.AppID, oldDomain.ID)
 
 	return oldDomain, nil
 }
 
 // DeleteDomain method to delete a domain.
-func DeleteDomain(domain *models.Domain) error {
-	_ = deleteDomainSettingsCache(domain.ID, domain.Name)
+// A version of 0 deletes the domain regardless of its version.
+func DeleteDomain(domain *models.Domain, version uint, actor *utils.Actor) error {
+	// Start a new transaction
+	tx := database.Pg.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
 
-	return database.Pg.Delete(domain).Error
-}
+	// Check the version the delete is based on.
+	if version != 0 {
+		if err := checkVersion(tx, &models.Domain{}, domain.ID, version); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
 
-// RestoreDomain method to restore a domain.
-func RestoreDomain(id uint) error {
-	return database.Pg.Unscoped().Model(&models.Domain{}).Where("id = ?", id).Update("deleted_at", nil).Error
-}
+	if err := deleteDomains(tx, []models.Domain{*domain}, uuid.NewString(), actor); err != nil {
+		tx.Rollback()
+		return err
+	}
 
-// deleteDomainSettingsCache method to delete the settings cache.
-func deleteDomainSettingsCache(domainID uint, domainName string) error {
-	if err := DeleteDomainSettingsFromCache(DomainSettingsCacheKeyOnId(domainID, enums.Private)); err != nil {
+	if err := bumpVersion(tx, &models.App{}, domain.AppID); err != nil {
+		tx.Rollback()
 		return err
 	}
-	if err := DeleteDomainSettingsFromCache(DomainSettingsCacheKeyOnId(domainID, enums.Public)); err != nil {
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
 		return err
 	}
 
-	var appName string
-	if result := database.Pg.Model(&models.Domain{}).
-		Joins("JOIN apps ON apps.id = domains.app_id").
-		Where("domains.id = ?", domainID).
-		Select("apps.name").
-		Scan(&appName); result.Error != nil {
+	_ = cache.Invalidate(DomainCacheTag(domain.ID), ResolveCacheTag)
+	_ = publishSettingsChanged(domain.AppID, domain.ID)
+
+	return nil
+}
+
+// RestoreDomain method to restore a domain.
+func RestoreDomain(id uint, actor *utils.Actor) error {
+	// Start a new transaction
+	tx := database.Pg.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	domain := &models.Domain{}
+	if result := tx.Unscoped().Find(domain, "id = ?", id); result.Error != nil {
+		tx.Rollback()
 		return result.Error
 	}
 
-	if err := DeleteDomainSettingsFromCache(DomainSettingsCacheKeyOnName(appName, domainName, enums.Private)); err != nil {
+	if err := restoreDomains(tx, []models.Domain{*domain}, actor); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := bumpVersion(tx, &models.App{}, domain.AppID); err != nil {
+		tx.Rollback()
 		return err
 	}
-	if err := DeleteDomainSettingsFromCache(DomainSettingsCacheKeyOnName(appName, domainName, enums.Public)); err != nil {
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
 		return err
 	}
 
+	_ = cache.Invalidate(DomainCacheTag(domain.ID), DomainNameCacheTag(domain.Name), ResolveCacheTag)
+	_ = publishSettingsChanged(domain.AppID, domain.ID)
+
+	return nil
+}
+
+// deleteDomains method to soft-delete domains within the transaction of a delete operation.
+// The domains are marked with the deletion batch of the operation, so a restore brings back exactly these domains.
+func deleteDomains(tx *gorm.DB, domains []models.Domain, batch string, actor *utils.Actor) error {
+	for i := range domains {
+		domains[i].DeletionBatch = sql.NullString{String: batch, Valid: true}
+		if result := tx.Model(&domains[i]).Update("deletion_batch", domains[i].DeletionBatch); result.Error != nil {
+			return result.Error
+		}
+
+		if result := tx.Delete(&domains[i]); result.Error != nil {
+			return result.Error
+		}
+
+		if err := recordDomainEvent(tx, enums.DomainRemoved, &domains[i], nil); err != nil {
+			return err
+		}
+		if err := recordDomainAudit(tx, actor, enums.AuditDomainDeleted, &domains[i], newDomainEvent(&domains[i], nil), nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// restoreDomains method to restore soft-deleted domains within the transaction of a restore operation.
+func restoreDomains(tx *gorm.DB, domains []models.Domain, actor *utils.Actor) error {
+	for i := range domains {
+		if result := tx.Unscoped().Model(&domains[i]).Updates(map[string]interface{}{
+			"deleted_at":     nil,
+			"deletion_batch": nil,
+		}); result.Error != nil {
+			return result.Error
+		}
+
+		if err := recordDomainEvent(tx, enums.DomainRestored, &domains[i], nil); err != nil {
+			return err
+		}
+		if err := recordDomainAudit(tx, actor, enums.AuditDomainRestored, &domains[i], nil, newDomainEvent(&domains[i], nil)); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
+
+// IsDomainAppDeleted method to check if the app of a domain is deleted.
+func IsDomainAppDeleted(id uint) (bool, error) {
+	var count int64
+	if result := database.Pg.Table("domains").
+		Joins("JOIN apps ON apps.id = domains.app_id").
+		Where("domains.id = ? AND apps.deleted_at IS NOT NULL", id).
+		Count(&count); result.Error != nil {
+		return false, result.Error
+	}
+
+	return count == 1, nil
+}
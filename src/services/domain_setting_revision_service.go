@@ -0,0 +1,150 @@
+package services
+
+import (
+	"api-app/main/src/cache"
+	"api-app/main/src/database"
+	"api-app/main/src/enums"
+	"api-app/main/src/models"
+	"api-app/main/src/utils"
+	"database/sql"
+	"encoding/json"
+	"gorm.io/gorm"
+)
+
+// GetDomainSettingRevisionById method to get a revision of the domain settings by its number.
+func GetDomainSettingRevisionById(domainID, revision uint) (*models.DomainSettingRevision, error) {
+	domainSettingRevision := &models.DomainSettingRevision{}
+
+	if result := database.Pg.Find(domainSettingRevision, "domain_id = ? AND revision = ?", domainID, revision); result.Error != nil {
+		return nil, result.Error
+	}
+
+	return domainSettingRevision, nil
+}
+
+// RollbackDomainSettings method to restore the domain settings to the state of a revision.
+// The rollback itself is stored as a new revision, so it can be undone as well.
+// Returns nil when the settings already match the revision.
+func RollbackDomainSettings(domain *models.Domain, domainSettingRevision *models.DomainSettingRevision, actor *utils.Actor) (*models.DomainSettingRevision, error) {
+	var snapshots []SettingSnapshot
+	if err := json.Unmarshal([]byte(domainSettingRevision.Settings), &snapshots); err != nil {
+		return nil, err
+	}
+
+	// Start a new transaction
+	tx := database.Pg.Begin()
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	var oldSettings []models.DomainSetting
+	if result := tx.Where("domain_id = ?", domain.ID).Find(&oldSettings); result.Error != nil {
+		tx.Rollback()
+		return nil, result.Error
+	}
+
+	if result := tx.Where("domain_id = ?", domain.ID).Delete(&models.DomainSetting{}); result.Error != nil {
+		tx.Rollback()
+		return nil, result.Error
+	}
+
+	newSettings := make([]models.DomainSetting, len(snapshots))
+	for i := range snapshots {
+		newSettings[i] = models.DomainSetting{
+			DomainID:    domain.ID,
+			Name:        snapshots[i].Name,
+			Level:       enums.Level(snapshots[i].Level),
+			Value:       snapshots[i].Value,
+			ValueType:   enums.ValueType(snapshots[i].ValueType),
+			Environment: snapshots[i].Environment,
+			Locked:      snapshots[i].Locked,
+		}
+	}
+	if len(newSettings) > 0 {
+		if result := tx.Create(&newSettings); result.Error != nil {
+			tx.Rollback()
+			return nil, result.Error
+		}
+	}
+
+	revision, err := createDomainSettingRevision(tx, domain.ID, oldSettings, newSettings, actor, domainSettingRevision.Revision)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := bumpVersion(tx, &models.Domain{}, domain.ID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	_ = cache.Invalidate(DomainCacheTag(domain.ID))
+	_ = publishSettingsChanged(domain.AppID, domain.ID)
+
+	return revision, nil
+}
+
+// createDomainSettingRevision method to store a new revision of the domain settings within a transaction, and adds the change to the audit log.
+// No revision is stored when the old and new settings are equal.
+// The rollbackOf parameter holds the revision that is restored, or zero for a regular change.
+func createDomainSettingRevision(tx *gorm.DB, domainID uint, oldSettings, newSettings []models.DomainSetting, actor *utils.Actor, rollbackOf uint) (*models.DomainSettingRevision, error) {
+	oldSnapshots := domainSettingsToSnapshots(oldSettings)
+	snapshots := domainSettingsToSnapshots(newSettings)
+	changes := diffSettingSnapshots(oldSnapshots, snapshots)
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	settings, diff, err := marshalRevision(snapshots, changes)
+	if err != nil {
+		return nil, err
+	}
+
+	// Lock the domain, so concurrent changes can not claim the same revision number.
+	var appID uint
+	if result := tx.Raw("SELECT app_id FROM domains WHERE id = ? FOR UPDATE", domainID).Scan(&appID); result.Error != nil {
+		return nil, result.Error
+	}
+
+	var lastRevision uint
+	if result := tx.Model(&models.DomainSettingRevision{}).
+		Select("COALESCE(MAX(revision), 0)").
+		Where("domain_id = ?", domainID).
+		Scan(&lastRevision); result.Error != nil {
+		return nil, result.Error
+	}
+
+	revision := models.DomainSettingRevision{
+		DomainID:   domainID,
+		Revision:   lastRevision + 1,
+		Actor:      actor.Name,
+		Settings:   settings,
+		Diff:       diff,
+		RollbackOf: sql.NullInt64{Int64: int64(rollbackOf), Valid: rollbackOf != 0},
+	}
+
+	if result := tx.Create(&revision); result.Error != nil {
+		return nil, result.Error
+	}
+
+	if err := recordEvent(tx, enums.SettingsChanged, appID, domainID, SettingsEvent{
+		AppID:    appID,
+		DomainID: &domainID,
+		Revision: revision.Revision,
+		Changes:  newSettingsEventChanges(changes),
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := recordAudit(tx, actor, enums.AuditSettingsChanged, appID, domainID, DomainAuditTarget(domainID), oldSnapshots, snapshots); err != nil {
+		return nil, err
+	}
+
+	return &revision, nil
+}
@@ -3,144 +3,221 @@ package services
 import (
 	"api-app/main/src/cache"
 	"api-app/main/src/database"
+	"api-app/main/src/dto/requests"
 	"api-app/main/src/enums"
 	"api-app/main/src/models"
-	"context"
-	"encoding/json"
+	"api-app/main/src/utils"
 	"fmt"
-	"github.com/valkey-io/valkey-go"
-	"os"
-	"time"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // GetDomainSettingsByName method to get settings by domain name.
-func GetDomainSettingsByName(appName, domainName string, level enums.Level) (*[]models.DomainSetting, error) {
-	var settings []models.DomainSetting
-	cacheKey := DomainSettingsCacheKeyOnName(appName, domainName, level)
-
-	if inCache, err := IsDomainSettingsInCache(cacheKey); err != nil {
+// The settings of the environment follow the base settings they override, so they take precedence.
+// It returns nil when the app, the domain or the environment does not exist.
+func GetDomainSettingsByName(appName, domainName string, level enums.Level, environment string) (*[]models.DomainSetting, error) {
+	settings, found, err := cache.Load(DomainSettingsCacheKeyOnName(appName, domainName, level, environment), func(tags *cache.Tags) ([]models.DomainSetting, bool, error) {
+		tags.Add(AppNameCacheTag(appName), DomainNameCacheTag(domainName))
+
+		return loadDomainSettings(database.Pg.Where("apps.name = ? AND domains.name = ?", appName, domainName), level, environment, tags)
+	})
+	if err != nil || !found {
 		return nil, err
-	} else if inCache {
-		if cacheSettings, err := GetDomainSettingsFromCache(cacheKey); err != nil {
-			return nil, err
-		} else if cacheSettings != nil && len(*cacheSettings) > 0 {
-			settings = *cacheSettings
-		}
 	}
 
-	if len(settings) == 0 {
-		if result := database.Pg.Model(&models.DomainSetting{}).
-			Joins("JOIN domains ON domains.id = domain_settings.domain_id").
-			Joins("JOIN apps ON apps.id = domains.app_id").
-			Where("apps.name = ? AND domains.name = ? AND (level = 'both' OR level = ?)", appName, domainName, level.String()).
-			Find(&settings); result.Error != nil {
-			return nil, result.Error
-		}
-		_ = SetDomainSettingsToCache(cacheKey, &settings)
+	return &settings, nil
+}
+
+// GetDomainSettingsByDomainID method to get settings by domain ID.
+// The settings of the environment follow the base settings they override, so they take precedence.
+// It returns nil when the domain, its app or the environment does not exist.
+func GetDomainSettingsByDomainID(domainID uint, level enums.Level, environment string) (*[]models.DomainSetting, error) {
+	settings, found, err := cache.Load(DomainSettingsCacheKeyOnId(domainID, level, environment), func(tags *cache.Tags) ([]models.DomainSetting, bool, error) {
+		tags.Add(DomainCacheTag(domainID))
+
+		return loadDomainSettings(database.Pg.Where("domains.id = ?", domainID), level, environment, tags)
+	})
+	if err != nil || !found {
+		return nil, err
 	}
 
 	return &settings, nil
 }
 
-// GetDomainSettingsByDomainID method to get settings by domain ID.
-func GetDomainSettingsByDomainID(domainID uint, level enums.Level) (*[]models.DomainSetting, error) {
-	var settings []models.DomainSetting
-	cacheKey := DomainSettingsCacheKeyOnId(domainID, level)
+// loadDomainSettings method to find the domain that matches the condition, with its settings.
+// The settings depend on the domain, its app and the domain it is an alias of, so the key is tagged with them.
+func loadDomainSettings(condition *gorm.DB, level enums.Level, environment string, tags *cache.Tags) ([]models.DomainSetting, bool, error) {
+	var domain models.Domain
+	if result := database.Pg.Model(&models.Domain{}).
+		Joins("JOIN apps ON apps.id = domains.app_id AND apps.deleted_at IS NULL").
+		Where(condition).
+		Select("domains.id", "domains.app_id", "domains.alias_of_id").
+		Scan(&domain); result.Error != nil || domain.ID == 0 {
+		return nil, false, result.Error
+	}
 
-	if inCache, err := IsDomainSettingsInCache(cacheKey); err != nil {
-		return nil, err
-	} else if inCache {
-		if cacheSettings, err := GetDomainSettingsFromCache(cacheKey); err != nil {
-			return nil, err
-		} else if cacheSettings != nil && len(*cacheSettings) > 0 {
-			settings = *cacheSettings
-		}
+	tags.Add(DomainCacheTag(domain.ID), AppCacheTag(domain.AppID))
+	if domain.AliasOfID != nil {
+		tags.Add(DomainCacheTag(*domain.AliasOfID))
 	}
 
-	if len(settings) == 0 {
-		if result := database.Pg.Model(&models.DomainSetting{}).
-			Where("domain_id = ? AND (level = 'both' OR level = ?)", domainID, level.String()).
-			Find(&settings); result.Error != nil {
-			return nil, result.Error
+	if environment != "" {
+		if exists, err := IsEnvironmentAvailable(domain.AppID, environment); err != nil || !exists {
+			return nil, false, err
 		}
-		_ = SetDomainSettingsToCache(cacheKey, &settings)
 	}
 
-	return &settings, nil
+	settings := make([]models.DomainSetting, 0)
+	if err := findDomainSettings(domain.ID, level, environment, &settings); err != nil {
+		return nil, false, err
+	}
+
+	return settings, true, nil
 }
 
-// IsDomainSettingsInCache checks if the settings exists in the cache.
-func IsDomainSettingsInCache(key string) (bool, error) {
-	result := cache.Valkey.Do(context.Background(), cache.Valkey.B().Exists().Key(key).Build())
-	if result.Error() != nil {
-		return false, result.Error()
+// findDomainSettings method to find the settings of a domain, in the environment when it is not empty.
+// An alias domain inherits the settings of its canonical domain, which are followed by its own settings,
+// so the settings of the alias take precedence. Within each domain, the settings of the environment
+// follow the base settings.
+func findDomainSettings(domainID uint, level enums.Level, environment string, settings *[]models.DomainSetting) error {
+	canonicalID := database.Pg.Model(&models.Domain{}).
+		Select("id").
+		Where("id = (?)", database.Pg.Unscoped().Model(&models.Domain{}).Select("alias_of_id").Where("id = ?", domainID))
+
+	if result := database.Pg.Model(&models.DomainSetting{}).
+		Where("(domain_id = ? OR domain_id IN (?)) AND environment IN ('', ?) AND (level = 'both' OR level = ?)", domainID, canonicalID, environment, level.String()).
+		Order(clause.OrderBy{Expression: clause.Expr{SQL: "domain_id = ?, environment <> ''", Vars: []interface{}{domainID}}}).
+		Find(settings); result.Error != nil {
+		return result.Error
 	}
 
-	value, err := result.ToInt64()
-	if err != nil {
-		return false, err
+	return nil
+}
+
+// UpsertDomainSetting method to create or update a single setting of a domain.
+// The setting is identified by its name, level and environment.
+func UpsertDomainSetting(domain *models.Domain, request *requests.DomainSetting, actor *utils.Actor) (*models.DomainSetting, error) {
+	setting := models.DomainSetting{
+		DomainID:    domain.ID,
+		Name:        request.Name,
+		Level:       enums.Level(request.Level),
+		Value:       request.Value,
+		ValueType:   enums.ValueType(request.ValueType),
+		Environment: request.Environment,
+		Locked:      request.Locked,
 	}
 
-	return value == 1, nil
-}
+	// Start a new transaction
+	tx := database.Pg.Begin()
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
 
-// GetDomainSettingsFromCache gets the settings from the cache.
-func GetDomainSettingsFromCache(key string) (*[]models.DomainSetting, error) {
-	result := cache.Valkey.Do(context.Background(), cache.Valkey.B().Get().Key(key).Build())
-	if result.Error() != nil {
-		return nil, result.Error()
+	var oldSettings []models.DomainSetting
+	if result := tx.Where("domain_id = ?", domain.ID).Find(&oldSettings); result.Error != nil {
+		tx.Rollback()
+		return nil, result.Error
 	}
 
-	value, err := result.ToString()
-	if err != nil {
+	if err := sealDomainSetting(&setting, oldSettings); err != nil {
+		tx.Rollback()
 		return nil, err
 	}
 
-	var settings []models.DomainSetting
-	if err := json.Unmarshal([]byte(value), &settings); err != nil {
+	if result := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "domain_id"}, {Name: "name"}, {Name: "level"}, {Name: "environment"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "value_type", "locked"}),
+	}).Create(&setting); result.Error != nil {
+		tx.Rollback()
+		return nil, result.Error
+	}
+
+	newSettings := make([]models.DomainSetting, 0, len(oldSettings)+1)
+	for i := range oldSettings {
+		if oldSettings[i].Name != setting.Name || oldSettings[i].Level != setting.Level || oldSettings[i].Environment != setting.Environment {
+			newSettings = append(newSettings, oldSettings[i])
+		}
+	}
+	newSettings = append(newSettings, setting)
+
+	if _, err := createDomainSettingRevision(tx, domain.ID, oldSettings, newSettings, actor, 0); err != nil {
+		tx.Rollback()
 		return nil, err
 	}
 
-	return &settings, nil
+	if err := bumpVersion(tx, &models.Domain{}, domain.ID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	_ = cache.Invalidate(DomainCacheTag(domain.ID))
+	_ = publishSettingsChanged(domain.AppID, domain.ID)
+
+	return &setting, nil
 }
 
-// SetDomainSettingsToCache sets the settings to the cache.
-func SetDomainSettingsToCache(key string, settings *[]models.DomainSetting) error {
-	value, err := json.Marshal(settings)
-	if err != nil {
-		return err
+// DeleteDomainSetting method to delete a single setting of a domain.
+// An empty environment deletes the base setting.
+func DeleteDomainSetting(domain *models.Domain, name string, level enums.Level, environment string, actor *utils.Actor) error {
+	// Start a new transaction
+	tx := database.Pg.Begin()
+	if tx.Error != nil {
+		return tx.Error
 	}
 
-	expiration := os.Getenv("VALKEY_EXPIRATION")
-	duration, err := time.ParseDuration(expiration)
-	if err != nil {
-		return err
+	var oldSettings []models.DomainSetting
+	if result := tx.Where("domain_id = ?", domain.ID).Find(&oldSettings); result.Error != nil {
+		tx.Rollback()
+		return result.Error
 	}
 
-	result := cache.Valkey.Do(context.Background(), cache.Valkey.B().Set().Key(key).Value(valkey.BinaryString(value)).Ex(duration).Build())
-	if result.Error() != nil {
-		return result.Error()
+	if result := tx.Where("domain_id = ? AND name = ? AND level = ? AND environment = ?", domain.ID, name, level.String(), environment).
+		Delete(&models.DomainSetting{}); result.Error != nil {
+		tx.Rollback()
+		return result.Error
 	}
 
-	return nil
-}
+	newSettings := make([]models.DomainSetting, 0, len(oldSettings))
+	for i := range oldSettings {
+		if oldSettings[i].Name != name || oldSettings[i].Level != level || oldSettings[i].Environment != environment {
+			newSettings = append(newSettings, oldSettings[i])
+		}
+	}
 
-// DeleteDomainSettingsFromCache deletes an existing setting from the cache.
-func DeleteDomainSettingsFromCache(key string) error {
-	result := cache.Valkey.Do(context.Background(), cache.Valkey.B().Del().Key(key).Build())
-	if result.Error() != nil {
-		return result.Error()
+	if _, err := createDomainSettingRevision(tx, domain.ID, oldSettings, newSettings, actor, 0); err != nil {
+		tx.Rollback()
+		return err
 	}
 
+	if err := bumpVersion(tx, &models.Domain{}, domain.ID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_ = cache.Invalidate(DomainCacheTag(domain.ID))
+	_ = publishSettingsChanged(domain.AppID, domain.ID)
+
 	return nil
 }
 
 // DomainSettingsCacheKeyOnName returns the key for the settings cache with a name.
-func DomainSettingsCacheKeyOnName(appName, domainName string, level enums.Level) string {
-	return fmt.Sprintf("%s:%s:settings:%s", appName, domainName, level.String())
+func DomainSettingsCacheKeyOnName(appName, domainName string, level enums.Level, environment string) string {
+	return fmt.Sprintf("%s:%s:settings:%s", appName, domainName, level.String()) + environmentCacheKeySuffix(environment)
 }
 
 // DomainSettingsCacheKeyOnId returns the key for the settings cache with an id.
-func DomainSettingsCacheKeyOnId(domainID uint, level enums.Level) string {
-	return fmt.Sprintf("settings:domains:%d:%s", domainID, level.String())
+func DomainSettingsCacheKeyOnId(domainID uint, level enums.Level, environment string) string {
+	return fmt.Sprintf("settings:domains:%d:%s", domainID, level.String()) + environmentCacheKeySuffix(environment)
 }
@@ -0,0 +1,283 @@
+package services
+
+import (
+	"api-app/main/src/cache"
+	"api-app/main/src/database"
+	"api-app/main/src/enums"
+	"api-app/main/src/models"
+	"api-app/main/src/utils"
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/gofiber/fiber/v2/log"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// VerificationRecordPrefix is the label of the DNS TXT record that holds the verification token.
+const VerificationRecordPrefix = "_app-verification"
+
+// VerificationPath is the path of the HTTP file that holds the verification token.
+const VerificationPath = "/.well-known/app-verification.txt"
+
+// verificationTimeout is the maximum duration of a single verification check.
+const verificationTimeout = 10 * time.Second
+
+// TXTResolver is the interface of the resolver that looks up the DNS TXT records of a domain.
+type TXTResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// VerificationResolver looks up the DNS TXT records for the verification.
+// It uses the VERIFICATION_DNS_SERVER when set, and can be replaced, for example by a stub DNS server in tests.
+var VerificationResolver TXTResolver = newVerificationResolver(os.Getenv("VERIFICATION_DNS_SERVER"))
+
+// VerificationHTTPClient fetches the HTTP files for the verification.
+// It only connects to public addresses and does not follow redirects, so a domain can not point it at internal services.
+var VerificationHTTPClient = &http.Client{
+	Timeout: verificationTimeout,
+	Transport: &http.Transport{
+		DialContext:         (&net.Dialer{Timeout: verificationTimeout, Control: publicAddressDialControl}).DialContext,
+		TLSHandshakeTimeout: verificationTimeout,
+	},
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// VerificationState struct holds the verification state of a domain in the audit log.
+type VerificationState struct {
+	Method     string     `json:"method,omitempty"`
+	Status     string     `json:"status"`
+	VerifiedAt *time.Time `json:"verifiedAt"`
+	Reason     string     `json:"reason,omitempty"`
+}
+
+// HideUnverifiedDomains checks if domains that are not verified are hidden from the public settings resolution.
+func HideUnverifiedDomains() bool {
+	return os.Getenv("HIDE_UNVERIFIED_DOMAINS") == "true"
+}
+
+// IsDomainHidden method to check if a domain is hidden from the settings resolution of the level,
+// because it is not verified.
+func IsDomainHidden(domainID uint, level enums.Level) (bool, error) {
+	if level != enums.Public || !HideUnverifiedDomains() {
+		return false, nil
+	}
+
+	var count int64
+	if result := database.Pg.Model(&models.Domain{}).
+		Where("id = ? AND verification_status = ?", domainID, enums.Verified).
+		Count(&count); result.Error != nil {
+		return false, result.Error
+	}
+
+	return count == 0, nil
+}
+
+// IsDomainNameHidden method to check if a domain of an app is hidden from the settings resolution of the level,
+// because it is not verified.
+func IsDomainNameHidden(appName, domainName string, level enums.Level) (bool, error) {
+	if level != enums.Public || !HideUnverifiedDomains() {
+		return false, nil
+	}
+
+	var count int64
+	if result := database.Pg.Model(&models.Domain{}).
+		Joins("JOIN apps ON apps.id = domains.app_id AND apps.deleted_at IS NULL").
+		Where("apps.name = ? AND domains.name = ? AND domains.verification_status = ?", appName, domainName, enums.Verified).
+		Count(&count); result.Error != nil {
+		return false, result.Error
+	}
+
+	return count == 0, nil
+}
+
+// VerificationRecordName returns the name of the DNS TXT record that holds the verification token of a domain.
+// The record of a wildcard domain is placed on its parent domain.
+func VerificationRecordName(domain *models.Domain) string {
+	return VerificationRecordPrefix + "." + strings.TrimPrefix(domain.Name, "*.")
+}
+
+// VerificationRecordValue returns the value of the DNS TXT record that holds the verification token of a domain.
+func VerificationRecordValue(domain *models.Domain) string {
+	return "app-verification=" + domain.VerificationToken
+}
+
+// VerifyDomain method to check if the verification token of a domain is published with the given method.
+// The status of the domain is stored, and a successful verification is recorded as a domain.verified event.
+// It returns the reason when the verification failed.
+func VerifyDomain(domain *models.Domain, method enums.VerificationMethod, actor *utils.Actor) (*models.Domain, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), verificationTimeout)
+	defer cancel()
+
+	var reason string
+	switch method {
+	case enums.DNS:
+		reason = checkVerificationRecord(ctx, domain)
+	case enums.HTTP:
+		reason = checkVerificationFile(ctx, domain)
+	default:
+		return nil, "", fmt.Errorf("unknown verification method %s", method)
+	}
+
+	before := newVerificationState(domain, "", "")
+	if reason != "" {
+		domain.VerificationStatus = enums.Failed
+		domain.VerifiedAt = sql.NullTime{}
+	} else {
+		domain.VerificationStatus = enums.Verified
+		domain.VerifiedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	}
+
+	// Start a new transaction
+	tx := database.Pg.Begin()
+	if tx.Error != nil {
+		return nil, "", tx.Error
+	}
+
+	if result := tx.Model(domain).Select("verification_status", "verified_at").Updates(domain); result.Error != nil {
+		tx.Rollback()
+		return nil, "", result.Error
+	}
+
+	if reason == "" {
+		if err := recordDomainEvent(tx, enums.DomainVerified, domain, nil); err != nil {
+			tx.Rollback()
+			return nil, "", err
+		}
+	}
+
+	action := enums.AuditDomainVerified
+	if reason != "" {
+		action = enums.AuditDomainVerifyFailed
+	}
+	if err := recordAudit(tx, actor, action, domain.AppID, domain.ID, DomainAuditTarget(domain.ID), before, newVerificationState(domain, method, reason)); err != nil {
+		tx.Rollback()
+		return nil, "", err
+	}
+
+	if err := bumpVersion(tx, &models.Domain{}, domain.ID); err != nil {
+		tx.Rollback()
+		return nil, "", err
+	}
+	domain.Version++
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return nil, "", err
+	}
+
+	if HideUnverifiedDomains() {
+		_ = cache.Invalidate(DomainCacheTag(domain.ID), ResolveCacheTag)
+		_ = publishSettingsChanged(domain.AppID, domain.ID)
+	}
+
+	return domain, reason, nil
+}
+
+// newVerificationState returns the verification state of a domain in the audit log,
+// with the method and the reason of a failure when the domain has been checked.
+func newVerificationState(domain *models.Domain, method enums.VerificationMethod, reason string) *VerificationState {
+	state := &VerificationState{
+		Method: method.String(),
+		Status: domain.VerificationStatus.String(),
+		Reason: reason,
+	}
+	if domain.VerifiedAt.Valid {
+		verifiedAt := domain.VerifiedAt.Time
+		state.VerifiedAt = &verifiedAt
+	}
+
+	return state
+}
+
+// resetDomainVerification method to give a domain a new verification token and mark it as not verified.
+func resetDomainVerification(domain *models.Domain) error {
+	token, err := utils.GenerateToken(16)
+	if err != nil {
+		return err
+	}
+
+	domain.VerificationToken = token
+	domain.VerificationStatus = enums.Pending
+	domain.VerifiedAt = sql.NullTime{}
+
+	return nil
+}
+
+// checkVerificationRecord checks if the DNS TXT record of the domain holds its verification token.
+func checkVerificationRecord(ctx context.Context, domain *models.Domain) string {
+	name := VerificationRecordName(domain)
+
+	records, err := VerificationResolver.LookupTXT(ctx, name)
+	if err != nil {
+		return fmt.Sprintf("Could not look up the TXT record %s: %v", name, err)
+	}
+	if !slices.Contains(records, VerificationRecordValue(domain)) {
+		return fmt.Sprintf("The TXT record %s does not contain %s", name, VerificationRecordValue(domain))
+	}
+
+	return ""
+}
+
+// checkVerificationFile checks if the HTTP file of the domain holds its verification token.
+// Wildcard domains can not be verified with a file, because they have no host of their own.
+func checkVerificationFile(ctx context.Context, domain *models.Domain) string {
+	if utils.IsWildcardDomain(domain.Name) {
+		return "A wildcard domain can only be verified with a DNS TXT record"
+	}
+
+	scheme := "http"
+	if domain.SSL {
+		scheme = "https"
+	}
+	url := scheme + "://" + domain.Name + VerificationPath
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Sprintf("Could not request %s: %v", url, err)
+	}
+	// The error of the request is logged only, so the reason does not reveal what is listening on the address.
+	response, err := VerificationHTTPClient.Do(request)
+	if err != nil {
+		log.Infof("Verification request to %s failed: %v", url, err)
+		return fmt.Sprintf("Could not request %s", url)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Sprintf("Request to %s returned status code %d", url, response.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(response.Body, 1024))
+	if err != nil {
+		return fmt.Sprintf("Could not read %s", url)
+	}
+	if strings.TrimSpace(string(body)) != domain.VerificationToken {
+		return fmt.Sprintf("The file %s does not contain the verification token", url)
+	}
+
+	return ""
+}
+
+// newVerificationResolver returns a resolver that uses the given DNS server, like "127.0.0.1:53",
+// or the resolver of the system when the server is empty.
+func newVerificationResolver(server string) TXTResolver {
+	if server == "" {
+		return net.DefaultResolver
+	}
+
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			dialer := net.Dialer{Timeout: verificationTimeout}
+			return dialer.DialContext(ctx, network, server)
+		},
+	}
+}
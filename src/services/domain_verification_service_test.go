@@ -0,0 +1,107 @@
+package services
+
+import (
+	"api-app/main/src/models"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// stubResolver answers the TXT lookups from its records, or with its error.
+type stubResolver struct {
+	records map[string][]string
+	err     error
+	lookups []string
+}
+
+func (r *stubResolver) LookupTXT(_ context.Context, name string) ([]string, error) {
+	r.lookups = append(r.lookups, name)
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	return r.records[name], nil
+}
+
+// useStubResolver replaces the verification resolver for the duration of the test.
+func useStubResolver(t *testing.T, resolver *stubResolver) {
+	t.Helper()
+
+	original := VerificationResolver
+	VerificationResolver = resolver
+	t.Cleanup(func() { VerificationResolver = original })
+}
+
+func TestCheckVerificationRecordMatchingToken(t *testing.T) {
+	domain := &models.Domain{Name: "www.example.com", VerificationToken: "token"}
+	resolver := &stubResolver{records: map[string][]string{
+		"_app-verification.www.example.com": {"v=spf1 -all", "app-verification=token"},
+	}}
+	useStubResolver(t, resolver)
+
+	if reason := checkVerificationRecord(context.Background(), domain); reason != "" {
+		t.Errorf("expected the domain to be verified, got %q", reason)
+	}
+}
+
+func TestCheckVerificationRecordWildcard(t *testing.T) {
+	domain := &models.Domain{Name: "*.example.com", VerificationToken: "token"}
+	resolver := &stubResolver{records: map[string][]string{
+		"_app-verification.example.com": {"app-verification=token"},
+	}}
+	useStubResolver(t, resolver)
+
+	if reason := checkVerificationRecord(context.Background(), domain); reason != "" {
+		t.Errorf("expected the wildcard domain to be verified on its parent domain, got %q", reason)
+	}
+	if len(resolver.lookups) != 1 || resolver.lookups[0] != "_app-verification.example.com" {
+		t.Errorf("expected a lookup of the parent domain, got %v", resolver.lookups)
+	}
+}
+
+func TestCheckVerificationRecordMissingToken(t *testing.T) {
+	tests := map[string][]string{
+		"no records":     nil,
+		"other token":    {"app-verification=other"},
+		"token as value": {"token"},
+	}
+
+	for name, records := range tests {
+		t.Run(name, func(t *testing.T) {
+			domain := &models.Domain{Name: "www.example.com", VerificationToken: "token"}
+			useStubResolver(t, &stubResolver{records: map[string][]string{"_app-verification.www.example.com": records}})
+
+			reason := checkVerificationRecord(context.Background(), domain)
+			if !strings.Contains(reason, "does not contain app-verification=token") {
+				t.Errorf("expected the missing token to be reported, got %q", reason)
+			}
+		})
+	}
+}
+
+func TestCheckVerificationRecordResolverError(t *testing.T) {
+	domain := &models.Domain{Name: "www.example.com", VerificationToken: "token"}
+	useStubResolver(t, &stubResolver{err: errors.New("server misbehaving")})
+
+	reason := checkVerificationRecord(context.Background(), domain)
+	if !strings.Contains(reason, "Could not look up the TXT record _app-verification.www.example.com") ||
+		!strings.Contains(reason, "server misbehaving") {
+		t.Errorf("expected the resolver error to be reported, got %q", reason)
+	}
+}
+
+func TestCheckVerificationFileRejectsInternalAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("expected no request, got %s", r.URL)
+	}))
+	t.Cleanup(server.Close)
+
+	domain := &models.Domain{Name: strings.TrimPrefix(server.URL, "http://"), VerificationToken: "token"}
+	reason := checkVerificationFile(context.Background(), domain)
+	if reason != "Could not request "+server.URL+VerificationPath {
+		t.Errorf("expected the request to be rejected without its error, got %q", reason)
+	}
+}
@@ -0,0 +1,375 @@
+package services
+
+import (
+	"api-app/main/src/cache"
+	"api-app/main/src/database"
+	"api-app/main/src/dto/requests"
+	"api-app/main/src/enums"
+	"api-app/main/src/models"
+	"api-app/main/src/utils"
+	"fmt"
+	"gorm.io/gorm"
+)
+
+// DomainPromotion struct to hold the changes of a single domain in a promotion.
+type DomainPromotion struct {
+	DomainID uint            `json:"domainId"`
+	Name     string          `json:"name"`
+	Changes  []SettingChange `json:"changes"`
+}
+
+// Promotion struct to hold the changes needed to promote the settings of an environment to another.
+type Promotion struct {
+	From    string            `json:"from"`
+	To      string            `json:"to"`
+	App     []SettingChange   `json:"app"`
+	Domains []DomainPromotion `json:"domains"`
+}
+
+// EnvironmentState struct holds the state of an environment in the audit log.
+type EnvironmentState struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+// IsEnvironmentAvailable method to check if an environment of an app exists.
+func IsEnvironmentAvailable(appID uint, name string) (bool, error) {
+	if result := database.Pg.Limit(1).Find(&models.Environment{}, "app_id = ? AND name = ?", appID, name); result.Error != nil {
+		return false, result.Error
+	} else {
+		return result.RowsAffected == 1, nil
+	}
+}
+
+// GetEnvironmentsByAppID method to get the environments of an app.
+func GetEnvironmentsByAppID(appID uint) (*[]models.Environment, error) {
+	environments := make([]models.Environment, 0)
+
+	if result := database.Pg.Where("app_id = ?", appID).Order("name").Find(&environments); result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &environments, nil
+}
+
+// GetEnvironmentByName method to get an environment of an app by its name.
+func GetEnvironmentByName(appID uint, name string) (*models.Environment, error) {
+	environment := &models.Environment{}
+
+	if result := database.Pg.Find(environment, "app_id = ? AND name = ?", appID, name); result.Error != nil {
+		return nil, result.Error
+	}
+
+	return environment, nil
+}
+
+// CreateEnvironment method to create an environment.
+func CreateEnvironment(appID uint, request *requests.CreateEnvironment, actor *utils.Actor) (*models.Environment, error) {
+	environment := &models.Environment{AppID: appID, Name: request.Name}
+
+	// Start a new transaction
+	tx := database.Pg.Begin()
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	if result := tx.Create(environment); result.Error != nil {
+		tx.Rollback()
+		return nil, result.Error
+	}
+
+	if err := recordAudit(tx, actor, enums.AuditEnvironmentCreated, appID, 0, EnvironmentAuditTarget(environment.ID), nil, newEnvironmentState(environment)); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	return environment, nil
+}
+
+// DeleteEnvironment method to delete an environment with the settings of the app and its domains in it.
+// The removed settings are stored as a new revision of the app and each domain that had any.
+func DeleteEnvironment(app *models.App, environment *models.Environment, actor *utils.Actor) error {
+	// Start a new transaction
+	tx := database.Pg.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	if err := replaceEnvironmentSettings(tx, app, environment.Name, nil, nil, actor); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// The settings of the deleted domains of the app are removed as well, so they do not return on a restore.
+	if result := tx.Where("domain_id IN (?) AND environment = ?", tx.Unscoped().Model(&models.Domain{}).Select("id").Where("app_id = ?", app.ID), environment.Name).
+		Delete(&models.DomainSetting{}); result.Error != nil {
+		tx.Rollback()
+		return result.Error
+	}
+
+	if result := tx.Delete(environment); result.Error != nil {
+		tx.Rollback()
+		return result.Error
+	}
+
+	if err := recordAudit(tx, actor, enums.AuditEnvironmentDeleted, app.ID, 0, EnvironmentAuditTarget(environment.ID), newEnvironmentState(environment), nil); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_ = cache.Invalidate(appCacheTags(app)...)
+	_ = publishSettingsChanged(app.ID, 0)
+
+	return nil
+}
+
+// GetPromotion method to get the changes needed to make the settings of the target environment
+// equal to the settings of the source environment, for the app and each of its domains.
+func GetPromotion(app *models.App, from, to string) (*Promotion, error) {
+	return getPromotion(database.Pg, app, from, to)
+}
+
+// PromoteEnvironment method to replace the settings of the target environment with the settings
+// of the source environment, for the app and each of its domains.
+// The changes are stored as a new revision of the app and each domain that changed.
+func PromoteEnvironment(app *models.App, from, to string, actor *utils.Actor) (*Promotion, error) {
+	// Start a new transaction
+	tx := database.Pg.Begin()
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	promotion, err := getPromotion(tx, app, from, to)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	var appSettings []models.AppSetting
+	if result := tx.Where("app_id = ? AND environment = ?", app.ID, from).Find(&appSettings); result.Error != nil {
+		tx.Rollback()
+		return nil, result.Error
+	}
+
+	var domainSettings []models.DomainSetting
+	if result := tx.Where("domain_id IN (?) AND environment = ?", domainIDs(tx, app.ID), from).Find(&domainSettings); result.Error != nil {
+		tx.Rollback()
+		return nil, result.Error
+	}
+
+	if err := replaceEnvironmentSettings(tx, app, to, appSettings, domainSettings, actor); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if len(promotion.App) > 0 || len(promotion.Domains) > 0 {
+		_ = cache.Invalidate(appCacheTags(app)...)
+		_ = publishSettingsChanged(app.ID, 0)
+	}
+
+	return promotion, nil
+}
+
+// getPromotion method to compare the settings of two environments within a transaction.
+// Only the domains with changes are returned, and the values of secret settings are masked.
+func getPromotion(tx *gorm.DB, app *models.App, from, to string) (*Promotion, error) {
+	promotion := &Promotion{From: from, To: to, Domains: make([]DomainPromotion, 0)}
+
+	var appSettings []models.AppSetting
+	if result := tx.Where("app_id = ? AND environment IN ?", app.ID, []string{from, to}).Find(&appSettings); result.Error != nil {
+		return nil, result.Error
+	}
+	var fromApp, toApp []models.AppSetting
+	for i := range appSettings {
+		setting := appSettings[i]
+		isFrom := setting.Environment == from
+		setting.Environment = ""
+		if isFrom {
+			fromApp = append(fromApp, setting)
+		} else {
+			toApp = append(toApp, setting)
+		}
+	}
+	promotion.App = maskSecretChanges(diffSettingSnapshots(appSettingsToSnapshots(toApp), appSettingsToSnapshots(fromApp)))
+
+	var domains []models.Domain
+	if result := tx.Where("app_id = ?", app.ID).Order("name").Find(&domains); result.Error != nil {
+		return nil, result.Error
+	}
+
+	var domainSettings []models.DomainSetting
+	if result := tx.Where("domain_id IN (?) AND environment IN ?", domainIDs(tx, app.ID), []string{from, to}).Find(&domainSettings); result.Error != nil {
+		return nil, result.Error
+	}
+	fromDomains := make(map[uint][]models.DomainSetting)
+	toDomains := make(map[uint][]models.DomainSetting)
+	for i := range domainSettings {
+		setting := domainSettings[i]
+		isFrom := setting.Environment == from
+		setting.Environment = ""
+		if isFrom {
+			fromDomains[setting.DomainID] = append(fromDomains[setting.DomainID], setting)
+		} else {
+			toDomains[setting.DomainID] = append(toDomains[setting.DomainID], setting)
+		}
+	}
+
+	for i := range domains {
+		changes := maskSecretChanges(diffSettingSnapshots(domainSettingsToSnapshots(toDomains[domains[i].ID]), domainSettingsToSnapshots(fromDomains[domains[i].ID])))
+		if len(changes) > 0 {
+			promotion.Domains = append(promotion.Domains, DomainPromotion{
+				DomainID: domains[i].ID,
+				Name:     domains[i].Name,
+				Changes:  changes,
+			})
+		}
+	}
+
+	return promotion, nil
+}
+
+// replaceEnvironmentSettings method to replace the settings of an environment of the app and its domains
+// within a transaction. The given settings are copied into the environment, nil settings empty it.
+// A revision is stored and the version is bumped for the app and each domain whose settings changed.
+func replaceEnvironmentSettings(tx *gorm.DB, app *models.App, environment string, appSettings []models.AppSetting, domainSettings []models.DomainSetting, actor *utils.Actor) error {
+	// Replace the settings of the app.
+	var oldAppSettings []models.AppSetting
+	if result := tx.Where("app_id = ?", app.ID).Find(&oldAppSettings); result.Error != nil {
+		return result.Error
+	}
+
+	newAppSettings := make([]models.AppSetting, 0, len(oldAppSettings)+len(appSettings))
+	for i := range oldAppSettings {
+		if oldAppSettings[i].Environment != environment {
+			newAppSettings = append(newAppSettings, oldAppSettings[i])
+		}
+	}
+	copies := make([]models.AppSetting, len(appSettings))
+	for i := range appSettings {
+		copies[i] = models.AppSetting{
+			AppID:       app.ID,
+			Name:        appSettings[i].Name,
+			Level:       appSettings[i].Level,
+			Value:       appSettings[i].Value,
+			ValueType:   appSettings[i].ValueType,
+			Environment: environment,
+			Locked:      appSettings[i].Locked,
+		}
+	}
+	newAppSettings = append(newAppSettings, copies...)
+
+	if result := tx.Where("app_id = ? AND environment = ?", app.ID, environment).Delete(&models.AppSetting{}); result.Error != nil {
+		return result.Error
+	}
+	if len(copies) > 0 {
+		if result := tx.Create(&copies); result.Error != nil {
+			return result.Error
+		}
+	}
+
+	if revision, err := createAppSettingRevision(tx, app.ID, oldAppSettings, newAppSettings, actor, 0); err != nil {
+		return err
+	} else if revision != nil {
+		if err := bumpVersion(tx, &models.App{}, app.ID); err != nil {
+			return err
+		}
+	}
+
+	// Replace the settings of each domain.
+	var oldDomainSettings []models.DomainSetting
+	if result := tx.Where("domain_id IN (?)", domainIDs(tx, app.ID)).Find(&oldDomainSettings); result.Error != nil {
+		return result.Error
+	}
+
+	oldByDomain := make(map[uint][]models.DomainSetting)
+	newByDomain := make(map[uint][]models.DomainSetting)
+	for i := range oldDomainSettings {
+		setting := oldDomainSettings[i]
+		oldByDomain[setting.DomainID] = append(oldByDomain[setting.DomainID], setting)
+		if setting.Environment != environment {
+			newByDomain[setting.DomainID] = append(newByDomain[setting.DomainID], setting)
+		}
+	}
+	domainCopies := make([]models.DomainSetting, len(domainSettings))
+	for i := range domainSettings {
+		domainCopies[i] = models.DomainSetting{
+			DomainID:    domainSettings[i].DomainID,
+			Name:        domainSettings[i].Name,
+			Level:       domainSettings[i].Level,
+			Value:       domainSettings[i].Value,
+			ValueType:   domainSettings[i].ValueType,
+			Environment: environment,
+			Locked:      domainSettings[i].Locked,
+		}
+		newByDomain[domainCopies[i].DomainID] = append(newByDomain[domainCopies[i].DomainID], domainCopies[i])
+	}
+
+	if result := tx.Where("domain_id IN (?) AND environment = ?", domainIDs(tx, app.ID), environment).Delete(&models.DomainSetting{}); result.Error != nil {
+		return result.Error
+	}
+	if len(domainCopies) > 0 {
+		if result := tx.Create(&domainCopies); result.Error != nil {
+			return result.Error
+		}
+	}
+
+	for domainID := range oldByDomain {
+		if _, exists := newByDomain[domainID]; !exists {
+			newByDomain[domainID] = nil
+		}
+	}
+	for domainID := range newByDomain {
+		if revision, err := createDomainSettingRevision(tx, domainID, oldByDomain[domainID], newByDomain[domainID], actor, 0); err != nil {
+			return err
+		} else if revision != nil {
+			if err := bumpVersion(tx, &models.Domain{}, domainID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// domainIDs returns a subquery of the IDs of the domains of an app.
+func domainIDs(tx *gorm.DB, appID uint) *gorm.DB {
+	return tx.Model(&models.Domain{}).Select("id").Where("app_id = ?", appID)
+}
+
+// EnvironmentAuditTarget returns the target of the audit events of an environment.
+func EnvironmentAuditTarget(environmentID uint) string {
+	return fmt.Sprintf("environment:%d", environmentID)
+}
+
+// newEnvironmentState returns the state of an environment in the audit log.
+func newEnvironmentState(environment *models.Environment) *EnvironmentState {
+	return &EnvironmentState{ID: environment.ID, Name: environment.Name}
+}
+
+// environmentCacheKeySuffix returns the suffix of a settings cache key for an environment,
+// so the settings of each environment are cached on their own key.
+func environmentCacheKeySuffix(environment string) string {
+	if environment == "" {
+		return ""
+	}
+
+	return "@" + environment
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"api-app/main/src/cache"
+	"api-app/main/src/database"
+	"api-app/main/src/dto/requests"
+	"api-app/main/src/enums"
+	"api-app/main/src/models"
+	"api-app/main/src/utils"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetGlobalSettings method to get the global settings that are visible on a level.
+func GetGlobalSettings(level enums.Level) (*[]models.GlobalSetting, error) {
+	settings, _, err := cache.Load(GlobalSettingsCacheKey(level), func(tags *cache.Tags) ([]models.GlobalSetting, bool, error) {
+		tags.Add(GlobalCacheTag)
+
+		settings := make([]models.GlobalSetting, 0)
+		if result := database.Pg.Where("level = 'both' OR level = ?", level.String()).Find(&settings); result.Error != nil {
+			return nil, false, result.Error
+		}
+
+		return settings, true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &settings, nil
+}
+
+// GetAllGlobalSettings method to get every global setting, on every level.
+func GetAllGlobalSettings() (*[]models.GlobalSetting, error) {
+	settings := make([]models.GlobalSetting, 0)
+
+	if result := database.Pg.Order("name").Order("level").Find(&settings); result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &settings, nil
+}
+
+// GetGlobalSetting method to get a global setting by its name and level.
+func GetGlobalSetting(name string, level enums.Level) (*models.GlobalSetting, error) {
+	setting := &models.GlobalSetting{}
+
+	if result := database.Pg.Limit(1).Find(setting, "name = ? AND level = ?", name, level.String()); result.Error != nil {
+		return nil, result.Error
+	}
+
+	return setting, nil
+}
+
+// UpsertGlobalSetting method to create or update a single global setting.
+// The setting is identified by its name and level.
+func UpsertGlobalSetting(request *requests.GlobalSetting, actor *utils.Actor) (*models.GlobalSetting, error) {
+	setting := models.GlobalSetting{
+		Name:      request.Name,
+		Level:     enums.Level(request.Level),
+		Value:     request.Value,
+		ValueType: enums.ValueType(request.ValueType),
+		Locked:    request.Locked,
+	}
+
+	oldSetting, err := GetGlobalSetting(setting.Name, setting.Level)
+	if err != nil {
+		return nil, err
+	}
+	if err := sealSettingValue(setting.ValueType, &setting.Value, &oldSetting.ValueType, oldSetting.Value); err != nil {
+		return nil, err
+	}
+
+	// Start a new transaction
+	tx := database.Pg.Begin()
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	if result := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}, {Name: "level"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "value_type", "locked"}),
+	}).Create(&setting); result.Error != nil {
+		tx.Rollback()
+		return nil, result.Error
+	}
+
+	action := SettingAdded
+	var before *SettingSnapshot
+	if oldSetting.Name != "" {
+		action = SettingChanged
+		before = globalSettingToSnapshot(oldSetting)
+	}
+	if err := recordGlobalSettingsEvent(tx, action, &setting); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := recordAudit(tx, actor, enums.AuditGlobalSettingSaved, 0, 0, GlobalAuditTarget(setting.Name, setting.Level), auditState(before), globalSettingToSnapshot(&setting)); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	_ = cache.Invalidate(GlobalCacheTag)
+	_ = publishSettingsChanged(0, 0)
+
+	return &setting, nil
+}
+
+// DeleteGlobalSetting method to delete a single global setting.
+func DeleteGlobalSetting(setting *models.GlobalSetting, actor *utils.Actor) error {
+	// Start a new transaction
+	tx := database.Pg.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	if result := tx.Where("name = ? AND level = ?", setting.Name, setting.Level.String()).
+		Delete(&models.GlobalSetting{}); result.Error != nil {
+		tx.Rollback()
+		return result.Error
+	}
+
+	if err := recordGlobalSettingsEvent(tx, SettingRemoved, setting); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := recordAudit(tx, actor, enums.AuditGlobalSettingDeleted, 0, 0, GlobalAuditTarget(setting.Name, setting.Level), globalSettingToSnapshot(setting), nil); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_ = cache.Invalidate(GlobalCacheTag)
+	_ = publishSettingsChanged(0, 0)
+
+	return nil
+}
+
+// GlobalSettingsCacheKey returns the key for the global settings cache.
+func GlobalSettingsCacheKey(level enums.Level) string {
+	return fmt.Sprintf("settings:global:%s", level.String())
+}
+
+// recordGlobalSettingsEvent method to add the settings.changed event of a global setting to the outbox
+// within the transaction of the mutation.
+func recordGlobalSettingsEvent(tx *gorm.DB, action string, setting *models.GlobalSetting) error {
+	return recordEvent(tx, enums.SettingsChanged, 0, 0, SettingsEvent{
+		Global:  true,
+		Changes: []SettingsEventChange{{Action: action, Name: setting.Name, Level: setting.Level.String()}},
+	})
+}
+
+// globalSettingToSnapshot converts a global setting to a snapshot, which is its state in the audit log.
+func globalSettingToSnapshot(setting *models.GlobalSetting) *SettingSnapshot {
+	return &SettingSnapshot{
+		Name:      setting.Name,
+		Level:     setting.Level.String(),
+		Value:     setting.Value,
+		ValueType: setting.ValueType.String(),
+		Locked:    setting.Locked,
+	}
+}
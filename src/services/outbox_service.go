@@ -0,0 +1,252 @@
+package services
+
+import (
+	"api-app/main/src/cache"
+	"api-app/main/src/database"
+	"api-app/main/src/enums"
+	"api-app/main/src/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/valkey-io/valkey-go"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"math"
+	"os"
+	"strconv"
+	"time"
+)
+
+// outboxBatchSize is the maximum number of events that are relayed in a single run.
+const outboxBatchSize = 100
+
+// AppEvent struct holds the payload of the app events.
+type AppEvent struct {
+	ID      uint    `json:"id"`
+	Name    string  `json:"name"`
+	OldName *string `json:"oldName,omitempty"`
+}
+
+// DomainEvent struct holds the payload of the domain events.
+type DomainEvent struct {
+	ID        uint    `json:"id"`
+	AppID     uint    `json:"appId"`
+	Name      string  `json:"name"`
+	OldName   *string `json:"oldName,omitempty"`
+	SSL       bool    `json:"ssl"`
+	IpAddress string  `json:"ipAddress"`
+	AliasOfID *uint   `json:"aliasOfId,omitempty"`
+}
+
+// SettingsEvent struct holds the payload of the settings.changed event.
+// The DomainID is nil when the settings of the app itself have been changed.
+// A change of a global setting is marked as global, and has no app and revision.
+type SettingsEvent struct {
+	AppID    uint                  `json:"appId"`
+	DomainID *uint                 `json:"domainId"`
+	Global   bool                  `json:"global"`
+	Revision uint                  `json:"revision"`
+	Changes  []SettingsEventChange `json:"changes"`
+}
+
+// SettingsEventChange struct holds a single change of the settings.changed event.
+// The values are left out, so private and secret settings are not published to the stream and webhooks.
+type SettingsEventChange struct {
+	Action      string `json:"action"`
+	Name        string `json:"name"`
+	Level       string `json:"level"`
+	Environment string `json:"environment,omitempty"`
+}
+
+// StartOutboxRelay relays the pending outbox events to the Valkey stream until the context is done.
+// Events are marked as published after they are added to the stream, so they are delivered at least once.
+func StartOutboxRelay(ctx context.Context) {
+	interval, err := time.ParseDuration(os.Getenv("OUTBOX_POLL_INTERVAL"))
+	if err != nil || interval <= 0 {
+		interval = time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := RelayOutboxEvents(ctx); err != nil {
+				log.Errorf("Could not relay outbox events: %v", err)
+			}
+		}
+	}
+}
+
+// RelayOutboxEvents publishes a batch of pending outbox events to the Valkey stream.
+// Failed events are retried with an exponential backoff, and moved to the dead-letter list
+// after OUTBOX_MAX_ATTEMPTS attempts.
+// While Valkey is unavailable, the events stay pending without using up their attempts.
+func RelayOutboxEvents(ctx context.Context) error {
+	if !cache.Available() {
+		return nil
+	}
+
+	return database.Pg.Transaction(func(tx *gorm.DB) error {
+		var events []models.OutboxEvent
+		if result := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("published_at IS NULL AND failed_at IS NULL AND next_attempt_at <= ?", time.Now()).
+			Order("id").
+			Limit(outboxBatchSize).
+			Find(&events); result.Error != nil {
+			return result.Error
+		}
+
+		for i := range events {
+			event := &events[i]
+
+			if err := publishOutboxEvent(ctx, event); err != nil {
+				event.Attempts++
+				event.LastError = sql.NullString{String: err.Error(), Valid: true}
+				event.NextAttemptAt = time.Now().Add(outboxBackoff(event.Attempts))
+
+				// An event that can not be dead-lettered stays pending, so the dead-lettering is retried.
+				if event.Attempts >= outboxMaxAttempts() {
+					if err := deadLetterOutboxEvent(ctx, event); err != nil {
+						log.Errorf("Could not dead-letter outbox event %d: %v", event.ID, err)
+						event.LastError = sql.NullString{String: err.Error(), Valid: true}
+					} else {
+						event.FailedAt = sql.NullTime{Time: time.Now(), Valid: true}
+					}
+				}
+			} else {
+				event.PublishedAt = sql.NullTime{Time: time.Now(), Valid: true}
+			}
+
+			if result := tx.Save(event); result.Error != nil {
+				return result.Error
+			}
+		}
+
+		return nil
+	})
+}
+
+// OutboxStream returns the name of the Valkey stream the events are published to.
+func OutboxStream() string {
+	if stream := os.Getenv("OUTBOX_STREAM"); stream != "" {
+		return stream
+	}
+
+	return "app:events"
+}
+
+// recordEvent method to add an event to the outbox within the transaction of the mutation.
+// The deliveries of the webhooks that are subscribed to the event are added as well.
+// The domainID is zero for events that do not belong to a domain.
+func recordEvent(tx *gorm.DB, eventType enums.EventType, appID, domainID uint, payload interface{}) error {
+	value, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	event := models.OutboxEvent{
+		Type:          eventType,
+		AppID:         appID,
+		DomainID:      sql.NullInt64{Int64: int64(domainID), Valid: domainID != 0},
+		Payload:       string(value),
+		NextAttemptAt: time.Now(),
+	}
+
+	if result := tx.Create(&event); result.Error != nil {
+		return result.Error
+	}
+
+	return enqueueWebhookDeliveries(tx, &event)
+}
+
+// recordDomainEvent method to add a domain event to the outbox within the transaction of the mutation.
+func recordDomainEvent(tx *gorm.DB, eventType enums.EventType, domain *models.Domain, oldName *string) error {
+	return recordEvent(tx, eventType, domain.AppID, domain.ID, newDomainEvent(domain, oldName))
+}
+
+// newDomainEvent returns the payload of a domain event, which is also the state of the domain in the audit log.
+func newDomainEvent(domain *models.Domain, oldName *string) *DomainEvent {
+	return &DomainEvent{
+		ID:        domain.ID,
+		AppID:     domain.AppID,
+		Name:      domain.Name,
+		OldName:   oldName,
+		SSL:       domain.SSL,
+		IpAddress: domain.IpAddress,
+		AliasOfID: domain.AliasOfID,
+	}
+}
+
+// publishOutboxEvent adds the event to the Valkey stream.
+func publishOutboxEvent(ctx context.Context, event *models.OutboxEvent) error {
+	domainID := ""
+	if event.DomainID.Valid {
+		domainID = strconv.FormatInt(event.DomainID.Int64, 10)
+	}
+
+	_, err := cache.Do(ctx, func(b valkey.Builder) valkey.Completed {
+		return b.Xadd().Key(OutboxStream()).Id("*").FieldValue().
+			FieldValue("id", strconv.FormatUint(uint64(event.ID), 10)).
+			FieldValue("type", event.Type.String()).
+			FieldValue("appId", strconv.FormatUint(uint64(event.AppID), 10)).
+			FieldValue("domainId", domainID).
+			FieldValue("payload", event.Payload).
+			FieldValue("createdAt", event.CreatedAt.Format(time.RFC3339Nano)).
+			Build()
+	})
+
+	return err
+}
+
+// deadLetterOutboxEvent adds the event to the dead-letter list of the stream.
+func deadLetterOutboxEvent(ctx context.Context, event *models.OutboxEvent) error {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = cache.Do(ctx, func(b valkey.Builder) valkey.Completed {
+		return b.Lpush().Key(OutboxStream() + ":dead").Element(string(value)).Build()
+	})
+
+	return err
+}
+
+// outboxMaxAttempts returns the number of attempts before an event is dead-lettered.
+func outboxMaxAttempts() uint {
+	if attempts, err := strconv.ParseUint(os.Getenv("OUTBOX_MAX_ATTEMPTS"), 10, 32); err == nil && attempts > 0 {
+		return uint(attempts)
+	}
+
+	return 10
+}
+
+// outboxBackoff returns the delay before the next attempt, doubling every attempt up to an hour.
+func outboxBackoff(attempts uint) time.Duration {
+	backoff := time.Duration(math.Pow(2, float64(attempts))) * time.Second
+	if backoff > time.Hour {
+		return time.Hour
+	}
+
+	return backoff
+}
+
+// newSettingsEventChanges converts the changes of a revision to the changes of the settings.changed event.
+func newSettingsEventChanges(changes []SettingChange) []SettingsEventChange {
+	eventChanges := make([]SettingsEventChange, len(changes))
+	for i := range changes {
+		eventChanges[i] = SettingsEventChange{
+			Action:      changes[i].Action,
+			Name:        changes[i].Name,
+			Level:       changes[i].Level,
+			Environment: changes[i].Environment,
+		}
+	}
+
+	return eventChanges
+}
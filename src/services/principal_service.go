@@ -0,0 +1,221 @@
+package services
+
+import (
+	"api-app/main/src/database"
+	"api-app/main/src/dto/requests"
+	"api-app/main/src/enums"
+	"api-app/main/src/models"
+	"api-app/main/src/utils"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"gorm.io/gorm"
+)
+
+// principalKeySize is the number of random bytes of a principal key.
+const principalKeySize = 32
+
+// PrincipalState struct holds a principal as it is stored in the audit log. The key is never stored.
+type PrincipalState struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+// IsPrincipalAvailable method to check if a principal name is taken.
+func IsPrincipalAvailable(name string) (bool, error) {
+	var count int64
+	if result := database.Pg.Model(&models.Principal{}).Where("name = ?", name).Count(&count); result.Error != nil {
+		return false, result.Error
+	}
+
+	return count == 1, nil
+}
+
+// GetPrincipals method to get all principals with their grants.
+func GetPrincipals() (*[]models.Principal, error) {
+	principals := make([]models.Principal, 0)
+
+	if result := database.Pg.Preload("Grants", orderGrants).Order("id").Find(&principals); result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &principals, nil
+}
+
+// GetPrincipalById method to get a principal with its grants by its ID.
+func GetPrincipalById(id uint) (*models.Principal, error) {
+	principal := &models.Principal{}
+
+	if result := database.Pg.Preload("Grants", orderGrants).Find(principal, "id = ?", id); result.Error != nil {
+		return nil, result.Error
+	}
+
+	return principal, nil
+}
+
+// GetPrincipalByKey method to get the principal of a key, the ID is 0 when the key is unknown.
+func GetPrincipalByKey(key string) (*models.Principal, error) {
+	principal := &models.Principal{}
+
+	if result := database.Pg.Find(principal, "key_hash = ?", HashPrincipalKey(key)); result.Error != nil {
+		return nil, result.Error
+	}
+
+	return principal, nil
+}
+
+// IsGranted method to check if a principal may perform an action on an app.
+func IsGranted(principalID, appID uint, action enums.Permission) (bool, error) {
+	var count int64
+	if result := database.Pg.Model(&models.Grant{}).
+		Where("principal_id = ? AND app_id = ? AND action = ?", principalID, appID, action).
+		Count(&count); result.Error != nil {
+		return false, result.Error
+	}
+
+	return count == 1, nil
+}
+
+// CreatePrincipal method to create a principal with a new random key.
+// The key is only returned here, only its hash is stored.
+func CreatePrincipal(request *requests.CreatePrincipal, actor *utils.Actor) (*models.Principal, string, error) {
+	key, err := utils.GenerateToken(principalKeySize)
+	if err != nil {
+		return nil, "", err
+	}
+
+	principal := &models.Principal{
+		Name:    request.Name,
+		KeyHash: HashPrincipalKey(key),
+		Grants:  make([]models.Grant, 0),
+	}
+
+	// Start a new transaction
+	tx := database.Pg.Begin()
+	if tx.Error != nil {
+		return nil, "", tx.Error
+	}
+
+	if result := tx.Create(principal); result.Error != nil {
+		tx.Rollback()
+		return nil, "", result.Error
+	}
+
+	if err := recordAudit(tx, actor, enums.AuditPrincipalCreated, 0, 0, PrincipalAuditTarget(principal.ID), nil, &PrincipalState{ID: principal.ID, Name: principal.Name}); err != nil {
+		tx.Rollback()
+		return nil, "", err
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return nil, "", err
+	}
+
+	return principal, key, nil
+}
+
+// DeletePrincipal method to delete a principal with its grants.
+func DeletePrincipal(principal *models.Principal, actor *utils.Actor) error {
+	// Start a new transaction
+	tx := database.Pg.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	if result := tx.Delete(principal); result.Error != nil {
+		tx.Rollback()
+		return result.Error
+	}
+
+	if err := recordAudit(tx, actor, enums.AuditPrincipalDeleted, 0, 0, PrincipalAuditTarget(principal.ID), &PrincipalState{ID: principal.ID, Name: principal.Name}, nil); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return nil
+}
+
+// UpdateGrants method to replace the actions a principal may perform on an app.
+// No actions removes every grant of the principal on the app.
+func UpdateGrants(principal *models.Principal, appID uint, actions []string, actor *utils.Actor) (*models.Principal, error) {
+	// Start a new transaction
+	tx := database.Pg.Begin()
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	oldActions := make([]string, 0)
+	if result := tx.Model(&models.Grant{}).
+		Where("principal_id = ? AND app_id = ?", principal.ID, appID).
+		Order("action").
+		Pluck("action", &oldActions); result.Error != nil {
+		tx.Rollback()
+		return nil, result.Error
+	}
+
+	if result := tx.Where("principal_id = ? AND app_id = ?", principal.ID, appID).Delete(&models.Grant{}); result.Error != nil {
+		tx.Rollback()
+		return nil, result.Error
+	}
+
+	if len(actions) > 0 {
+		grants := make([]models.Grant, len(actions))
+		for i := range actions {
+			grants[i] = models.Grant{PrincipalID: principal.ID, AppID: appID, Action: enums.Permission(actions[i])}
+		}
+		if result := tx.Create(&grants); result.Error != nil {
+			tx.Rollback()
+			return nil, result.Error
+		}
+	}
+
+	if err := recordAudit(tx, actor, enums.AuditGrantsChanged, appID, 0, PrincipalAuditTarget(principal.ID), oldActions, actions); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	return GetPrincipalById(principal.ID)
+}
+
+// GetAppIDByName method to get the ID of an app by its name, the ID is 0 when the app does not exist.
+func GetAppIDByName(name string) (uint, error) {
+	var appID uint
+	if result := database.Pg.Model(&models.App{}).
+		Select("id").
+		Where("name = ?", name).
+		Scan(&appID); result.Error != nil {
+		return 0, result.Error
+	}
+
+	return appID, nil
+}
+
+// orderGrants orders the preloaded grants of a principal by app and action.
+func orderGrants(db *gorm.DB) *gorm.DB {
+	return db.Order("app_id, action")
+}
+
+// HashPrincipalKey returns the hex encoded SHA-256 hash of a principal key, as it is stored.
+func HashPrincipalKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(hash[:])
+}
+
+// PrincipalAuditTarget returns the target of the audit events of a principal.
+func PrincipalAuditTarget(principalID uint) string {
+	return fmt.Sprintf("principal:%d", principalID)
+}
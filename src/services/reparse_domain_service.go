@@ -0,0 +1,136 @@
+package services
+
+import (
+	"api-app/main/src/cache"
+	"api-app/main/src/database"
+	"api-app/main/src/enums"
+	"api-app/main/src/models"
+	"api-app/main/src/utils"
+	"database/sql"
+	"gorm.io/gorm"
+)
+
+// reparseBatchSize is the number of domains that are loaded at once while re-parsing.
+const reparseBatchSize = 500
+
+// ReparseFailure struct holds a domain that could not be re-parsed.
+type ReparseFailure struct {
+	ID    uint
+	Name  string
+	Error error
+}
+
+// ReparseState struct holds the parsed name of a domain in the audit log.
+type ReparseState struct {
+	Name        string  `json:"name"`
+	Sub         *string `json:"sub"`
+	SecondLevel string  `json:"secondLevel"`
+	TopLevel    string  `json:"topLevel"`
+}
+
+// ReparseDomains method to parse the names of all domains again, including the deleted ones,
+// and to store their canonical name, subdomain, second-level domain and top-level domain.
+// It returns the number of updated domains and the domains that could not be parsed or saved.
+func ReparseDomains(actor *utils.Actor) (int, []ReparseFailure, error) {
+	updated := 0
+	failures := make([]ReparseFailure, 0)
+	var domains []models.Domain
+
+	result := database.Pg.Unscoped().Order("id").FindInBatches(&domains, reparseBatchSize, func(batch *gorm.DB, _ int) error {
+		for i := range domains {
+			changed, err := reparseDomain(&domains[i], actor)
+			if err != nil {
+				failures = append(failures, ReparseFailure{ID: domains[i].ID, Name: domains[i].Name, Error: err})
+			} else if changed {
+				updated++
+			}
+		}
+
+		return nil
+	})
+	if result.Error != nil {
+		return updated, failures, result.Error
+	}
+
+	return updated, failures, nil
+}
+
+// reparseDomain method to parse the name of a domain again and to store the result when it changed.
+// A changed name is recorded as a domain.updated event, and every change is recorded in the audit log.
+func reparseDomain(domain *models.Domain, actor *utils.Actor) (bool, error) {
+	name, err := utils.NormalizeDomain(domain.Name)
+	if err != nil {
+		return false, err
+	}
+	subdomain, secondLevelDomain, topLevelDomain, err := utils.ExtractDomain(name)
+	if err != nil {
+		return false, err
+	}
+
+	sub := sql.NullString{String: subdomain, Valid: subdomain != ""}
+	if domain.Name == name && domain.Sub == sub && domain.SecondLevel == secondLevelDomain && domain.TopLevel == topLevelDomain {
+		return false, nil
+	}
+
+	oldName := domain.Name
+	before := newReparseState(domain)
+	domain.Name = name
+	domain.Sub = sub
+	domain.SecondLevel = secondLevelDomain
+	domain.TopLevel = topLevelDomain
+
+	// Start a new transaction
+	tx := database.Pg.Begin()
+	if tx.Error != nil {
+		return false, tx.Error
+	}
+
+	if result := tx.Unscoped().Model(domain).UpdateColumns(map[string]interface{}{
+		"name":         domain.Name,
+		"sub":          domain.Sub,
+		"second_level": domain.SecondLevel,
+		"top_level":    domain.TopLevel,
+	}); result.Error != nil {
+		tx.Rollback()
+		return false, result.Error
+	}
+
+	if oldName != name {
+		if err := recordDomainEvent(tx, enums.DomainUpdated, domain, &oldName); err != nil {
+			tx.Rollback()
+			return false, err
+		}
+	}
+
+	if err := recordAudit(tx, actor, enums.AuditDomainReparsed, domain.AppID, domain.ID, DomainAuditTarget(domain.ID), before, newReparseState(domain)); err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	if oldName != name {
+		_ = cache.Invalidate(DomainCacheTag(domain.ID), DomainNameCacheTag(oldName), DomainNameCacheTag(name), ResolveCacheTag)
+	}
+
+	return true, nil
+}
+
+// newReparseState returns the parsed name of a domain in the audit log.
+func newReparseState(domain *models.Domain) *ReparseState {
+	state := &ReparseState{
+		Name:        domain.Name,
+		SecondLevel: domain.SecondLevel,
+		TopLevel:    domain.TopLevel,
+	}
+	if domain.Sub.Valid {
+		sub := domain.Sub.String
+		state.Sub = &sub
+	}
+
+	return state
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"api-app/main/src/cache"
+	"api-app/main/src/database"
+	"api-app/main/src/dto/responses"
+	"api-app/main/src/enums"
+	"api-app/main/src/models"
+	"api-app/main/src/utils"
+	"errors"
+	"fmt"
+)
+
+// ErrAmbiguousHost is returned when a host matches the domains of more than one app equally well.
+var ErrAmbiguousHost = errors.New("host matches the domains of multiple apps")
+
+// ErrHostNotFound is returned when a host matches no domain.
+var ErrHostNotFound = errors.New("host matches no domain")
+
+// ResolveHost method to find the domain that matches a normalized host, with its app.
+// An exact match is preferred over a wildcard, and a wildcard of a closer parent over a wildcard of a further one.
+// When verifiedOnly is true, domains that are not verified are skipped.
+// Returns an empty domain when no domain matches.
+func ResolveHost(host string, verifiedOnly bool) (*models.Domain, error) {
+	candidates := utils.HostCandidates(host)
+
+	var domains []models.Domain
+	query := database.Pg.Model(&models.Domain{}).
+		InnerJoins("App").
+		Where("domains.name IN ?", candidates)
+	if verifiedOnly {
+		query = query.Where("domains.verification_status = ?", enums.Verified)
+	}
+	if result := query.Find(&domains); result.Error != nil {
+		return nil, result.Error
+	}
+
+	var match *models.Domain
+	for i := range candidates {
+		for j := range domains {
+			if domains[j].Name != candidates[i] {
+				continue
+			}
+			if match != nil {
+				return nil, ErrAmbiguousHost
+			}
+			match = &domains[j]
+		}
+		if match != nil {
+			return match, nil
+		}
+	}
+
+	return &models.Domain{}, nil
+}
+
+// LoadResolvedHost method to get the resolved host from the cache, and to resolve it with the resolve function on a miss.
+// The key is tagged with the app and domain of the host, so it is deleted when they or their settings change.
+// A host that is not found is cached briefly as well.
+func LoadResolvedHost(host string, level enums.Level, environment string, resolve func() (*responses.ResolvedHost, error)) (*responses.ResolvedHost, error) {
+	resolvedHost, found, err := cache.Load(ResolvedHostCacheKey(host, level, environment), func(tags *cache.Tags) (*responses.ResolvedHost, bool, error) {
+		tags.Add(ResolveCacheTag, GlobalCacheTag)
+
+		resolvedHost, err := resolve()
+		if err == ErrHostNotFound {
+			return nil, false, nil
+		} else if err != nil {
+			return nil, false, err
+		}
+
+		tags.Add(AppCacheTag(resolvedHost.AppID), DomainCacheTag(resolvedHost.DomainID))
+		if resolvedHost.AliasOfID != nil {
+			tags.Add(DomainCacheTag(*resolvedHost.AliasOfID))
+		}
+
+		return resolvedHost, true, nil
+	})
+	if err != nil {
+		return nil, err
+	} else if !found {
+		return nil, ErrHostNotFound
+	}
+
+	return resolvedHost, nil
+}
+
+// ResolvedHostCacheKey returns the key for the resolved host cache.
+func ResolvedHostCacheKey(host string, level enums.Level, environment string) string {
+	return fmt.Sprintf("resolve:%s:%s", level.String(), host) + environmentCacheKeySuffix(environment)
+}
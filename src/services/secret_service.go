@@ -0,0 +1,165 @@
+package services
+
+import (
+	"api-app/main/src/database"
+	"api-app/main/src/enums"
+	"api-app/main/src/models"
+	"api-app/main/src/secrets"
+	"api-app/main/src/utils"
+	"github.com/gofiber/fiber/v2/log"
+	"gorm.io/gorm"
+)
+
+// SecretRevealedEvent struct holds the payload of the setting.revealed event.
+// The AppID is 0 for a global setting, and the DomainID is nil unless a domain setting has been revealed.
+type SecretRevealedEvent struct {
+	AppID       uint   `json:"appId"`
+	DomainID    *uint  `json:"domainId"`
+	Name        string `json:"name"`
+	Level       string `json:"level"`
+	Environment string `json:"environment,omitempty"`
+	Actor       string `json:"actor"`
+}
+
+// sealAppSetting encrypts the value of a secret app setting. A masked value keeps the stored secret
+// of the old setting with the same name, level and environment, so a masked read can be written back as it is.
+func sealAppSetting(setting *models.AppSetting, oldSettings []models.AppSetting) error {
+	for i := range oldSettings {
+		if oldSettings[i].Name == setting.Name && oldSettings[i].Level == setting.Level && oldSettings[i].Environment == setting.Environment {
+			return sealSettingValue(setting.ValueType, &setting.Value, &oldSettings[i].ValueType, oldSettings[i].Value)
+		}
+	}
+
+	return sealSettingValue(setting.ValueType, &setting.Value, nil, "")
+}
+
+// sealDomainSetting encrypts the value of a secret domain setting. A masked value keeps the stored secret
+// of the old setting with the same name, level and environment, so a masked read can be written back as it is.
+func sealDomainSetting(setting *models.DomainSetting, oldSettings []models.DomainSetting) error {
+	for i := range oldSettings {
+		if oldSettings[i].Name == setting.Name && oldSettings[i].Level == setting.Level && oldSettings[i].Environment == setting.Environment {
+			return sealSettingValue(setting.ValueType, &setting.Value, &oldSettings[i].ValueType, oldSettings[i].Value)
+		}
+	}
+
+	return sealSettingValue(setting.ValueType, &setting.Value, nil, "")
+}
+
+// sealSettingValue encrypts the value when the value type is a secret.
+// The mask is replaced by the old value instead, when the old setting is a secret as well.
+func sealSettingValue(valueType enums.ValueType, value *string, oldValueType *enums.ValueType, oldValue string) error {
+	if valueType != enums.Secret {
+		return nil
+	}
+	if *value == secrets.Mask && oldValueType != nil && *oldValueType == enums.Secret {
+		*value = oldValue
+		return nil
+	}
+
+	encrypted, err := secrets.Encrypt(*value)
+	if err != nil {
+		return err
+	}
+	*value = encrypted
+
+	return nil
+}
+
+// maskSecretChanges masks the values of the changes from or to a secret, so a change that made a setting
+// a secret does not show its plain value either.
+func maskSecretChanges(changes []SettingChange) []SettingChange {
+	mask := secrets.Mask
+	for i := range changes {
+		oldSecret := changes[i].OldValueType != nil && *changes[i].OldValueType == enums.Secret.String()
+		newSecret := changes[i].NewValueType != nil && *changes[i].NewValueType == enums.Secret.String()
+		if !oldSecret && !newSecret {
+			continue
+		}
+		if changes[i].OldValue != nil {
+			changes[i].OldValue = &mask
+		}
+		if changes[i].NewValue != nil {
+			changes[i].NewValue = &mask
+		}
+	}
+
+	return changes
+}
+
+// GetSecretSettingNamesByAppID method to get the names of the secret settings and definitions of an app,
+// so their values are masked as well in the revisions that were stored before they became a secret.
+func GetSecretSettingNamesByAppID(appID uint) (map[string]bool, error) {
+	return secretSettingNames(
+		database.Pg.Model(&models.AppSetting{}).Where("app_id = ? AND value_type = ?", appID, enums.Secret),
+		database.Pg.Model(&models.SettingDefinition{}).Where("app_id = ? AND value_type = ?", appID, enums.Secret),
+	)
+}
+
+// GetSecretSettingNamesByDomainID method to get the names of the secret settings of a domain and the secret
+// definitions of its app, so their values are masked as well in the revisions that were stored before they became a secret.
+func GetSecretSettingNamesByDomainID(domainID uint) (map[string]bool, error) {
+	return secretSettingNames(
+		database.Pg.Model(&models.DomainSetting{}).Where("domain_id = ? AND value_type = ?", domainID, enums.Secret),
+		database.Pg.Model(&models.SettingDefinition{}).
+			Where("app_id = (?) AND value_type = ?", database.Pg.Unscoped().Model(&models.Domain{}).Select("app_id").Where("id = ?", domainID), enums.Secret),
+	)
+}
+
+// secretSettingNames method to collect the names of the settings or definitions of the queries.
+func secretSettingNames(queries ...*gorm.DB) (map[string]bool, error) {
+	names := make(map[string]bool)
+	for _, query := range queries {
+		var result []string
+		if db := query.Distinct().Pluck("name", &result); db.Error != nil {
+			return nil, db.Error
+		}
+		for _, name := range result {
+			names[name] = true
+		}
+	}
+
+	return names, nil
+}
+
+// RevealSecret method to decrypt the value of a secret setting.
+// Every reveal is recorded in the outbox, the audit log and the log, so it can be audited.
+func RevealSecret(value string, event *SecretRevealedEvent, actor *utils.Actor) (string, error) {
+	plaintext, err := secrets.Decrypt(value)
+	if err != nil {
+		return "", err
+	}
+	event.Actor = actor.Name
+
+	var domainID uint
+	target := GlobalAuditTarget(event.Name, enums.Level(event.Level))
+	if event.DomainID != nil {
+		domainID = *event.DomainID
+		target = DomainAuditTarget(domainID)
+	} else if event.AppID != 0 {
+		target = AppAuditTarget(event.AppID)
+	}
+
+	// Start a new transaction
+	tx := database.Pg.Begin()
+	if tx.Error != nil {
+		return "", tx.Error
+	}
+
+	if err := recordEvent(tx, enums.SettingRevealed, event.AppID, domainID, event); err != nil {
+		tx.Rollback()
+		return "", err
+	}
+	if err := recordAudit(tx, actor, enums.AuditSettingRevealed, event.AppID, domainID, target, nil, event); err != nil {
+		tx.Rollback()
+		return "", err
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return "", err
+	}
+	log.Infof("Secret setting %s (%s) of app %d revealed by %s", event.Name, event.Level, event.AppID, event.Actor)
+
+	return plaintext, nil
+}
@@ -0,0 +1,269 @@
+package services
+
+import (
+	"api-app/main/src/cache"
+	"api-app/main/src/database"
+	"api-app/main/src/dto/requests"
+	"api-app/main/src/enums"
+	"api-app/main/src/models"
+	"api-app/main/src/secrets"
+	"api-app/main/src/utils"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+// SettingDefinitionState struct holds the state of a setting definition in the audit log.
+// The default of a secret definition is masked.
+type SettingDefinitionState struct {
+	ID            uint            `json:"id"`
+	Name          string          `json:"name"`
+	Description   string          `json:"description"`
+	Level         string          `json:"level"`
+	ValueType     string          `json:"valueType"`
+	Default       *string         `json:"default"`
+	Min           *float64        `json:"min"`
+	Max           *float64        `json:"max"`
+	Regex         *string         `json:"regex"`
+	AllowedValues json.RawMessage `json:"allowedValues"`
+	JSONSchema    json.RawMessage `json:"jsonSchema"`
+	Required      bool            `json:"required"`
+	Owner         string          `json:"owner"`
+}
+
+// GetSettingDefinitionsByAppName method to get the setting definitions by app name.
+func GetSettingDefinitionsByAppName(appName string) (*[]models.SettingDefinition, error) {
+	definitions, _, err := cache.Load(SettingDefinitionsCacheKeyOnName(appName), func(tags *cache.Tags) ([]models.SettingDefinition, bool, error) {
+		tags.Add(AppNameCacheTag(appName))
+
+		definitions := make([]models.SettingDefinition, 0)
+		result := database.Pg.Model(&models.SettingDefinition{}).
+			Joins("JOIN apps ON apps.id = setting_definitions.app_id AND apps.deleted_at IS NULL").
+			Where("apps.name = ?", appName).
+			Order("setting_definitions.name").
+			Find(&definitions)
+
+		return definitions, true, result.Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &definitions, nil
+}
+
+// GetSettingDefinitionsByAppID method to get the setting definitions by app ID.
+func GetSettingDefinitionsByAppID(appID uint) (*[]models.SettingDefinition, error) {
+	definitions, _, err := cache.Load(SettingDefinitionsCacheKeyOnId(appID), func(tags *cache.Tags) ([]models.SettingDefinition, bool, error) {
+		tags.Add(AppCacheTag(appID))
+
+		definitions := make([]models.SettingDefinition, 0)
+		result := database.Pg.Model(&models.SettingDefinition{}).
+			Where("app_id = ?", appID).
+			Order("name").
+			Find(&definitions)
+
+		return definitions, true, result.Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &definitions, nil
+}
+
+// GetSettingDefinitionByName method to get a setting definition of an app by its name.
+func GetSettingDefinitionByName(appID uint, name string) (*models.SettingDefinition, error) {
+	definition := &models.SettingDefinition{}
+
+	if result := database.Pg.Find(definition, "app_id = ? AND name = ?", appID, name); result.Error != nil {
+		return nil, result.Error
+	}
+
+	return definition, nil
+}
+
+// BuildSettingDefinition method to build a setting definition from a request.
+func BuildSettingDefinition(appID uint, name string, request *requests.UpdateSettingDefinition) (*models.SettingDefinition, error) {
+	definition := &models.SettingDefinition{
+		AppID:       appID,
+		Name:        name,
+		Description: request.Description,
+		Level:       enums.Level(request.Level),
+		ValueType:   enums.ValueType(request.ValueType),
+		Required:    request.Required,
+		Owner:       request.Owner,
+	}
+
+	if request.Default != nil {
+		definition.Default = sql.NullString{String: *request.Default, Valid: true}
+	}
+	if request.Min != nil {
+		definition.Min = sql.NullFloat64{Float64: *request.Min, Valid: true}
+	}
+	if request.Max != nil {
+		definition.Max = sql.NullFloat64{Float64: *request.Max, Valid: true}
+	}
+	if request.Regex != nil {
+		definition.Regex = sql.NullString{String: *request.Regex, Valid: true}
+	}
+	if len(request.AllowedValues) > 0 {
+		allowedValues, err := json.Marshal(request.AllowedValues)
+		if err != nil {
+			return nil, err
+		}
+		definition.AllowedValues = sql.NullString{String: string(allowedValues), Valid: true}
+	}
+	if len(request.JSONSchema) > 0 && string(request.JSONSchema) != "null" {
+		definition.JSONSchema = sql.NullString{String: string(request.JSONSchema), Valid: true}
+	}
+
+	return definition, nil
+}
+
+// CreateSettingDefinition method to create a setting definition.
+func CreateSettingDefinition(app *models.App, definition *models.SettingDefinition, actor *utils.Actor) (*models.SettingDefinition, error) {
+	// Start a new transaction
+	tx := database.Pg.Begin()
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	if result := tx.Create(definition); result.Error != nil {
+		tx.Rollback()
+		return nil, result.Error
+	}
+
+	if err := recordAudit(tx, actor, enums.AuditDefinitionCreated, app.ID, 0, SettingDefinitionAuditTarget(definition.ID), nil, newSettingDefinitionState(definition)); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	_ = cache.Invalidate(AppCacheTag(app.ID), AppNameCacheTag(app.Name))
+	_ = publishSettingsChanged(app.ID, 0)
+
+	return definition, nil
+}
+
+// UpdateSettingDefinition method to update a setting definition.
+func UpdateSettingDefinition(app *models.App, oldDefinition, newDefinition *models.SettingDefinition, actor *utils.Actor) (*models.SettingDefinition, error) {
+	newDefinition.ID = oldDefinition.ID
+	newDefinition.CreatedAt = oldDefinition.CreatedAt
+
+	// Start a new transaction
+	tx := database.Pg.Begin()
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	if result := tx.Save(newDefinition); result.Error != nil {
+		tx.Rollback()
+		return nil, result.Error
+	}
+
+	if err := recordAudit(tx, actor, enums.AuditDefinitionUpdated, app.ID, 0, SettingDefinitionAuditTarget(newDefinition.ID), newSettingDefinitionState(oldDefinition), newSettingDefinitionState(newDefinition)); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	_ = cache.Invalidate(AppCacheTag(app.ID), AppNameCacheTag(app.Name))
+	_ = publishSettingsChanged(app.ID, 0)
+
+	return newDefinition, nil
+}
+
+// DeleteSettingDefinition method to delete a setting definition.
+func DeleteSettingDefinition(app *models.App, definition *models.SettingDefinition, actor *utils.Actor) error {
+	_ = cache.Invalidate(AppCacheTag(app.ID), AppNameCacheTag(app.Name))
+
+	// Start a new transaction
+	tx := database.Pg.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	if result := tx.Delete(definition); result.Error != nil {
+		tx.Rollback()
+		return result.Error
+	}
+
+	if err := recordAudit(tx, actor, enums.AuditDefinitionDeleted, app.ID, 0, SettingDefinitionAuditTarget(definition.ID), newSettingDefinitionState(definition), nil); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_ = publishSettingsChanged(app.ID, 0)
+
+	return nil
+}
+
+// SettingDefinitionAuditTarget returns the target of the audit events of a setting definition.
+func SettingDefinitionAuditTarget(definitionID uint) string {
+	return fmt.Sprintf("definition:%d", definitionID)
+}
+
+// newSettingDefinitionState returns the state of a setting definition in the audit log.
+func newSettingDefinitionState(definition *models.SettingDefinition) *SettingDefinitionState {
+	state := &SettingDefinitionState{
+		ID:          definition.ID,
+		Name:        definition.Name,
+		Description: definition.Description,
+		Level:       definition.Level.String(),
+		ValueType:   definition.ValueType.String(),
+		Required:    definition.Required,
+		Owner:       definition.Owner,
+	}
+
+	if definition.Default.Valid {
+		value := definition.Default.String
+		if definition.ValueType == enums.Secret {
+			value = secrets.Mask
+		}
+		state.Default = &value
+	}
+	if definition.Min.Valid {
+		state.Min = &definition.Min.Float64
+	}
+	if definition.Max.Valid {
+		state.Max = &definition.Max.Float64
+	}
+	if definition.Regex.Valid {
+		state.Regex = &definition.Regex.String
+	}
+	if definition.AllowedValues.Valid {
+		state.AllowedValues = json.RawMessage(definition.AllowedValues.String)
+	}
+	if definition.JSONSchema.Valid {
+		state.JSONSchema = json.RawMessage(definition.JSONSchema.String)
+	}
+
+	return state
+}
+
+// SettingDefinitionsCacheKeyOnName returns the key for the setting definitions cache with a name.
+func SettingDefinitionsCacheKeyOnName(appName string) string {
+	return fmt.Sprintf("%s:definitions", appName)
+}
+
+// SettingDefinitionsCacheKeyOnId returns the key for the setting definitions cache with an id.
+func SettingDefinitionsCacheKeyOnId(appID uint) string {
+	return fmt.Sprintf("definitions:apps:%d", appID)
+}
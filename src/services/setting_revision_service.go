@@ -0,0 +1,162 @@
+package services
+
+import (
+	"api-app/main/src/models"
+	"encoding/json"
+	"sort"
+)
+
+// SettingSnapshot struct to hold a single setting as stored in a revision.
+type SettingSnapshot struct {
+	Name      string `json:"name"`
+	Level     string `json:"level"`
+	Value     string `json:"value"`
+	ValueType string `json:"valueType"`
+	// Environment is the name of the environment the setting overrides, or empty for the base setting.
+	Environment string `json:"environment,omitempty"`
+	Locked      bool   `json:"locked,omitempty"`
+}
+
+// SettingChange struct to hold a single difference between two revisions.
+type SettingChange struct {
+	Action       string  `json:"action"`
+	Name         string  `json:"name"`
+	Level        string  `json:"level"`
+	Environment  string  `json:"environment,omitempty"`
+	OldValue     *string `json:"oldValue"`
+	NewValue     *string `json:"newValue"`
+	OldValueType *string `json:"oldValueType"`
+	NewValueType *string `json:"newValueType"`
+	OldLocked    *bool   `json:"oldLocked"`
+	NewLocked    *bool   `json:"newLocked"`
+}
+
+// Possible actions of a SettingChange.
+const (
+	SettingAdded   = "added"
+	SettingChanged = "changed"
+	SettingRemoved = "removed"
+)
+
+// appSettingsToSnapshots converts app settings to sorted snapshots.
+func appSettingsToSnapshots(settings []models.AppSetting) []SettingSnapshot {
+	snapshots := make([]SettingSnapshot, len(settings))
+	for i := range settings {
+		snapshots[i] = SettingSnapshot{
+			Name:        settings[i].Name,
+			Level:       settings[i].Level.String(),
+			Value:       settings[i].Value,
+			ValueType:   settings[i].ValueType.String(),
+			Environment: settings[i].Environment,
+			Locked:      settings[i].Locked,
+		}
+	}
+	sortSnapshots(snapshots)
+
+	return snapshots
+}
+
+// domainSettingsToSnapshots converts domain settings to sorted snapshots.
+func domainSettingsToSnapshots(settings []models.DomainSetting) []SettingSnapshot {
+	snapshots := make([]SettingSnapshot, len(settings))
+	for i := range settings {
+		snapshots[i] = SettingSnapshot{
+			Name:        settings[i].Name,
+			Level:       settings[i].Level.String(),
+			Value:       settings[i].Value,
+			ValueType:   settings[i].ValueType.String(),
+			Environment: settings[i].Environment,
+			Locked:      settings[i].Locked,
+		}
+	}
+	sortSnapshots(snapshots)
+
+	return snapshots
+}
+
+// sortSnapshots sorts the snapshots on environment, name and level, so revisions are stored in a stable order.
+func sortSnapshots(snapshots []SettingSnapshot) {
+	sort.Slice(snapshots, func(i, j int) bool {
+		if snapshots[i].Environment != snapshots[j].Environment {
+			return snapshots[i].Environment < snapshots[j].Environment
+		}
+		if snapshots[i].Name != snapshots[j].Name {
+			return snapshots[i].Name < snapshots[j].Name
+		}
+		return snapshots[i].Level < snapshots[j].Level
+	})
+}
+
+// diffSettingSnapshots returns the changes needed to go from the old to the new snapshots.
+// Settings are matched on the combination of name, level and environment.
+func diffSettingSnapshots(oldSnapshots, newSnapshots []SettingSnapshot) []SettingChange {
+	type key struct{ name, level, environment string }
+	changes := make([]SettingChange, 0)
+
+	oldMap := make(map[key]SettingSnapshot, len(oldSnapshots))
+	for _, snapshot := range oldSnapshots {
+		oldMap[key{snapshot.Name, snapshot.Level, snapshot.Environment}] = snapshot
+	}
+
+	newMap := make(map[key]SettingSnapshot, len(newSnapshots))
+	for _, snapshot := range newSnapshots {
+		newMap[key{snapshot.Name, snapshot.Level, snapshot.Environment}] = snapshot
+
+		oldSnapshot, exists := oldMap[key{snapshot.Name, snapshot.Level, snapshot.Environment}]
+		if !exists {
+			changes = append(changes, SettingChange{
+				Action:       SettingAdded,
+				Name:         snapshot.Name,
+				Level:        snapshot.Level,
+				Environment:  snapshot.Environment,
+				NewValue:     &snapshot.Value,
+				NewValueType: &snapshot.ValueType,
+				NewLocked:    &snapshot.Locked,
+			})
+		} else if oldSnapshot.Value != snapshot.Value || oldSnapshot.ValueType != snapshot.ValueType || oldSnapshot.Locked != snapshot.Locked {
+			changes = append(changes, SettingChange{
+				Action:       SettingChanged,
+				Name:         snapshot.Name,
+				Level:        snapshot.Level,
+				Environment:  snapshot.Environment,
+				OldValue:     &oldSnapshot.Value,
+				NewValue:     &snapshot.Value,
+				OldValueType: &oldSnapshot.ValueType,
+				NewValueType: &snapshot.ValueType,
+				OldLocked:    &oldSnapshot.Locked,
+				NewLocked:    &snapshot.Locked,
+			})
+		}
+	}
+
+	for _, snapshot := range oldSnapshots {
+		if _, exists := newMap[key{snapshot.Name, snapshot.Level, snapshot.Environment}]; !exists {
+			changes = append(changes, SettingChange{
+				Action:       SettingRemoved,
+				Name:         snapshot.Name,
+				Level:        snapshot.Level,
+				Environment:  snapshot.Environment,
+				OldValue:     &snapshot.Value,
+				OldValueType: &snapshot.ValueType,
+				OldLocked:    &snapshot.Locked,
+			})
+		}
+	}
+
+	return changes
+}
+
+// marshalRevision marshals the snapshots and changes of a revision to JSON strings.
+func marshalRevision(snapshots []SettingSnapshot, changes []SettingChange) (string, string, error) {
+	settings, err := json.Marshal(snapshots)
+	if err != nil {
+		return "", "", err
+	}
+
+	diff, err := json.Marshal(changes)
+	if err != nil {
+		return "", "", err
+	}
+
+	return string(settings), string(diff), nil
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"api-app/main/src/cache"
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/valkey-io/valkey-go"
+	"sync"
+	"time"
+)
+
+// SettingsChangedChannel is the Valkey channel on which settings changes are published.
+const SettingsChangedChannel = "settings:changed"
+
+// SettingsChangedEvent struct holds the owner of settings that have been changed.
+// The DomainID is zero when the settings of the app itself have been changed,
+// and the AppID is zero as well when the global settings have been changed.
+type SettingsChangedEvent struct {
+	AppID    uint `json:"appId"`
+	DomainID uint `json:"domainId"`
+}
+
+// settingsChangedSubscriber struct holds a local subscriber of settings changes.
+type settingsChangedSubscriber struct {
+	events chan SettingsChangedEvent
+	filter func(event SettingsChangedEvent) bool
+}
+
+var settingsChangedHub = struct {
+	sync.Mutex
+	once        sync.Once
+	subscribers map[*settingsChangedSubscriber]struct{}
+}{
+	subscribers: make(map[*settingsChangedSubscriber]struct{}),
+}
+
+// SubscribeSettingsChanged registers a subscriber for the settings changes that pass the filter.
+// The first subscriber starts listening on the Valkey channel, so every instance receives all changes.
+// Events are coalesced, so a slow subscriber only receives one pending event.
+// The returned function must be called to unsubscribe.
+func SubscribeSettingsChanged(filter func(event SettingsChangedEvent) bool) (<-chan SettingsChangedEvent, func()) {
+	settingsChangedHub.once.Do(func() {
+		go listenSettingsChanged()
+	})
+
+	subscriber := &settingsChangedSubscriber{
+		events: make(chan SettingsChangedEvent, 1),
+		filter: filter,
+	}
+
+	settingsChangedHub.Lock()
+	settingsChangedHub.subscribers[subscriber] = struct{}{}
+	settingsChangedHub.Unlock()
+
+	return subscriber.events, func() {
+		settingsChangedHub.Lock()
+		delete(settingsChangedHub.subscribers, subscriber)
+		settingsChangedHub.Unlock()
+	}
+}
+
+// publishSettingsChanged publishes a settings change on the Valkey channel.
+func publishSettingsChanged(appID, domainID uint) error {
+	value, err := json.Marshal(SettingsChangedEvent{AppID: appID, DomainID: domainID})
+	if err != nil {
+		return err
+	}
+
+	_, err = cache.Do(context.Background(), func(b valkey.Builder) valkey.Completed {
+		return b.Publish().Channel(SettingsChangedChannel).Message(valkey.BinaryString(value)).Build()
+	})
+
+	return err
+}
+
+// listenSettingsChanged listens on the Valkey channel and passes the changes to the local subscribers.
+// When the subscription is lost, it is restored after a second.
+func listenSettingsChanged() {
+	for {
+		err := cache.Receive(context.Background(), func(b valkey.Builder) valkey.Completed {
+			return b.Subscribe().Channel(SettingsChangedChannel).Build()
+		}, func(message valkey.PubSubMessage) {
+			var event SettingsChangedEvent
+			if err := json.Unmarshal([]byte(message.Message), &event); err != nil {
+				return
+			}
+
+			settingsChangedHub.Lock()
+			for subscriber := range settingsChangedHub.subscribers {
+				if !subscriber.filter(event) {
+					continue
+				}
+				select {
+				case subscriber.events <- event:
+				default:
+					// An event is already pending, which resolves the latest settings anyway.
+				}
+			}
+			settingsChangedHub.Unlock()
+		})
+		if err != nil && err != cache.ErrUnavailable {
+			log.Errorf("Settings changed subscription lost: %v", err)
+		}
+
+		time.Sleep(time.Second)
+	}
+}
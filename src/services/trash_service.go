@@ -0,0 +1,212 @@
+package services
+
+import (
+	"api-app/main/src/cache"
+	"api-app/main/src/database"
+	"api-app/main/src/enums"
+	"api-app/main/src/models"
+	"api-app/main/src/utils"
+	"context"
+	"github.com/gofiber/fiber/v2/log"
+	"gorm.io/gorm"
+	"os"
+	"strconv"
+	"time"
+)
+
+// purgeBatchSize is the number of apps or domains that is purged per batch.
+const purgeBatchSize = 100
+
+// TrashQuery returns a query of the deleted apps and domains as models.TrashItem{},
+// so it can be filtered, sorted and paginated like a table.
+func TrashQuery() *gorm.DB {
+	apps := database.Pg.Unscoped().Model(&models.App{}).
+		Select("'app' AS type, id, name, NULL::bigint AS app_id, deleted_at, deletion_batch").
+		Where("deleted_at IS NOT NULL")
+	domains := database.Pg.Unscoped().Model(&models.Domain{}).
+		Select("'domain' AS type, id, name, app_id, deleted_at, deletion_batch").
+		Where("deleted_at IS NOT NULL")
+
+	return database.Pg.Table("(?) AS trash", database.Pg.Raw("? UNION ALL ?", apps, domains))
+}
+
+// IsDeletionBatchTaken method to check if a wildcard domain of a deletion batch has been taken by another app
+// since it was deleted, because the same wildcard can not be used by multiple apps.
+func IsDeletionBatchTaken(appID uint, batch string) (bool, error) {
+	var count int64
+	if result := database.Pg.Model(&models.Domain{}).
+		Where("name IN (?)", database.Pg.Unscoped().Model(&models.Domain{}).
+			Select("name").
+			Where("app_id = ? AND deletion_batch = ? AND name LIKE ?", appID, batch, "*.%")).
+		Count(&count); result.Error != nil {
+		return false, result.Error
+	}
+
+	return count > 0, nil
+}
+
+// PurgeApp method to permanently delete an app, deleted or not, with its domains, settings and revisions.
+// The app.deleted event is only recorded when the app was not deleted yet.
+func PurgeApp(app *models.App, actor *utils.Actor) error {
+	// Start a new transaction
+	tx := database.Pg.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	// The domains are loaded for their cache tags, the rows are deleted by the foreign keys.
+	if result := tx.Unscoped().Select("id", "app_id", "name").Where("app_id = ?", app.ID).Find(&app.Domains); result.Error != nil {
+		tx.Rollback()
+		return result.Error
+	}
+
+	if result := tx.Unscoped().Delete(app); result.Error != nil {
+		tx.Rollback()
+		return result.Error
+	}
+
+	if !app.DeletedAt.Valid {
+		if err := recordEvent(tx, enums.AppDeleted, app.ID, 0, AppEvent{ID: app.ID, Name: app.Name}); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := recordAppAudit(tx, actor, enums.AuditAppPurged, app.ID, &AppEvent{ID: app.ID, Name: app.Name}, nil); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_ = cache.Invalidate(appCacheTags(app)...)
+	if !app.DeletedAt.Valid {
+		_ = publishSettingsChanged(app.ID, 0)
+	}
+
+	return nil
+}
+
+// PurgeDomain method to permanently delete a domain, deleted or not, with its settings and revisions.
+// The domain.removed event is only recorded when the domain was not deleted yet.
+func PurgeDomain(domain *models.Domain, actor *utils.Actor) error {
+	// Start a new transaction
+	tx := database.Pg.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	if result := tx.Unscoped().Delete(domain); result.Error != nil {
+		tx.Rollback()
+		return result.Error
+	}
+
+	if !domain.DeletedAt.Valid {
+		if err := recordDomainEvent(tx, enums.DomainRemoved, domain, nil); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := recordDomainAudit(tx, actor, enums.AuditDomainPurged, domain, newDomainEvent(domain, nil), nil); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_ = cache.Invalidate(DomainCacheTag(domain.ID), DomainNameCacheTag(domain.Name), ResolveCacheTag)
+	if !domain.DeletedAt.Valid {
+		_ = publishSettingsChanged(domain.AppID, domain.ID)
+	}
+
+	return nil
+}
+
+// PurgeTrash method to permanently delete the apps and domains that were deleted before the cutoff.
+// Returns the number of purged apps and domains.
+func PurgeTrash(cutoff time.Time) (int, int, error) {
+	appCount, domainCount := 0, 0
+
+	var apps []models.App
+	if result := database.Pg.Unscoped().
+		Where("deleted_at < ?", cutoff).
+		Order("id").
+		FindInBatches(&apps, purgeBatchSize, func(batch *gorm.DB, _ int) error {
+			for i := range apps {
+				if err := PurgeApp(&apps[i], utils.SystemActor); err != nil {
+					return err
+				}
+				appCount++
+			}
+
+			return nil
+		}); result.Error != nil {
+		return appCount, domainCount, result.Error
+	}
+
+	// The domains of the purged apps are gone already, the remaining ones were deleted on their own.
+	var domains []models.Domain
+	result := database.Pg.Unscoped().
+		Where("deleted_at < ?", cutoff).
+		Order("id").
+		FindInBatches(&domains, purgeBatchSize, func(batch *gorm.DB, _ int) error {
+			for i := range domains {
+				if err := PurgeDomain(&domains[i], utils.SystemActor); err != nil {
+					return err
+				}
+				domainCount++
+			}
+
+			return nil
+		})
+
+	return appCount, domainCount, result.Error
+}
+
+// StartTrashPurger purges the apps and domains that are deleted longer than TRASH_RETENTION_DAYS ago,
+// every TRASH_PURGE_INTERVAL, until the context is done. A retention of 0 days disables the purge.
+func StartTrashPurger(ctx context.Context) {
+	retention := trashRetentionDays()
+	if retention == 0 {
+		return
+	}
+
+	interval, err := time.ParseDuration(os.Getenv("TRASH_PURGE_INTERVAL"))
+	if err != nil || interval <= 0 {
+		interval = time.Hour
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		cutoff := time.Now().AddDate(0, 0, -retention)
+		if apps, domains, err := PurgeTrash(cutoff); err != nil {
+			log.Errorf("Could not purge the trash: %v", err)
+		} else if apps > 0 || domains > 0 {
+			log.Infof("Purged %d apps and %d domains from the trash", apps, domains)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// trashRetentionDays returns the number of days a deleted app or domain is kept before it is purged.
+func trashRetentionDays() int {
+	if days, err := strconv.Atoi(os.Getenv("TRASH_RETENTION_DAYS")); err == nil && days >= 0 {
+		return days
+	}
+
+	return 30
+}
@@ -0,0 +1,33 @@
+package services
+
+import (
+	"errors"
+	"gorm.io/gorm"
+)
+
+// ErrVersionConflict is returned when an app or domain has been changed since the version a change is based on.
+var ErrVersionConflict = errors.New("the version does not match the current version")
+
+// checkVersion method to increment the version of an app or domain within the transaction of a change,
+// only when it still has the expected version. The row stays locked until the transaction ends,
+// so concurrent changes based on the same version fail with ErrVersionConflict.
+func checkVersion(tx *gorm.DB, model interface{}, id, version uint) error {
+	result := tx.Unscoped().Model(model).
+		Where("id = ? AND version = ?", id, version).
+		UpdateColumn("version", gorm.Expr("version + 1"))
+	if result.Error != nil {
+		return result.Error
+	} else if result.RowsAffected == 0 {
+		return ErrVersionConflict
+	}
+
+	return nil
+}
+
+// bumpVersion method to increment the version of an app or domain within the transaction of a change
+// to its settings or domains, which are part of its representation as well.
+func bumpVersion(tx *gorm.DB, model interface{}, id uint) error {
+	return tx.Unscoped().Model(model).
+		Where("id = ?", id).
+		UpdateColumn("version", gorm.Expr("version + 1")).Error
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"api-app/main/src/database"
+	"api-app/main/src/models"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"github.com/gofiber/fiber/v2/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// webhookBatchSize is the maximum number of deliveries that are sent in a single run.
+const webhookBatchSize = 20
+
+// webhookClient is the HTTP client that sends the webhook deliveries.
+// The address is checked again when it is dialed, so a host that resolves to an internal address
+// after the webhook has been saved is still rejected.
+var webhookClient = &http.Client{
+	Timeout: webhookTimeout(),
+	Transport: &http.Transport{
+		DialContext:         (&net.Dialer{Timeout: webhookTimeout(), Control: publicAddressDialControl}).DialContext,
+		TLSHandshakeTimeout: webhookTimeout(),
+	},
+}
+
+// StartWebhookDispatcher sends the pending webhook deliveries until the context is done.
+func StartWebhookDispatcher(ctx context.Context) {
+	interval, err := time.ParseDuration(os.Getenv("WEBHOOK_POLL_INTERVAL"))
+	if err != nil || interval <= 0 {
+		interval = time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := DispatchWebhookDeliveries(ctx); err != nil {
+				log.Errorf("Could not dispatch webhook deliveries: %v", err)
+			}
+		}
+	}
+}
+
+// DispatchWebhookDeliveries sends a batch of pending webhook deliveries.
+// The deliveries are claimed before they are sent, so no transaction is held during the requests.
+// Failed deliveries are retried with an exponential backoff, and marked as failed
+// after WEBHOOK_MAX_ATTEMPTS attempts.
+func DispatchWebhookDeliveries(ctx context.Context) error {
+	deliveries, err := claimWebhookDeliveries()
+	if err != nil {
+		return err
+	}
+
+	for i := range deliveries {
+		delivery := &deliveries[i]
+
+		statusCode, err := sendWebhookDelivery(ctx, webhookClient, delivery)
+		if ctx.Err() != nil {
+			// The claim expires, so the delivery is sent again by the next run.
+			return nil
+		}
+		applyWebhookDeliveryResult(delivery, statusCode, err, time.Now())
+
+		if err := saveWebhookDeliveryResult(delivery); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// claimWebhookDeliveries claims a batch of pending webhook deliveries by moving their next attempt
+// past the lease, so other dispatchers skip them while they are sent.
+// A delivery of which the result is never saved is claimed again once the lease is over.
+func claimWebhookDeliveries() ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := database.Pg.Transaction(func(tx *gorm.DB) error {
+		if result := tx.Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: "webhook_deliveries"}, Options: "SKIP LOCKED"}).
+			Joins("Webhook").
+			Where("webhook_deliveries.delivered_at IS NULL AND webhook_deliveries.failed_at IS NULL AND webhook_deliveries.next_attempt_at <= ?", time.Now()).
+			Order("webhook_deliveries.id").
+			Limit(webhookBatchSize).
+			Find(&deliveries); result.Error != nil {
+			return result.Error
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, len(deliveries))
+		for i := range deliveries {
+			ids[i] = deliveries[i].ID
+		}
+
+		return tx.Model(&models.WebhookDelivery{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", time.Now().Add(webhookLease())).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// applyWebhookDeliveryResult updates the delivery with the result of an attempt.
+func applyWebhookDeliveryResult(delivery *models.WebhookDelivery, statusCode int, err error, now time.Time) {
+	delivery.Attempts++
+	delivery.StatusCode = sql.NullInt64{Int64: int64(statusCode), Valid: statusCode != 0}
+
+	if err != nil {
+		delivery.LastError = sql.NullString{String: err.Error(), Valid: true}
+		delivery.NextAttemptAt = now.Add(outboxBackoff(delivery.Attempts))
+
+		if delivery.Attempts >= webhookMaxAttempts() {
+			delivery.FailedAt = sql.NullTime{Time: now, Valid: true}
+		}
+	} else {
+		delivery.LastError = sql.NullString{}
+		delivery.DeliveredAt = sql.NullTime{Time: now, Valid: true}
+	}
+}
+
+// saveWebhookDeliveryResult saves the result of an attempt in its own short transaction.
+func saveWebhookDeliveryResult(delivery *models.WebhookDelivery) error {
+	return database.Pg.Model(delivery).
+		Select("Attempts", "StatusCode", "LastError", "NextAttemptAt", "DeliveredAt", "FailedAt").
+		Omit(clause.Associations).
+		Updates(delivery).Error
+}
+
+// SignWebhookPayload returns the hex encoded HMAC-SHA256 signature of the payload.
+func SignWebhookPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// sendWebhookDelivery posts the payload of the delivery to the URL of its webhook.
+// Every response outside the 2xx range is handled as a failure.
+func sendWebhookDelivery(ctx context.Context, client *http.Client, delivery *models.WebhookDelivery) (int, error) {
+	payload := []byte(delivery.Payload)
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Webhook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-Signature", "sha256="+SignWebhookPayload(delivery.Webhook.Secret, payload))
+	request.Header.Set("X-Event-Type", delivery.EventType.String())
+	request.Header.Set("X-Delivery-ID", strconv.FormatUint(uint64(delivery.ID), 10))
+
+	response, err := client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, 1<<16))
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return response.StatusCode, fmt.Errorf("unexpected status code %d", response.StatusCode)
+	}
+
+	return response.StatusCode, nil
+}
+
+// publicAddressDialControl rejects the connections to addresses that are not public,
+// for the requests to hosts that are given by the callers, like webhook deliveries and verification files.
+func publicAddressDialControl(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !isPublicWebhookAddress(ip) {
+		return fmt.Errorf("the address %s is not allowed", host)
+	}
+
+	return nil
+}
+
+// webhookMaxAttempts returns the number of attempts before a delivery is marked as failed.
+func webhookMaxAttempts() uint {
+	if attempts, err := strconv.ParseUint(os.Getenv("WEBHOOK_MAX_ATTEMPTS"), 10, 32); err == nil && attempts > 0 {
+		return uint(attempts)
+	}
+
+	return 10
+}
+
+// webhookLease returns the time a claimed batch of deliveries is reserved for the dispatcher that sends it,
+// which covers the timeouts of all deliveries in the batch.
+func webhookLease() time.Duration {
+	return webhookBatchSize*webhookTimeout() + time.Minute
+}
+
+// webhookTimeout returns the timeout of a single delivery attempt.
+func webhookTimeout() time.Duration {
+	if timeout, err := time.ParseDuration(os.Getenv("WEBHOOK_TIMEOUT")); err == nil && timeout > 0 {
+		return timeout
+	}
+
+	return 10 * time.Second
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"api-app/main/src/enums"
+	"api-app/main/src/models"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testWebhookSecret = "0123456789abcdef"
+
+// newTestWebhookServer starts a server that checks the signature of every delivery,
+// and answers with the status codes in order, repeating the last one.
+func newTestWebhookServer(t *testing.T, statusCodes ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("could not read the body: %v", err)
+		}
+
+		mac := hmac.New(sha256.New, []byte(testWebhookSecret))
+		mac.Write(body)
+		if expected := "sha256=" + hex.EncodeToString(mac.Sum(nil)); r.Header.Get("X-Signature") != expected {
+			t.Errorf("expected signature %s, got %s", expected, r.Header.Get("X-Signature"))
+		}
+		if r.Header.Get("X-Event-Type") != enums.SettingsChanged.String() {
+			t.Errorf("expected event type %s, got %s", enums.SettingsChanged, r.Header.Get("X-Event-Type"))
+		}
+
+		i := int(requests.Add(1)) - 1
+		if i >= len(statusCodes) {
+			i = len(statusCodes) - 1
+		}
+		w.WriteHeader(statusCodes[i])
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &requests
+}
+
+func newTestWebhookDelivery(url string) *models.WebhookDelivery {
+	return &models.WebhookDelivery{
+		ID:        1,
+		EventType: enums.SettingsChanged,
+		Payload:   `{"id":1,"type":"settings.changed"}`,
+		Webhook:   models.Webhook{URL: url, Secret: testWebhookSecret, Active: true},
+	}
+}
+
+func TestSendWebhookDelivery(t *testing.T) {
+	server, requests := newTestWebhookServer(t, http.StatusNoContent)
+	delivery := newTestWebhookDelivery(server.URL)
+
+	statusCode, err := sendWebhookDelivery(context.Background(), server.Client(), delivery)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if statusCode != http.StatusNoContent {
+		t.Errorf("expected status code %d, got %d", http.StatusNoContent, statusCode)
+	}
+
+	applyWebhookDeliveryResult(delivery, statusCode, err, time.Now())
+	if !delivery.DeliveredAt.Valid || delivery.FailedAt.Valid || delivery.LastError.Valid {
+		t.Errorf("expected the delivery to be delivered, got %+v", delivery)
+	}
+	if requests.Load() != 1 {
+		t.Errorf("expected 1 request, got %d", requests.Load())
+	}
+}
+
+func TestWebhookDeliveryRetriesUntilDelivered(t *testing.T) {
+	t.Setenv("WEBHOOK_MAX_ATTEMPTS", "5")
+	server, _ := newTestWebhookServer(t, http.StatusInternalServerError, http.StatusBadGateway, http.StatusOK)
+	delivery := newTestWebhookDelivery(server.URL)
+
+	for attempt := uint(1); attempt <= 3; attempt++ {
+		now := time.Now()
+		statusCode, err := sendWebhookDelivery(context.Background(), server.Client(), delivery)
+		applyWebhookDeliveryResult(delivery, statusCode, err, now)
+
+		if delivery.Attempts != attempt {
+			t.Fatalf("expected %d attempts, got %d", attempt, delivery.Attempts)
+		}
+		if attempt < 3 {
+			if err == nil || !delivery.LastError.Valid {
+				t.Fatalf("expected attempt %d to fail", attempt)
+			}
+			if backoff := delivery.NextAttemptAt.Sub(now); backoff != outboxBackoff(attempt) {
+				t.Errorf("expected a backoff of %s after attempt %d, got %s", outboxBackoff(attempt), attempt, backoff)
+			}
+		}
+	}
+
+	if !delivery.DeliveredAt.Valid || delivery.FailedAt.Valid || delivery.LastError.Valid {
+		t.Errorf("expected the delivery to be delivered, got %+v", delivery)
+	}
+	if delivery.StatusCode.Int64 != http.StatusOK {
+		t.Errorf("expected status code %d, got %d", http.StatusOK, delivery.StatusCode.Int64)
+	}
+}
+
+func TestWebhookDeliveryFailsAfterMaxAttempts(t *testing.T) {
+	t.Setenv("WEBHOOK_MAX_ATTEMPTS", "3")
+	server, requests := newTestWebhookServer(t, http.StatusServiceUnavailable)
+	delivery := newTestWebhookDelivery(server.URL)
+
+	var previousBackoff time.Duration
+	for !delivery.FailedAt.Valid {
+		if delivery.Attempts >= 3 {
+			t.Fatalf("expected the delivery to fail after 3 attempts, got %d", delivery.Attempts)
+		}
+
+		now := time.Now()
+		statusCode, err := sendWebhookDelivery(context.Background(), server.Client(), delivery)
+		applyWebhookDeliveryResult(delivery, statusCode, err, now)
+
+		if backoff := delivery.NextAttemptAt.Sub(now); backoff <= previousBackoff {
+			t.Errorf("expected the backoff to grow, got %s after %s", backoff, previousBackoff)
+		} else {
+			previousBackoff = backoff
+		}
+	}
+
+	if delivery.Attempts != 3 || requests.Load() != 3 {
+		t.Errorf("expected 3 attempts and requests, got %d and %d", delivery.Attempts, requests.Load())
+	}
+	if delivery.DeliveredAt.Valid {
+		t.Error("expected the failed delivery not to be delivered")
+	}
+	if delivery.StatusCode.Int64 != http.StatusServiceUnavailable || !delivery.LastError.Valid {
+		t.Errorf("expected the last status code and error to be kept, got %+v", delivery)
+	}
+}
+
+func TestWebhookClientRejectsInternalAddresses(t *testing.T) {
+	server, requests := newTestWebhookServer(t, http.StatusOK)
+	delivery := newTestWebhookDelivery(server.URL)
+
+	if _, err := sendWebhookDelivery(context.Background(), webhookClient, delivery); err == nil {
+		t.Fatal("expected the delivery to a loopback address to be rejected")
+	}
+	if requests.Load() != 0 {
+		t.Errorf("expected no requests, got %d", requests.Load())
+	}
+}
+
+func TestValidateWebhookURL(t *testing.T) {
+	tests := []struct {
+		url   string
+		valid bool
+	}{
+		{"https://93.184.215.14/hook", true},
+		{"ftp://93.184.215.14/hook", false},
+		{"http://127.0.0.1:8080/hook", false},
+		{"http://10.0.0.1/hook", false},
+		{"http://192.168.1.1/hook", false},
+		{"http://169.254.169.254/latest/meta-data", false},
+		{"http://0.0.0.0/hook", false},
+		{"http://[::1]/hook", false},
+		{"http://[fe80::1]/hook", false},
+	}
+
+	for _, test := range tests {
+		if err := ValidateWebhookURL(context.Background(), test.url); (err == nil) != test.valid {
+			t.Errorf("expected %s to be valid %t, got %v", test.url, test.valid, err)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package services
+
+import (
+	"api-app/main/src/database"
+	"api-app/main/src/dto/requests"
+	"api-app/main/src/enums"
+	"api-app/main/src/models"
+	"api-app/main/src/utils"
+	"context"
+	"encoding/json"
+	"fmt"
+	"gorm.io/gorm"
+	"net"
+	"net/url"
+	"time"
+)
+
+// WebhookPayload struct holds the body that is posted to a webhook.
+type WebhookPayload struct {
+	ID        uint            `json:"id"`
+	Type      string          `json:"type"`
+	AppID     uint            `json:"appId"`
+	DomainID  *uint           `json:"domainId"`
+	Data      json.RawMessage `json:"data"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+// WebhookState struct holds the state of a webhook in the audit log.
+type WebhookState struct {
+	ID     uint            `json:"id"`
+	URL    string          `json:"url"`
+	Events json.RawMessage `json:"events"`
+	Active bool            `json:"active"`
+}
+
+// GetWebhooksByAppID method to get the webhooks of an app.
+func GetWebhooksByAppID(appID uint) (*[]models.Webhook, error) {
+	webhooks := make([]models.Webhook, 0)
+
+	if result := database.Pg.Where("app_id = ?", appID).Order("id").Find(&webhooks); result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &webhooks, nil
+}
+
+// GetWebhookById method to get a webhook of an app by its ID.
+func GetWebhookById(appID, id uint) (*models.Webhook, error) {
+	webhook := &models.Webhook{}
+
+	if result := database.Pg.Find(webhook, "app_id = ? AND id = ?", appID, id); result.Error != nil {
+		return nil, result.Error
+	}
+
+	return webhook, nil
+}
+
+// CreateWebhook method to create a webhook.
+func CreateWebhook(appID uint, request *requests.CreateWebhook, actor *utils.Actor) (*models.Webhook, error) {
+	webhook := &models.Webhook{AppID: appID}
+	if err := setWebhook(webhook, &request.UpdateWebhook); err != nil {
+		return nil, err
+	}
+
+	// Start a new transaction
+	tx := database.Pg.Begin()
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	if result := tx.Create(webhook); result.Error != nil {
+		tx.Rollback()
+		return nil, result.Error
+	}
+
+	if err := recordAudit(tx, actor, enums.AuditWebhookCreated, appID, 0, WebhookAuditTarget(webhook.ID), nil, newWebhookState(webhook)); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+// UpdateWebhook method to update a webhook.
+func UpdateWebhook(webhook *models.Webhook, request *requests.UpdateWebhook, actor *utils.Actor) (*models.Webhook, error) {
+	before := newWebhookState(webhook)
+	if err := setWebhook(webhook, request); err != nil {
+		return nil, err
+	}
+
+	// Start a new transaction
+	tx := database.Pg.Begin()
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	if result := tx.Save(webhook); result.Error != nil {
+		tx.Rollback()
+		return nil, result.Error
+	}
+
+	if err := recordAudit(tx, actor, enums.AuditWebhookUpdated, webhook.AppID, 0, WebhookAuditTarget(webhook.ID), before, newWebhookState(webhook)); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+// DeleteWebhook method to delete a webhook and its delivery log.
+func DeleteWebhook(webhook *models.Webhook, actor *utils.Actor) error {
+	// Start a new transaction
+	tx := database.Pg.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	if result := tx.Delete(webhook); result.Error != nil {
+		tx.Rollback()
+		return result.Error
+	}
+
+	if err := recordAudit(tx, actor, enums.AuditWebhookDeleted, webhook.AppID, 0, WebhookAuditTarget(webhook.ID), newWebhookState(webhook), nil); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// Commit the transaction
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return nil
+}
+
+// WebhookAuditTarget returns the target of the audit events of a webhook.
+func WebhookAuditTarget(webhookID uint) string {
+	return fmt.Sprintf("webhook:%d", webhookID)
+}
+
+// newWebhookState returns the state of a webhook in the audit log, which leaves out its secret.
+func newWebhookState(webhook *models.Webhook) *WebhookState {
+	return &WebhookState{
+		ID:     webhook.ID,
+		URL:    webhook.URL,
+		Events: json.RawMessage(webhook.Events),
+		Active: webhook.Active,
+	}
+}
+
+// ValidateWebhookURL method to check that the URL of a webhook uses HTTP or HTTPS, and that its host
+// only resolves to public addresses, so webhooks can not be used to reach internal services.
+func ValidateWebhookURL(ctx context.Context, rawURL string) error {
+	webhookURL, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if webhookURL.Scheme != "http" && webhookURL.Scheme != "https" {
+		return fmt.Errorf("the scheme %s is not allowed, use http or https", webhookURL.Scheme)
+	}
+	host := webhookURL.Hostname()
+	if host == "" {
+		return fmt.Errorf("the URL has no host")
+	}
+
+	addresses, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("the host %s can not be resolved", host)
+	}
+	for _, address := range addresses {
+		if !isPublicWebhookAddress(address.IP) {
+			return fmt.Errorf("the host %s resolves to the address %s, which is not allowed", host, address.IP)
+		}
+	}
+
+	return nil
+}
+
+// isPublicWebhookAddress method to check that a webhook may be sent to the IP address.
+// Loopback, private, link-local and unspecified addresses are rejected.
+func isPublicWebhookAddress(ip net.IP) bool {
+	return !ip.IsLoopback() &&
+		!ip.IsPrivate() &&
+		!ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() &&
+		!ip.IsUnspecified()
+}
+
+// setWebhook method to set the fields of a webhook from a request.
+func setWebhook(webhook *models.Webhook, request *requests.UpdateWebhook) error {
+	events := request.Events
+	if events == nil {
+		events = make([]string, 0)
+	}
+	value, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+
+	webhook.URL = request.URL
+	webhook.Events = string(value)
+	webhook.Secret = request.Secret
+	webhook.Active = request.Active
+
+	return nil
+}
+
+// enqueueWebhookDeliveries method to add a delivery for every active webhook of the app
+// that is subscribed to the event, within the transaction of the mutation.
+// A webhook without events is subscribed to all events, and a change of the global settings
+// is delivered to the webhooks of every app.
+func enqueueWebhookDeliveries(tx *gorm.DB, event *models.OutboxEvent) error {
+	subscribedTo, err := json.Marshal([]string{event.Type.String()})
+	if err != nil {
+		return err
+	}
+
+	query := tx.Model(&models.Webhook{}).Where("active = ?", true)
+	if event.AppID != 0 {
+		query = query.Where("app_id = ?", event.AppID)
+	} else if event.Type != enums.SettingsChanged {
+		return nil
+	}
+
+	var webhookIDs []uint
+	if result := query.
+		Where("(events = '[]'::jsonb OR events @> ?::jsonb)", string(subscribedTo)).
+		Pluck("id", &webhookIDs); result.Error != nil {
+		return result.Error
+	}
+	if len(webhookIDs) == 0 {
+		return nil
+	}
+
+	payload := WebhookPayload{
+		ID:        event.ID,
+		Type:      event.Type.String(),
+		AppID:     event.AppID,
+		Data:      json.RawMessage(event.Payload),
+		CreatedAt: event.CreatedAt,
+	}
+	if event.DomainID.Valid {
+		domainID := uint(event.DomainID.Int64)
+		payload.DomainID = &domainID
+	}
+	value, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	deliveries := make([]models.WebhookDelivery, len(webhookIDs))
+	for i := range webhookIDs {
+		deliveries[i] = models.WebhookDelivery{
+			WebhookID:     webhookIDs[i],
+			EventID:       event.ID,
+			EventType:     event.Type,
+			Payload:       string(value),
+			NextAttemptAt: event.CreatedAt,
+		}
+	}
+
+	return tx.Create(&deliveries).Error
+}
@@ -1,24 +1,94 @@
 package utils
 
 import (
+	"errors"
+	"golang.org/x/net/idna"
+	"golang.org/x/net/publicsuffix"
+	"net"
 	"strings"
 )
 
+// maxDomainLength is the maximum length of a hostname in its ASCII form.
+const maxDomainLength = 253
+
+// domainProfile converts hostnames to their ASCII (punycode) form and rejects invalid hostnames.
+var domainProfile = idna.New(
+	idna.MapForLookup(),
+	idna.BidiRule(),
+	idna.VerifyDNSLength(true),
+	idna.StrictDomainName(true),
+)
+
+// wildcardPrefix is the first label of a wildcard domain, like "*.example.com".
+const wildcardPrefix = "*."
+
+// IsWildcardDomain checks if the domain name is a wildcard domain.
+func IsWildcardDomain(domainName string) bool {
+	return strings.HasPrefix(domainName, wildcardPrefix)
+}
+
+// NormalizeDomain returns the canonical form of a hostname or wildcard domain.
+// The canonical form is lowercase punycode without a trailing dot.
+func NormalizeDomain(domainName string) (string, error) {
+	name := strings.TrimSuffix(strings.TrimSpace(domainName), ".")
+	if IsWildcardDomain(name) {
+		if IsWildcardDomain(strings.TrimPrefix(name, wildcardPrefix)) {
+			return "", errors.New("domain name " + domainName + " has more than one wildcard")
+		}
+		name, err := NormalizeDomain(strings.TrimPrefix(name, wildcardPrefix))
+		if err != nil {
+			return "", err
+		}
+
+		return wildcardPrefix + name, nil
+	}
+	if name == "" {
+		return "", errors.New("domain name is empty")
+	}
+	if net.ParseIP(name) != nil {
+		return "", errors.New("domain name " + domainName + " is an IP address")
+	}
+
+	name, err := domainProfile.ToASCII(name)
+	if err != nil {
+		return "", errors.New("domain name " + domainName + " is invalid: " + err.Error())
+	}
+	if len(name) > maxDomainLength {
+		return "", errors.New("domain name " + domainName + " is too long")
+	}
+
+	return strings.ToLower(name), nil
+}
+
 // ExtractDomain extracts the subdomain, second-level domain, and top-level domain from a given domain name.
-func ExtractDomain(domainName string) (subdomain, secondLevelDomain, topLevelDomain string) {
-	parts := strings.Split(domainName, ".")
-	if len(parts) < 2 {
-		return "", "", ""
+// The top-level domain is the public suffix of the Public Suffix List, like "co.uk",
+// and the second-level domain is the label that is registered under it.
+// The subdomain of a wildcard domain starts with "*".
+func ExtractDomain(domainName string) (subdomain, secondLevelDomain, topLevelDomain string, err error) {
+	name, err := NormalizeDomain(domainName)
+	if err != nil {
+		return "", "", "", err
 	}
+	if IsWildcardDomain(name) {
+		subdomain, secondLevelDomain, topLevelDomain, err = ExtractDomain(strings.TrimPrefix(name, wildcardPrefix))
+		if err != nil {
+			return "", "", "", err
+		}
+		if subdomain != "" {
+			return "*." + subdomain, secondLevelDomain, topLevelDomain, nil
+		}
 
-	topLevelDomain = parts[len(parts)-1]
-	secondLevelDomain = parts[len(parts)-2]
+		return "*", secondLevelDomain, topLevelDomain, nil
+	}
 
-	if len(parts) > 2 {
-		subdomain = strings.Join(parts[:len(parts)-2], ".")
-	} else {
-		subdomain = ""
+	registered, err := publicsuffix.EffectiveTLDPlusOne(name)
+	if err != nil {
+		return "", "", "", errors.New("domain name " + domainName + " has no registrable domain")
 	}
 
-	return subdomain, secondLevelDomain, topLevelDomain
+	topLevelDomain, _ = publicsuffix.PublicSuffix(name)
+	secondLevelDomain = strings.TrimSuffix(registered, "."+topLevelDomain)
+	subdomain = strings.TrimSuffix(strings.TrimSuffix(name, registered), ".")
+
+	return subdomain, secondLevelDomain, topLevelDomain, nil
 }
@@ -0,0 +1,36 @@
+package utils
+
+import "github.com/gofiber/fiber/v2"
+
+// Actor struct holds who performs a request, as it is stored in the audit log.
+// The name is the authenticated machine or principal. The claimed name is the unverified x-actor header,
+// which the caller may set to anything, so it is only kept for reference.
+type Actor struct {
+	Name        string
+	ClaimedName string
+	IpAddress   string
+	RequestID   string
+}
+
+// ActorLocal is the key of the local that holds the name of the authenticated machine or principal of a request.
+const ActorLocal = "actor"
+
+// SystemActor is the actor of the changes that are made by the API itself, like purging the trash.
+var SystemActor = &Actor{Name: "system"}
+
+// GetActor returns the actor that performs the request.
+// The name is the authenticated machine or principal and defaults to "unknown", the claimed name is read from
+// the x-actor header, and the request ID is read from the X-Request-ID response header.
+func GetActor(c *fiber.Ctx) *Actor {
+	actor := &Actor{
+		Name:        "unknown",
+		ClaimedName: c.Get("x-actor"),
+		IpAddress:   c.IP(),
+		RequestID:   c.GetRespHeader(fiber.HeaderXRequestID),
+	}
+	if name, _ := c.Locals(ActorLocal).(string); name != "" {
+		actor.Name = name
+	}
+
+	return actor
+}
@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"golang.org/x/net/publicsuffix"
+	"strings"
+)
+
+// HostCandidates returns the domain names that can match a normalized host, from the most to the least specific.
+// The first candidate is the host itself, followed by the wildcards of its parent domains,
+// like "*.shop.example.com" and "*.example.com" for "www.shop.example.com".
+// Wildcards directly below a public suffix, like "*.co.uk", are never returned.
+func HostCandidates(host string) []string {
+	candidates := []string{host}
+	suffix, _ := publicsuffix.PublicSuffix(host)
+
+	parent := host
+	for {
+		i := strings.IndexByte(parent, '.')
+		if i < 0 {
+			break
+		}
+		parent = parent[i+1:]
+		if parent == suffix || strings.HasSuffix(suffix, "."+parent) {
+			break
+		}
+		candidates = append(candidates, "*."+parent)
+	}
+
+	return candidates
+}
@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"slices"
+	"strings"
+)
+
+// Expansion holds the relations and fields of a read, requested with the include and fields query parameters.
+// A nil Fields returns every field.
+type Expansion struct {
+	Includes map[string]bool
+	Fields   map[string]bool
+}
+
+// ParseExpansion parses the comma separated include and fields query parameters of a read.
+// Without an include parameter the default relations are included, an empty include parameter includes none.
+// A nested relation, like "domains.settings", includes its parent as well.
+func ParseExpansion(c *fiber.Ctx, defaultIncludes, allowedIncludes, allowedFields []string) (*Expansion, error) {
+	expansion := &Expansion{Includes: make(map[string]bool)}
+
+	includes := defaultIncludes
+	if c.Request().URI().QueryArgs().Has("include") {
+		includes = splitList(c.Query("include"))
+	}
+	for _, include := range includes {
+		if !slices.Contains(allowedIncludes, include) {
+			return nil, fmt.Errorf("invalid include %q", include)
+		}
+		for i := range include {
+			if include[i] == '.' {
+				expansion.Includes[include[:i]] = true
+			}
+		}
+		expansion.Includes[include] = true
+	}
+
+	if c.Request().URI().QueryArgs().Has("fields") {
+		expansion.Fields = make(map[string]bool)
+		for _, field := range splitList(c.Query("fields")) {
+			if !slices.Contains(allowedFields, field) {
+				return nil, fmt.Errorf("invalid field %q", field)
+			}
+			expansion.Fields[field] = true
+		}
+	}
+
+	return expansion, nil
+}
+
+// Include returns true when the relation is included.
+func (e *Expansion) Include(relation string) bool {
+	return e.Includes[relation]
+}
+
+// SelectFields returns the JSON object of the value with only the requested fields and the included relations.
+// The value is returned as it is when every field is requested.
+func (e *Expansion) SelectFields(value interface{}) (interface{}, error) {
+	if e.Fields == nil {
+		return value, nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	object := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, err
+	}
+
+	for key := range object {
+		if !e.Fields[key] && !e.Includes[key] {
+			delete(object, key)
+		}
+	}
+
+	return object, nil
+}
+
+// splitList splits a comma separated list, without empty and surrounding whitespace.
+func splitList(list string) []string {
+	var values []string
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"strings"
+)
+
+// CompileJSONSchema compiles a JSON Schema document, so it can be used to validate values.
+func CompileJSONSchema(schema string) (*jsonschema.Schema, error) {
+	document, err := jsonschema.UnmarshalJSON(strings.NewReader(schema))
+	if err != nil {
+		return nil, err
+	}
+
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource("schema.json", document); err != nil {
+		return nil, err
+	}
+
+	return compiler.Compile("schema.json")
+}
+
+// ValidateJSONSchema validates a JSON value against a JSON Schema document.
+func ValidateJSONSchema(schema, value string) error {
+	compiled, err := CompileJSONSchema(schema)
+	if err != nil {
+		return err
+	}
+
+	instance, err := jsonschema.UnmarshalJSON(strings.NewReader(value))
+	if err != nil {
+		return err
+	}
+
+	return compiled.Validate(instance)
+}
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"strconv"
+	"strings"
+)
+
+// VersionETag returns the strong ETag of a version, like "3".
+func VersionETag(version uint) string {
+	return `"` + strconv.FormatUint(uint64(version), 10) + `"`
+}
+
+// GetIfMatchVersion returns the version of the If-Match header, and false when the header is missing.
+// The wildcard "*" returns version 0, which stands for any version.
+// Weak ETags are rejected, because the version has to match exactly.
+func GetIfMatchVersion(c *fiber.Ctx) (uint, bool, error) {
+	ifMatch := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+	if ifMatch == "" {
+		return 0, false, nil
+	} else if ifMatch == "*" {
+		return 0, true, nil
+	}
+
+	if len(ifMatch) < 3 || ifMatch[0] != '"' || ifMatch[len(ifMatch)-1] != '"' {
+		return 0, true, errors.New("the If-Match header must be a single strong ETag")
+	}
+	version, err := strconv.ParseUint(ifMatch[1:len(ifMatch)-1], 10, 32)
+	if err != nil || version == 0 {
+		return 0, true, errors.New("the If-Match header is not a version of this API")
+	}
+
+	return uint(version), true, nil
+}